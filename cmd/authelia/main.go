@@ -11,6 +11,7 @@ import (
 	"github.com/authelia/authelia/internal/authorization"
 	"github.com/authelia/authelia/internal/commands"
 	"github.com/authelia/authelia/internal/configuration"
+	"github.com/authelia/authelia/internal/geoip"
 	"github.com/authelia/authelia/internal/logging"
 	"github.com/authelia/authelia/internal/middlewares"
 	"github.com/authelia/authelia/internal/notification"
@@ -66,16 +67,8 @@ func startServer() {
 		logging.Logger().Fatalf("Unrecognized authentication backend")
 	}
 
-	var storageProvider storage.Provider
-
-	switch {
-	case config.Storage.PostgreSQL != nil:
-		storageProvider = storage.NewPostgreSQLProvider(*config.Storage.PostgreSQL)
-	case config.Storage.MySQL != nil:
-		storageProvider = storage.NewMySQLProvider(*config.Storage.MySQL)
-	case config.Storage.Local != nil:
-		storageProvider = storage.NewSQLiteProvider(config.Storage.Local.Path)
-	default:
+	storageProvider := storage.NewProvider(config.Storage)
+	if storageProvider == nil {
 		logging.Logger().Fatalf("Unrecognized storage backend")
 	}
 
@@ -98,8 +91,20 @@ func startServer() {
 	}
 
 	clock := utils.RealClock{}
-	authorizer := authorization.NewAuthorizer(config.AccessControl)
-	sessionProvider := session.NewProvider(config.Session)
+
+	// The generations of the sessions are checked on every request of the users.
+	storageProvider = storage.NewSessionGenerationCache(storageProvider, storage.SessionGenerationCacheTTL, clock)
+
+	authorizer := authorization.NewAuthorizer(config.AccessControl, clock)
+	authorizer.SetDomains(config.Domains)
+
+	if err := authorizer.ReloadGrants(storageProvider); err != nil {
+		logging.Logger().Fatalf("Unable to load access grants: %s", err)
+	}
+
+	authorizer.StartGrantsReload(storageProvider, authorization.GrantsReloadInterval)
+
+	sessionProvider := session.NewProvider(config.Session, config.Domains, config.Server.TrustedProxies, storageProvider)
 	regulator := regulation.NewRegulator(config.Regulation, storageProvider, clock)
 
 	providers := middlewares.Providers{
@@ -110,6 +115,22 @@ func startServer() {
 		Notifier:        notifier,
 		SessionProvider: sessionProvider,
 	}
+
+	if config.GeoIP != nil {
+		geoIPProvider, err := geoip.NewMaxMindProvider(*config.GeoIP)
+		if err != nil {
+			logging.Logger().Fatalf("Unable to load the GeoIP database: %s", err)
+		}
+
+		// Skip Error Check since validator checks it.
+		reloadInterval, _ := utils.ParseDurationString(config.GeoIP.ReloadInterval)
+		if reloadInterval > 0 {
+			geoIPProvider.StartAutoReload(clock, reloadInterval)
+		}
+
+		providers.GeoIP = geoIPProvider
+	}
+
 	server.StartServer(*config, providers)
 }
 
@@ -132,7 +153,7 @@ func main() {
 	}
 
 	rootCmd.AddCommand(versionCmd, commands.HashPasswordCmd,
-		commands.ValidateConfigCmd, commands.CertificatesCmd)
+		commands.ValidateConfigCmd, commands.CertificatesCmd, commands.GrantsCmd, commands.SessionsCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		logging.Logger().Fatal(err)
@@ -13,14 +13,14 @@ require (
 	github.com/duosecurity/duo_api_golang v0.0.0-20190308151101-6c680f768e74
 	github.com/facebookgo/stack v0.0.0-20160209184415-751773369052 // indirect
 	github.com/fasthttp/router v1.2.4
-	github.com/fasthttp/session/v2 v2.2.1
+	github.com/fasthttp/session/v2 v2.3.2
 	github.com/go-ldap/ldap/v3 v3.2.3
-	github.com/go-sql-driver/mysql v1.5.0
+	github.com/go-redis/redis/v8 v8.3.4
+	github.com/go-sql-driver/mysql v1.6.0
 	github.com/golang/mock v1.4.4
 	github.com/jackc/pgx/v4 v4.8.1
 	github.com/mattn/go-sqlite3 v2.0.3+incompatible
-	github.com/onsi/ginkgo v1.10.3 // indirect
-	github.com/onsi/gomega v1.7.1 // indirect
+	github.com/oschwald/maxminddb-golang v1.3.1
 	github.com/otiai10/copy v1.2.0
 	github.com/pelletier/go-toml v1.4.0 // indirect
 	github.com/pquerna/otp v1.2.0
@@ -31,7 +31,7 @@ require (
 	github.com/stretchr/testify v1.6.1
 	github.com/tebeka/selenium v0.9.9
 	github.com/tstranex/u2f v1.0.0
-	github.com/valyala/fasthttp v1.15.1
-	golang.org/x/text v0.3.3
+	github.com/valyala/fasthttp v1.24.0
+	golang.org/x/text v0.3.5
 	gopkg.in/yaml.v2 v2.3.0
 )
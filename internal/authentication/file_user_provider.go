@@ -27,6 +27,9 @@ type UserDetailsModel struct {
 	DisplayName    string   `yaml:"displayname" valid:"required"`
 	Email          string   `yaml:"email"`
 	Groups         []string `yaml:"groups"`
+
+	// The extra attributes of the user, indexed by attribute name.
+	Attributes map[string][]string `yaml:"attributes,omitempty"`
 }
 
 // DatabaseModel is the model of users file database.
@@ -166,6 +169,7 @@ func (p *FileUserProvider) GetDetails(username string) (*UserDetails, error) {
 			DisplayName: details.DisplayName,
 			Groups:      details.Groups,
 			Emails:      []string{details.Email},
+			Attributes:  details.Attributes,
 		}, nil
 	}
 
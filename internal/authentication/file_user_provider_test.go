@@ -139,6 +139,7 @@ func TestShouldRetrieveUserDetails(t *testing.T) {
 		assert.Equal(t, details.Username, "john")
 		assert.Equal(t, details.Emails, []string{"john.doe@authelia.com"})
 		assert.Equal(t, details.Groups, []string{"admins", "dev"})
+		assert.Equal(t, details.Attributes, map[string][]string{"department": {"finance"}})
 	})
 }
 
@@ -293,6 +294,9 @@ users:
     groups:
       - admins
       - dev
+    attributes:
+      department:
+        - finance
 
   harry:
     displayname: "Harry Potter"
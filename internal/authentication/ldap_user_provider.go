@@ -144,6 +144,7 @@ type ldapUserProfile struct {
 	Emails      []string
 	DisplayName string
 	Username    string
+	Attributes  map[string][]string
 }
 
 func (p *LDAPUserProvider) resolveUsersFilter(userFilter string, inputUsername string) string {
@@ -168,6 +169,7 @@ func (p *LDAPUserProvider) getUserProfile(conn LDAPConnection, inputUsername str
 		p.configuration.DisplayNameAttribute,
 		p.configuration.MailAttribute,
 		p.configuration.UsernameAttribute}
+	attributes = append(attributes, p.configuration.ExtraAttributes...)
 
 	// Search for the given username.
 	searchRequest := ldap.NewSearchRequest(
@@ -193,6 +195,15 @@ func (p *LDAPUserProvider) getUserProfile(conn LDAPConnection, inputUsername str
 	}
 
 	for _, attr := range sr.Entries[0].Attributes {
+		// The names of the attributes are case insensitive, the attributes are kept under their configured names.
+		if name, ok := p.getExtraAttributeName(attr.Name); ok {
+			if userProfile.Attributes == nil {
+				userProfile.Attributes = make(map[string][]string)
+			}
+
+			userProfile.Attributes[name] = attr.Values
+		}
+
 		if attr.Name == p.configuration.DisplayNameAttribute {
 			userProfile.DisplayName = attr.Values[0]
 		}
@@ -218,6 +229,18 @@ func (p *LDAPUserProvider) getUserProfile(conn LDAPConnection, inputUsername str
 	return &userProfile, nil
 }
 
+// getExtraAttributeName returns the configured name of the extra attribute matching the name of an attribute of the
+// profile of a user, regardless of their case.
+func (p *LDAPUserProvider) getExtraAttributeName(name string) (string, bool) {
+	for _, extraAttribute := range p.configuration.ExtraAttributes {
+		if strings.EqualFold(extraAttribute, name) {
+			return extraAttribute, true
+		}
+	}
+
+	return "", false
+}
+
 func (p *LDAPUserProvider) resolveGroupsFilter(inputUsername string, profile *ldapUserProfile) (string, error) { //nolint:unparam
 	inputUsername = p.ldapEscape(inputUsername)
 
@@ -285,6 +308,7 @@ func (p *LDAPUserProvider) GetDetails(inputUsername string) (*UserDetails, error
 		DisplayName: profile.DisplayName,
 		Emails:      profile.Emails,
 		Groups:      groups,
+		Attributes:  profile.Attributes,
 	}, nil
 }
 
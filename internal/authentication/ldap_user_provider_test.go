@@ -392,6 +392,78 @@ func TestShouldReturnUsernameFromLDAP(t *testing.T) {
 	assert.Equal(t, details.Username, "John")
 }
 
+func TestShouldReturnExtraAttributesFromLDAP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFactory := NewMockLDAPConnectionFactory(ctrl)
+	mockConn := NewMockLDAPConnection(ctrl)
+
+	ldapClient := NewLDAPUserProviderWithFactory(schema.LDAPAuthenticationBackendConfiguration{
+		URL:                  "ldap://127.0.0.1:389",
+		User:                 "cn=admin,dc=example,dc=com",
+		Password:             "password",
+		UsernameAttribute:    "uid",
+		MailAttribute:        "mail",
+		DisplayNameAttribute: "displayname",
+		ExtraAttributes:      []string{"department", "title"},
+		UsersFilter:          "uid={input}",
+		AdditionalUsersDN:    "ou=users",
+		BaseDN:               "dc=example,dc=com",
+	}, mockFactory)
+
+	mockFactory.EXPECT().
+		Dial(gomock.Eq("tcp"), gomock.Eq("127.0.0.1:389")).
+		Return(mockConn, nil)
+
+	mockConn.EXPECT().
+		Bind(gomock.Eq("cn=admin,dc=example,dc=com"), gomock.Eq("password")).
+		Return(nil)
+
+	mockConn.EXPECT().
+		Close()
+
+	searchGroups := mockConn.EXPECT().
+		Search(gomock.Any()).
+		Return(createSearchResultWithAttributeValues("group1"), nil)
+	searchProfile := mockConn.EXPECT().
+		Search(gomock.Any()).
+		DoAndReturn(func(request *ldap.SearchRequest) (*ldap.SearchResult, error) {
+			assert.Contains(t, request.Attributes, "department")
+			assert.Contains(t, request.Attributes, "title")
+
+			return &ldap.SearchResult{
+				Entries: []*ldap.Entry{
+					{
+						DN: "uid=test,dc=example,dc=com",
+						Attributes: []*ldap.EntryAttribute{
+							{
+								Name:   "uid",
+								Values: []string{"john"},
+							},
+							{
+								Name:   "department",
+								Values: []string{"finance", "audit"},
+							},
+							{
+								Name:   "Title",
+								Values: []string{"Auditor"},
+							},
+						},
+					},
+				},
+			}, nil
+		})
+
+	gomock.InOrder(searchProfile, searchGroups)
+
+	details, err := ldapClient.GetDetails("john")
+	require.NoError(t, err)
+
+	// The attributes are kept under their configured names whatever the case returned by the server.
+	assert.Equal(t, map[string][]string{"department": {"finance", "audit"}, "title": {"Auditor"}}, details.Attributes)
+}
+
 func TestShouldCallStartTLSWhenEnabled(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
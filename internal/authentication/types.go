@@ -6,4 +6,6 @@ type UserDetails struct {
 	DisplayName string
 	Emails      []string
 	Groups      []string
+	// The extra attributes of the user, indexed by attribute name.
+	Attributes map[string][]string
 }
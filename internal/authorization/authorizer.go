@@ -3,59 +3,151 @@ package authorization
 import (
 	"fmt"
 	"net"
+	"net/http"
 	"net/url"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/authelia/authelia/internal/configuration/schema"
 	"github.com/authelia/authelia/internal/logging"
+	"github.com/authelia/authelia/internal/utils"
 )
 
 const userPrefix = "user:"
 const groupPrefix = "group:"
+const emailPrefix = "email:"
+const attributePrefix = "attr:"
+const negationPrefix = "!"
 
 // Authorizer the component in charge of checking whether a user can access a given resource.
 type Authorizer struct {
 	configuration schema.AccessControlConfiguration
+	rules         []aclRule
+	clock         utils.Clock
+
+	grantsMutex      sync.RWMutex
+	grants           []aclRule
+	stopGrantsReload func()
+
+	// The policy deciding the rules having the 'external' policy, nil if not configured.
+	external *externalPolicy
+
+	// The protected root domains whose default policy overrides the default policy of the configuration.
+	domains     []schema.DomainConfiguration
+	domainNames []string
+}
+
+// aclRule is an ACL rule with its networks and network aliases parsed once for all.
+type aclRule struct {
+	schema.ACLRule
+
+	// The index of the rule in the configuration.
+	index int
+
+	// The parsed networks of the rule, nil if the rule applies to any network.
+	networks []*net.IPNet
+
+	// The parsed schedule of the rule, nil if the rule is always active.
+	schedule *schedule
+
+	// The parsed header and query parameter conditions of the rule.
+	headers []requestMatcher
+	query   []requestMatcher
+
+	// The time an access grant expires, zero for the rules of the configuration.
+	expiresAt time.Time
 }
 
-// NewAuthorizer create an instance of authorizer with a given access control configuration.
-func NewAuthorizer(configuration schema.AccessControlConfiguration) *Authorizer {
-	return &Authorizer{
+// NewAuthorizer create an instance of authorizer with a given access control configuration, the clock is used
+// to check the schedules of the rules.
+func NewAuthorizer(configuration schema.AccessControlConfiguration, clock utils.Clock) *Authorizer {
+	rules := make([]aclRule, 0, len(configuration.Rules))
+
+	for i, rule := range configuration.Rules {
+		rules = append(rules, aclRule{
+			ACLRule:  rule,
+			index:    i,
+			networks: parseNetworks(rule.Networks, configuration.Networks),
+			schedule: parseSchedule(rule.Schedule),
+			headers:  parseRequestMatchers(rule.Headers),
+			query:    parseRequestMatchers(rule.Query),
+		})
+	}
+
+	authorizer := &Authorizer{
 		configuration: configuration,
+		rules:         rules,
+		clock:         clock,
+	}
+
+	if configuration.External != nil {
+		authorizer.external = newExternalPolicy(*configuration.External, clock)
 	}
+
+	return authorizer
 }
 
 // Subject subject who to check access control for.
 type Subject struct {
 	Username string
 	Groups   []string
-	IP       net.IP
+	Emails   []string
+	// The extra attributes of the user, indexed by attribute name.
+	Attributes map[string][]string
+	IP         net.IP
+	// The ISO 3166-1 alpha-2 code of the country of the IP, empty when unknown.
+	Country string
 }
 
 func (s Subject) String() string {
-	return fmt.Sprintf("username=%s groups=%s ip=%s", s.Username, strings.Join(s.Groups, ","), s.IP.String())
+	return fmt.Sprintf("username=%s groups=%s ip=%s country=%s", s.Username, strings.Join(s.Groups, ","), s.IP.String(),
+		s.Country)
 }
 
 // Object object to check access control for.
 type Object struct {
+	URL    url.URL
 	Domain string
 	Path   string
+	Query  url.Values
+	// The headers of the request forwarded by the proxy, they are not part of the string representation since they
+	// may contain credentials.
+	Headers http.Header
+}
+
+// NewObject create the object of a request to the given URL carrying the given headers.
+func NewObject(requestURL url.URL, headers http.Header) Object {
+	return Object{
+		URL:     requestURL,
+		Domain:  requestURL.Hostname(),
+		Path:    requestURL.Path,
+		Query:   requestURL.Query(),
+		Headers: headers,
+	}
+}
+
+func (o Object) String() string {
+	return o.URL.String()
 }
 
 // selectMatchingSubjectRules take a set of rules and select only the rules matching the subject constraints.
-func selectMatchingSubjectRules(rules []schema.ACLRule, subject Subject) []schema.ACLRule {
-	selectedRules := []schema.ACLRule{}
+func selectMatchingSubjectRules(rules []aclRule, subject Subject) []aclRule {
+	selectedRules := []aclRule{}
 
 	for _, rule := range rules {
+		isLocationMatching := isIPMatching(subject.IP, rule.networks) &&
+			isCountryMatching(subject.Country, rule.Countries, rule.NotCountries)
+
 		switch {
 		case len(rule.Subjects) > 0:
 			for _, subjectRule := range rule.Subjects {
-				if isSubjectMatching(subject, subjectRule) && isIPMatching(subject.IP, rule.Networks) {
+				if isSubjectMatching(subject, subjectRule) && isLocationMatching {
 					selectedRules = append(selectedRules, rule)
 				}
 			}
 		default:
-			if isIPMatching(subject.IP, rule.Networks) {
+			if isLocationMatching {
 				selectedRules = append(selectedRules, rule)
 			}
 		}
@@ -64,11 +156,25 @@ func selectMatchingSubjectRules(rules []schema.ACLRule, subject Subject) []schem
 	return selectedRules
 }
 
-func selectMatchingObjectRules(rules []schema.ACLRule, object Object) []schema.ACLRule {
-	selectedRules := []schema.ACLRule{}
+func selectMatchingObjectRules(rules []aclRule, object Object) []aclRule {
+	selectedRules := []aclRule{}
+
+	for _, rule := range rules {
+		if isDomainMatching(object.Domain, rule.Domains) && isPathMatching(object.Path, rule.Resources) &&
+			isQueryMatching(object.Query, rule.query) && isHeadersMatching(object.Headers, rule.headers) {
+			selectedRules = append(selectedRules, rule)
+		}
+	}
+
+	return selectedRules
+}
+
+// selectMatchingScheduleRules take a set of rules and select only the rules active at the given time.
+func selectMatchingScheduleRules(rules []aclRule, now time.Time) []aclRule {
+	selectedRules := []aclRule{}
 
 	for _, rule := range rules {
-		if isDomainMatching(object.Domain, rule.Domains) && isPathMatching(object.Path, rule.Resources) {
+		if isScheduleMatching(rule.schedule, now) {
 			selectedRules = append(selectedRules, rule)
 		}
 	}
@@ -76,9 +182,25 @@ func selectMatchingObjectRules(rules []schema.ACLRule, object Object) []schema.A
 	return selectedRules
 }
 
-func selectMatchingRules(rules []schema.ACLRule, subject Subject, object Object) []schema.ACLRule {
+func selectMatchingRules(rules []aclRule, subject Subject, object Object, now time.Time) []aclRule {
 	matchingRules := selectMatchingSubjectRules(rules, subject)
-	return selectMatchingObjectRules(matchingRules, object)
+	matchingRules = selectMatchingObjectRules(matchingRules, object)
+
+	return selectMatchingScheduleRules(matchingRules, now)
+}
+
+// LevelToPolicy converts an authorization level to its string policy.
+func LevelToPolicy(level Level) string {
+	switch level {
+	case Bypass:
+		return "bypass"
+	case OneFactor:
+		return "one_factor"
+	case TwoFactor:
+		return "two_factor"
+	}
+
+	return denyPolicy
 }
 
 // PolicyToLevel converts a string policy to int authorization level.
@@ -97,14 +219,43 @@ func PolicyToLevel(policy string) Level {
 	return Denied
 }
 
+// SetDomains sets the protected root domains whose default policy, if any, applies to the resources under them
+// instead of the default policy of the configuration.
+func (p *Authorizer) SetDomains(domains []schema.DomainConfiguration) {
+	p.domains = domains
+	p.domainNames = make([]string, 0, len(domains))
+
+	for _, domain := range domains {
+		p.domainNames = append(p.domainNames, domain.Domain)
+	}
+}
+
+// getDefaultPolicy retrieve the default policy of the most specific protected root domain of the host, the default
+// policy of the configuration if the domain has none.
+func (p *Authorizer) getDefaultPolicy(host string) string {
+	i := utils.GetMostSpecificDomain(host, p.domainNames)
+	if i == -1 || p.domains[i].DefaultPolicy == "" {
+		return p.configuration.DefaultPolicy
+	}
+
+	return p.domains[i].DefaultPolicy
+}
+
 // IsSecondFactorEnabled return true if at least one policy is set to second factor.
 func (p *Authorizer) IsSecondFactorEnabled() bool {
 	if PolicyToLevel(p.configuration.DefaultPolicy) == TwoFactor {
 		return true
 	}
 
+	for _, domain := range p.domains {
+		if PolicyToLevel(domain.DefaultPolicy) == TwoFactor {
+			return true
+		}
+	}
+
 	for _, r := range p.configuration.Rules {
-		if PolicyToLevel(r.Policy) == TwoFactor {
+		// The external endpoint may require two factor.
+		if PolicyToLevel(r.Policy) == TwoFactor || r.Policy == schema.ExternalPolicy {
 			return true
 		}
 	}
@@ -112,34 +263,92 @@ func (p *Authorizer) IsSecondFactorEnabled() bool {
 	return false
 }
 
-// GetRequiredLevel retrieve the required level of authorization to access the object.
-func (p *Authorizer) GetRequiredLevel(subject Subject, requestURL url.URL) Level {
+// GetMatchingRule retrieve the index of the first rule matching the subject and the object and the rule itself,
+// -1 and nil if there is none and the default policy applies. Access grants are checked before the rules of the
+// configuration and have a -1 index, they are identified by their name instead.
+func (p *Authorizer) GetMatchingRule(subject Subject, object Object) (int, *schema.ACLRule) {
+	now := p.clock.Now()
+
+	matchingRules := selectMatchingRules(append(p.getActiveGrants(now), p.rules...), subject, object, now)
+
+	if len(matchingRules) == 0 {
+		return -1, nil
+	}
+
+	index := matchingRules[0].index
+	rule := matchingRules[0].ACLRule
+
+	if subject.Country == "" && (len(rule.Countries) > 0 || len(rule.NotCountries) > 0) {
+		logging.Logger().Warnf("Country of subject %s is unknown while required by the rule matching url %s... "+
+			"Denying access.", subject.String(), object.String())
+
+		// The rule is denied but keeps its other settings, for instance how the denial is rendered.
+		rule.Policy = denyPolicy
+	}
+
+	return index, &rule
+}
+
+// GetDecision retrieve the rule applied to the subject accessing the object and the level it requires.
+func (p *Authorizer) GetDecision(subject Subject, object Object) Decision {
 	logging.Logger().Tracef("Check authorization of subject %s and url %s.",
-		subject.String(), requestURL.String())
+		subject.String(), object.String())
 
-	matchingRules := selectMatchingRules(p.configuration.Rules, subject, Object{
-		Domain: requestURL.Hostname(),
-		Path:   requestURL.Path,
-	})
+	index, rule := p.GetMatchingRule(subject, object)
+	if rule != nil {
+		if rule.Policy == schema.ExternalPolicy && p.external != nil {
+			return Decision{Index: index, Rule: rule, Level: p.external.GetRequiredLevel(subject, object)}
+		}
 
-	if len(matchingRules) > 0 {
-		return PolicyToLevel(matchingRules[0].Policy)
+		return Decision{Index: index, Rule: rule, Level: PolicyToLevel(rule.Policy)}
 	}
 
 	logging.Logger().Tracef("No matching rule for subject %s and url %s... Applying default policy.",
-		subject.String(), requestURL.String())
+		subject.String(), object.String())
+
+	return Decision{Index: index, Level: PolicyToLevel(p.getDefaultPolicy(object.Domain)),
+		defaultDenyBehavior: p.configuration.DefaultDenyBehavior}
+}
+
+// GetRequiredLevel retrieve the required level of authorization to access the object.
+func (p *Authorizer) GetRequiredLevel(subject Subject, object Object) Level {
+	return p.GetDecision(subject, object).Level
+}
+
+// GetRequiredMethods retrieve the second factor methods, one of which the subject must have used to access
+// the object. An empty list means any method is accepted.
+func (p *Authorizer) GetRequiredMethods(subject Subject, object Object) []string {
+	index, rule := p.GetMatchingRule(subject, object)
+
+	return Decision{Index: index, Rule: rule}.RequiredMethods()
+}
+
+// GetDenyBehavior retrieve the behavior applied when the subject is denied the access to the object by a rule or the
+// default policy.
+func (p *Authorizer) GetDenyBehavior(subject Subject, object Object) string {
+	index, rule := p.GetMatchingRule(subject, object)
 
-	return PolicyToLevel(p.configuration.DefaultPolicy)
+	return Decision{Index: index, Rule: rule, defaultDenyBehavior: p.configuration.DefaultDenyBehavior}.DenyBehavior()
 }
 
-// IsURLMatchingRuleWithGroupSubjects returns true if the request has at least one
-// matching ACL with a subject of type group attached to it, otherwise false.
-func (p *Authorizer) IsURLMatchingRuleWithGroupSubjects(requestURL url.URL) (hasGroupSubjects bool) {
-	for _, rule := range p.configuration.Rules {
+// GetMaxAuthenticationAge retrieve the maximum age of the last authentication of the subject to access the object.
+func (p *Authorizer) GetMaxAuthenticationAge(subject Subject, object Object) time.Duration {
+	index, rule := p.GetMatchingRule(subject, object)
+
+	return Decision{Index: index, Rule: rule}.MaxAuthenticationAge()
+}
+
+// IsURLMatchingRuleWithProfileSubjects returns true if the request has at least one matching ACL with a subject
+// depending on the profile of the user attached to it, i.e. a group, email or attribute subject, otherwise false.
+func (p *Authorizer) IsURLMatchingRuleWithProfileSubjects(requestURL url.URL) (hasProfileSubjects bool) {
+	for _, rule := range append(p.getActiveGrants(p.clock.Now()), p.rules...) {
 		if isDomainMatching(requestURL.Hostname(), rule.Domains) && isPathMatching(requestURL.Path, rule.Resources) {
 			for _, subjectRule := range rule.Subjects {
 				for _, subject := range subjectRule {
-					if strings.HasPrefix(subject, groupPrefix) {
+					subject = strings.TrimPrefix(subject, negationPrefix)
+
+					if strings.HasPrefix(subject, groupPrefix) || strings.HasPrefix(subject, emailPrefix) ||
+						strings.HasPrefix(subject, attributePrefix) {
 						return true
 					}
 				}
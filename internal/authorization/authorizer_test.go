@@ -2,13 +2,17 @@ package authorization
 
 import (
 	"net"
+	"net/http"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/utils"
 )
 
 type AuthorizerSuite struct {
@@ -19,29 +23,50 @@ type AuthorizerTester struct {
 	*Authorizer
 }
 
-func NewAuthorizerTester(config schema.AccessControlConfiguration) *AuthorizerTester {
+func NewAuthorizerTester(config schema.AccessControlConfiguration, clock utils.Clock) *AuthorizerTester {
 	return &AuthorizerTester{
-		NewAuthorizer(config),
+		NewAuthorizer(config, clock),
 	}
 }
 
+type FixedClock struct {
+	now time.Time
+}
+
+func (c *FixedClock) Now() time.Time {
+	return c.now
+}
+
+func (c *FixedClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
 func (s *AuthorizerTester) CheckAuthorizations(t *testing.T, subject Subject, requestURI string, expectedLevel Level) {
+	s.CheckAuthorizationsWithHeaders(t, subject, requestURI, nil, expectedLevel)
+}
+
+func (s *AuthorizerTester) CheckAuthorizationsWithHeaders(t *testing.T, subject Subject, requestURI string,
+	headers http.Header, expectedLevel Level) {
 	url, _ := url.ParseRequestURI(requestURI)
 	level := s.GetRequiredLevel(Subject{
-		Groups:   subject.Groups,
-		Username: subject.Username,
-		IP:       subject.IP,
-	}, *url)
+		Groups:     subject.Groups,
+		Username:   subject.Username,
+		Emails:     subject.Emails,
+		Attributes: subject.Attributes,
+		IP:         subject.IP,
+		Country:    subject.Country,
+	}, NewObject(*url, headers))
 
 	assert.Equal(t, expectedLevel, level)
 }
 
 type AuthorizerTesterBuilder struct {
 	config schema.AccessControlConfiguration
+	clock  utils.Clock
 }
 
 func NewAuthorizerBuilder() *AuthorizerTesterBuilder {
-	return &AuthorizerTesterBuilder{}
+	return &AuthorizerTesterBuilder{clock: utils.RealClock{}}
 }
 
 func (b *AuthorizerTesterBuilder) WithDefaultPolicy(policy string) *AuthorizerTesterBuilder {
@@ -49,13 +74,28 @@ func (b *AuthorizerTesterBuilder) WithDefaultPolicy(policy string) *AuthorizerTe
 	return b
 }
 
+func (b *AuthorizerTesterBuilder) WithDefaultDenyBehavior(behavior string) *AuthorizerTesterBuilder {
+	b.config.DefaultDenyBehavior = behavior
+	return b
+}
+
 func (b *AuthorizerTesterBuilder) WithRule(rule schema.ACLRule) *AuthorizerTesterBuilder {
 	b.config.Rules = append(b.config.Rules, rule)
 	return b
 }
 
+func (b *AuthorizerTesterBuilder) WithExternalPolicy(external schema.ExternalPolicyConfiguration) *AuthorizerTesterBuilder {
+	b.config.External = &external
+	return b
+}
+
+func (b *AuthorizerTesterBuilder) WithClock(clock utils.Clock) *AuthorizerTesterBuilder {
+	b.clock = clock
+	return b
+}
+
 func (b *AuthorizerTesterBuilder) Build() *AuthorizerTester {
-	return NewAuthorizerTester(b.config)
+	return NewAuthorizerTester(b.config, b.clock)
 }
 
 var AnonymousUser = Subject{
@@ -80,6 +120,12 @@ var UserWithoutGroups = Subject{
 
 var Bob = UserWithoutGroups
 
+var Harry = Subject{
+	Username: "harry",
+	Groups:   []string{"dev"},
+	IP:       net.ParseIP("10.0.0.9"),
+}
+
 func (s *AuthorizerSuite) TestShouldCheckDefaultBypassConfig() {
 	tester := NewAuthorizerBuilder().
 		WithDefaultPolicy("bypass").Build()
@@ -211,6 +257,55 @@ func (s *AuthorizerSuite) TestShouldCheckGroupMatching() {
 	tester.CheckAuthorizations(s.T(), Bob, "https://protected.example.com/", Denied)
 }
 
+func (s *AuthorizerSuite) TestShouldCheckEmailMatching() {
+	tester := NewAuthorizerBuilder().
+		WithDefaultPolicy("deny").
+		WithRule(schema.ACLRule{
+			Domains:  []string{"protected.example.com"},
+			Policy:   "bypass",
+			Subjects: [][]string{{"email:*@partner.com"}},
+		}).
+		WithRule(schema.ACLRule{
+			Domains:  []string{"staff.example.com"},
+			Policy:   "bypass",
+			Subjects: [][]string{{"email:john.doe@example.com"}},
+		}).
+		Build()
+
+	partner := Subject{Username: "alice", Emails: []string{"alice@example.com", "Alice@Partner.com"}}
+
+	tester.CheckAuthorizations(s.T(), partner, "https://protected.example.com/", Bypass)
+	tester.CheckAuthorizations(s.T(), partner, "https://staff.example.com/", Denied)
+	tester.CheckAuthorizations(s.T(), Subject{Username: "john", Emails: []string{"john.doe@example.com"}},
+		"https://staff.example.com/", Bypass)
+	tester.CheckAuthorizations(s.T(), Subject{Username: "eve", Emails: []string{"eve@partner.com.evil.com"}},
+		"https://protected.example.com/", Denied)
+	tester.CheckAuthorizations(s.T(), AnonymousUser, "https://protected.example.com/", Denied)
+}
+
+func (s *AuthorizerSuite) TestShouldCheckAttributeMatching() {
+	tester := NewAuthorizerBuilder().
+		WithDefaultPolicy("deny").
+		WithRule(schema.ACLRule{
+			Domains:  []string{"protected.example.com"},
+			Policy:   "bypass",
+			Subjects: [][]string{{"attr:department=finance", "!attr:title=intern"}},
+		}).
+		Build()
+
+	finance := Subject{Username: "alice", Attributes: map[string][]string{"department": {"finance"}}}
+	intern := Subject{Username: "bob", Attributes: map[string][]string{"department": {"finance"}, "title": {"intern"}}}
+	sales := Subject{Username: "carol", Attributes: map[string][]string{"department": {"sales"}}}
+
+	tester.CheckAuthorizations(s.T(), finance, "https://protected.example.com/", Bypass)
+	tester.CheckAuthorizations(s.T(), intern, "https://protected.example.com/", Denied)
+	tester.CheckAuthorizations(s.T(), sales, "https://protected.example.com/", Denied)
+	tester.CheckAuthorizations(s.T(), John, "https://protected.example.com/", Denied)
+
+	protectedURL, _ := url.ParseRequestURI("https://protected.example.com/")
+	s.Assert().True(tester.IsURLMatchingRuleWithProfileSubjects(*protectedURL))
+}
+
 func (s *AuthorizerSuite) TestShouldCheckSubjectsMatching() {
 	tester := NewAuthorizerBuilder().
 		WithDefaultPolicy("deny").
@@ -241,6 +336,31 @@ func (s *AuthorizerSuite) TestShouldCheckMultipleSubjectsMatching() {
 	tester.CheckAuthorizations(s.T(), AnonymousUser, "https://protected.example.com/", Denied)
 }
 
+func (s *AuthorizerSuite) TestShouldCheckNegatedSubjectsMatching() {
+	tester := NewAuthorizerBuilder().
+		WithDefaultPolicy("deny").
+		WithRule(schema.ACLRule{
+			Domains:  []string{"protected.example.com"},
+			Policy:   "bypass",
+			Subjects: [][]string{{"group:dev", "!group:admins"}, {"!group:dev", "!user:john"}},
+		}).
+		WithRule(schema.ACLRule{
+			Domains:  []string{"staff.example.com"},
+			Policy:   "bypass",
+			Subjects: [][]string{{"group:dev", "!user:john"}},
+		}).
+		Build()
+
+	tester.CheckAuthorizations(s.T(), John, "https://protected.example.com/", Denied)
+	tester.CheckAuthorizations(s.T(), Bob, "https://protected.example.com/", Bypass)
+	tester.CheckAuthorizations(s.T(), AnonymousUser, "https://protected.example.com/", Denied)
+
+	tester.CheckAuthorizations(s.T(), John, "https://staff.example.com/", Denied)
+	tester.CheckAuthorizations(s.T(), Bob, "https://staff.example.com/", Denied)
+	tester.CheckAuthorizations(s.T(), Harry, "https://staff.example.com/", Bypass)
+	tester.CheckAuthorizations(s.T(), AnonymousUser, "https://staff.example.com/", Denied)
+}
+
 func (s *AuthorizerSuite) TestShouldCheckIPMatching() {
 	tester := NewAuthorizerBuilder().
 		WithDefaultPolicy("deny").
@@ -293,6 +413,425 @@ func (s *AuthorizerSuite) TestShouldCheckResourceMatching() {
 	tester.CheckAuthorizations(s.T(), John, "https://resource.example.com/xyz/embedded/abc", Bypass)
 }
 
+func (s *AuthorizerSuite) TestShouldGetRequiredMethods() {
+	tester := NewAuthorizerBuilder().
+		WithDefaultPolicy("two_factor").
+		WithRule(schema.ACLRule{
+			Domains:         []string{"secure.example.com"},
+			Policy:          "two_factor",
+			RequiredMethods: []string{"u2f"},
+		}).
+		WithRule(schema.ACLRule{
+			Domains: []string{"protected.example.com"},
+			Policy:  "two_factor",
+		}).
+		Build()
+
+	secureURL, _ := url.ParseRequestURI("https://secure.example.com/")
+	protectedURL, _ := url.ParseRequestURI("https://protected.example.com/")
+	defaultURL, _ := url.ParseRequestURI("https://default.example.com/")
+
+	s.Assert().Equal([]string{"u2f"}, tester.GetRequiredMethods(John, NewObject(*secureURL, nil)))
+	s.Assert().Len(tester.GetRequiredMethods(John, NewObject(*protectedURL, nil)), 0)
+	s.Assert().Len(tester.GetRequiredMethods(John, NewObject(*defaultURL, nil)), 0)
+}
+
+func (s *AuthorizerSuite) TestShouldGetMatchingRule() {
+	tester := NewAuthorizerBuilder().
+		WithDefaultPolicy("deny").
+		WithRule(schema.ACLRule{
+			Domains: []string{"public.example.com"},
+			Policy:  "bypass",
+		}).
+		WithRule(schema.ACLRule{
+			Name:      "admins",
+			Domains:   []string{"admin.example.com"},
+			Policy:    "two_factor",
+			Countries: []string{"FR"},
+		}).
+		Build()
+
+	publicURL, _ := url.ParseRequestURI("https://public.example.com/")
+	adminURL, _ := url.ParseRequestURI("https://admin.example.com/")
+	defaultURL, _ := url.ParseRequestURI("https://default.example.com/")
+
+	index, rule := tester.GetMatchingRule(John, NewObject(*publicURL, nil))
+	s.Assert().Equal(0, index)
+	s.Require().NotNil(rule)
+	s.Assert().Equal("bypass", rule.Policy)
+
+	index, rule = tester.GetMatchingRule(Subject{Username: "john", Country: "FR"}, NewObject(*adminURL, nil))
+	s.Assert().Equal(1, index)
+	s.Require().NotNil(rule)
+	s.Assert().Equal("admins", rule.Name)
+	s.Assert().Equal("two_factor", rule.Policy)
+
+	index, rule = tester.GetMatchingRule(John, NewObject(*adminURL, nil))
+	s.Assert().Equal(1, index)
+	s.Require().NotNil(rule)
+	s.Assert().Equal("admins", rule.Name)
+	s.Assert().Equal("deny", rule.Policy)
+
+	index, rule = tester.GetMatchingRule(John, NewObject(*defaultURL, nil))
+	s.Assert().Equal(-1, index)
+	s.Assert().Nil(rule)
+}
+
+func (s *AuthorizerSuite) TestShouldGetDecision() {
+	tester := NewAuthorizerBuilder().
+		WithDefaultPolicy("one_factor").
+		WithRule(schema.ACLRule{
+			Name:                 "payroll",
+			Domains:              []string{"payroll.example.com"},
+			Policy:               "two_factor",
+			RequiredMethods:      []string{"u2f"},
+			MaxAuthenticationAge: "10m",
+		}).
+		WithRule(schema.ACLRule{
+			Domains:      []string{"admin.example.com"},
+			Policy:       "deny",
+			DenyBehavior: "forbidden",
+		}).
+		Build()
+
+	payrollURL, _ := url.ParseRequestURI("https://payroll.example.com/")
+	adminURL, _ := url.ParseRequestURI("https://admin.example.com/")
+	defaultURL, _ := url.ParseRequestURI("https://default.example.com/")
+
+	decision := tester.GetDecision(John, NewObject(*payrollURL, nil))
+	s.Assert().Equal(0, decision.Index)
+	s.Require().NotNil(decision.Rule)
+	s.Assert().Equal("payroll", decision.Rule.Name)
+	s.Assert().Equal(TwoFactor, decision.Level)
+	s.Assert().Equal([]string{"u2f"}, decision.RequiredMethods())
+	s.Assert().Equal(10*time.Minute, decision.MaxAuthenticationAge())
+	s.Assert().Equal("redirect", decision.DenyBehavior())
+
+	decision = tester.GetDecision(John, NewObject(*adminURL, nil))
+	s.Assert().Equal(1, decision.Index)
+	s.Assert().Equal(Denied, decision.Level)
+	s.Assert().Equal("forbidden", decision.DenyBehavior())
+
+	decision = tester.GetDecision(John, NewObject(*defaultURL, nil))
+	s.Assert().Equal(-1, decision.Index)
+	s.Assert().Nil(decision.Rule)
+	s.Assert().Equal(OneFactor, decision.Level)
+	s.Assert().Len(decision.RequiredMethods(), 0)
+	s.Assert().Equal(time.Duration(0), decision.MaxAuthenticationAge())
+}
+
+func (s *AuthorizerSuite) TestShouldGetMaxAuthenticationAge() {
+	tester := NewAuthorizerBuilder().
+		WithDefaultPolicy("two_factor").
+		WithRule(schema.ACLRule{
+			Domains:              []string{"payroll.example.com"},
+			Policy:               "two_factor",
+			MaxAuthenticationAge: "10m",
+		}).
+		WithRule(schema.ACLRule{
+			Domains: []string{"protected.example.com"},
+			Policy:  "two_factor",
+		}).
+		Build()
+
+	payrollURL, _ := url.ParseRequestURI("https://payroll.example.com/")
+	protectedURL, _ := url.ParseRequestURI("https://protected.example.com/")
+	defaultURL, _ := url.ParseRequestURI("https://default.example.com/")
+
+	s.Assert().Equal(10*time.Minute, tester.GetMaxAuthenticationAge(John, NewObject(*payrollURL, nil)))
+	s.Assert().Equal(time.Duration(0), tester.GetMaxAuthenticationAge(John, NewObject(*protectedURL, nil)))
+	s.Assert().Equal(time.Duration(0), tester.GetMaxAuthenticationAge(John, NewObject(*defaultURL, nil)))
+}
+
+func (s *AuthorizerSuite) TestShouldCheckScheduleMatching() {
+	clock := &FixedClock{}
+	tester := NewAuthorizerBuilder().
+		WithDefaultPolicy("deny").
+		WithClock(clock).
+		WithRule(schema.ACLRule{
+			Domains: []string{"tools.example.com"},
+			Policy:  "one_factor",
+			Schedule: &schema.ACLRuleSchedule{
+				Days:     []string{"monday", "tuesday", "wednesday", "thursday", "friday"},
+				Times:    []string{"09:00-18:00"},
+				TimeZone: "UTC",
+			},
+		}).
+		Build()
+
+	// 2020-06-01 is a Monday.
+	clock.now = time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC)
+	tester.CheckAuthorizations(s.T(), John, "https://tools.example.com/", OneFactor)
+
+	clock.now = time.Date(2020, 6, 1, 20, 0, 0, 0, time.UTC)
+	tester.CheckAuthorizations(s.T(), John, "https://tools.example.com/", Denied)
+
+	clock.now = time.Date(2020, 6, 6, 10, 0, 0, 0, time.UTC)
+	tester.CheckAuthorizations(s.T(), John, "https://tools.example.com/", Denied)
+}
+
+func (s *AuthorizerSuite) TestShouldCheckCountryMatching() {
+	tester := NewAuthorizerBuilder().
+		WithDefaultPolicy("one_factor").
+		WithRule(schema.ACLRule{
+			Domains:      []string{"protected.example.com"},
+			Policy:       "two_factor",
+			NotCountries: []string{"FR", "de"},
+		}).
+		WithRule(schema.ACLRule{
+			Domains:   []string{"local.example.com"},
+			Policy:    "bypass",
+			Countries: []string{"FR"},
+		}).
+		Build()
+
+	french := Subject{Username: "john", IP: net.ParseIP("2.125.160.216"), Country: "FR"}
+	german := Subject{Username: "john", IP: net.ParseIP("2.160.0.1"), Country: "DE"}
+	british := Subject{Username: "john", IP: net.ParseIP("81.2.69.142"), Country: "GB"}
+	unknown := Subject{Username: "john", IP: net.ParseIP("10.0.0.1")}
+
+	tester.CheckAuthorizations(s.T(), french, "https://protected.example.com/", OneFactor)
+	tester.CheckAuthorizations(s.T(), german, "https://protected.example.com/", OneFactor)
+	tester.CheckAuthorizations(s.T(), british, "https://protected.example.com/", TwoFactor)
+	tester.CheckAuthorizations(s.T(), unknown, "https://protected.example.com/", Denied)
+
+	tester.CheckAuthorizations(s.T(), french, "https://local.example.com/", Bypass)
+	tester.CheckAuthorizations(s.T(), british, "https://local.example.com/", OneFactor)
+	tester.CheckAuthorizations(s.T(), unknown, "https://local.example.com/", Denied)
+	tester.CheckAuthorizations(s.T(), unknown, "https://other.example.com/", OneFactor)
+}
+
+func (s *AuthorizerSuite) TestShouldCheckHeadersAndQueryMatching() {
+	tester := NewAuthorizerBuilder().
+		WithDefaultPolicy("deny").
+		WithRule(schema.ACLRule{
+			Domains: []string{"api.example.com"},
+			Policy:  "one_factor",
+			Headers: []schema.ACLRuleMatcher{{Name: "accept", Value: "application/json"}},
+		}).
+		WithRule(schema.ACLRule{
+			Domains: []string{"api.example.com"},
+			Policy:  "bypass",
+			Query:   []schema.ACLRuleMatcher{{Name: "api_key"}},
+		}).
+		WithRule(schema.ACLRule{
+			Domains: []string{"api.example.com"},
+			Policy:  "two_factor",
+			Headers: []schema.ACLRuleMatcher{{Name: "User-Agent", Pattern: "^Mozilla/"}},
+			Query:   []schema.ACLRuleMatcher{{Name: "format", Pattern: "^(html|xml)$"}},
+		}).
+		Build()
+
+	john := Subject{Username: "john", Groups: []string{"dev"}, IP: net.ParseIP("127.0.0.1")}
+
+	withHeaders := func(headers ...string) http.Header {
+		header := http.Header{}
+
+		for i := 0; i < len(headers); i += 2 {
+			header.Add(headers[i], headers[i+1])
+		}
+
+		return header
+	}
+
+	tester.CheckAuthorizationsWithHeaders(s.T(), john, "https://api.example.com/", withHeaders("Accept", "application/json"),
+		OneFactor)
+	tester.CheckAuthorizationsWithHeaders(s.T(), john, "https://api.example.com/", withHeaders("Accept", "text/html"),
+		Denied)
+	tester.CheckAuthorizationsWithHeaders(s.T(), john, "https://api.example.com/?api_key=abc", withHeaders(), Bypass)
+	tester.CheckAuthorizationsWithHeaders(s.T(), john, "https://api.example.com/?api_key", withHeaders(), Bypass)
+	tester.CheckAuthorizationsWithHeaders(s.T(), john, "https://api.example.com/?other=abc", withHeaders(), Denied)
+	tester.CheckAuthorizationsWithHeaders(s.T(), john, "https://api.example.com/?format=html",
+		withHeaders("User-Agent", "Mozilla/5.0"), TwoFactor)
+	tester.CheckAuthorizationsWithHeaders(s.T(), john, "https://api.example.com/?format=json",
+		withHeaders("User-Agent", "Mozilla/5.0"), Denied)
+	tester.CheckAuthorizationsWithHeaders(s.T(), john, "https://api.example.com/?format=html",
+		withHeaders("User-Agent", "curl/7.68.0"), Denied)
+	tester.CheckAuthorizations(s.T(), AnonymousUser, "https://api.example.com/", Denied)
+}
+
+type GrantsProviderStub struct {
+	grants []models.AccessGrant
+}
+
+func (p *GrantsProviderStub) LoadActiveAccessGrants(now time.Time) ([]models.AccessGrant, error) {
+	grants := []models.AccessGrant{}
+
+	for _, grant := range p.grants {
+		if grant.ExpiresAt.After(now) {
+			grants = append(grants, grant)
+		}
+	}
+
+	return grants, nil
+}
+
+func (s *AuthorizerSuite) TestShouldCheckAccessGrants() {
+	clock := &FixedClock{now: time.Date(2020, time.June, 1, 12, 0, 0, 0, time.UTC)}
+	tester := NewAuthorizerBuilder().
+		WithDefaultPolicy("deny").
+		WithClock(clock).
+		WithRule(schema.ACLRule{
+			Domains:  []string{"*.example.com"},
+			Subjects: [][]string{{"group:admin"}},
+			Policy:   "two_factor",
+		}).
+		Build()
+
+	err := tester.ReloadGrants(&GrantsProviderStub{grants: []models.AccessGrant{
+		{ID: "1", Subject: "user:bob", Domain: "app.example.com", Policy: "one_factor",
+			ExpiresAt: clock.now.Add(48 * time.Hour)},
+		{ID: "2", Subject: "group:dev", Domain: "app.example.com", Resource: "^/api/", Policy: "two_factor",
+			ExpiresAt: clock.now.Add(time.Hour)},
+		{ID: "3", Subject: "user:bob", Domain: "old.example.com", Policy: "one_factor",
+			ExpiresAt: clock.now.Add(-time.Hour)},
+	}})
+	s.Require().NoError(err)
+
+	tester.CheckAuthorizations(s.T(), Bob, "https://app.example.com/", OneFactor)
+	tester.CheckAuthorizations(s.T(), Bob, "https://old.example.com/", Denied)
+	tester.CheckAuthorizations(s.T(), John, "https://app.example.com/api/users", TwoFactor)
+	tester.CheckAuthorizations(s.T(), John, "https://app.example.com/", Denied)
+	tester.CheckAuthorizations(s.T(), AnonymousUser, "https://app.example.com/", Denied)
+
+	appURL, _ := url.ParseRequestURI("https://app.example.com/")
+	index, rule := tester.GetMatchingRule(Bob, NewObject(*appURL, nil))
+	s.Assert().Equal(-1, index)
+	s.Require().NotNil(rule)
+	s.Assert().Equal("grant:1", rule.Name)
+
+	// The grants expire even before they are reloaded.
+	clock.now = clock.now.Add(2 * time.Hour)
+
+	tester.CheckAuthorizations(s.T(), Bob, "https://app.example.com/", OneFactor)
+	tester.CheckAuthorizations(s.T(), John, "https://app.example.com/api/users", Denied)
+}
+
+type CountingGrantsProviderStub struct {
+	reloads chan struct{}
+}
+
+func (p *CountingGrantsProviderStub) LoadActiveAccessGrants(now time.Time) ([]models.AccessGrant, error) {
+	select {
+	case p.reloads <- struct{}{}:
+	default:
+	}
+
+	return nil, nil
+}
+
+func (s *AuthorizerSuite) TestShouldStopReloadingGrants() {
+	tester := NewAuthorizerBuilder().WithDefaultPolicy("deny").Build()
+	provider := &CountingGrantsProviderStub{reloads: make(chan struct{}, 1)}
+
+	tester.StartGrantsReload(provider, time.Millisecond)
+
+	select {
+	case <-provider.reloads:
+	case <-time.After(time.Second):
+		s.FailNow("The access grants have not been reloaded")
+	}
+
+	tester.StopGrantsReload()
+
+	select {
+	case <-provider.reloads:
+	default:
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	select {
+	case <-provider.reloads:
+		s.Fail("The access grants have been reloaded after the reload was stopped")
+	default:
+	}
+}
+
+func (s *AuthorizerSuite) TestShouldGetDenyBehavior() {
+	tester := NewAuthorizerBuilder().
+		WithDefaultPolicy("deny").
+		WithRule(schema.ACLRule{
+			Domains:      []string{"admin.example.com"},
+			Policy:       "deny",
+			DenyBehavior: "access_denied",
+		}).
+		WithRule(schema.ACLRule{
+			Domains: []string{"private.example.com"},
+			Policy:  "deny",
+		}).
+		WithRule(schema.ACLRule{
+			Domains:      []string{"local.example.com"},
+			Policy:       "one_factor",
+			Countries:    []string{"FR"},
+			DenyBehavior: "forbidden",
+		}).
+		Build()
+
+	adminURL, _ := url.ParseRequestURI("https://admin.example.com/")
+	privateURL, _ := url.ParseRequestURI("https://private.example.com/")
+	otherURL, _ := url.ParseRequestURI("https://other.example.com/")
+	localURL, _ := url.ParseRequestURI("https://local.example.com/")
+
+	s.Assert().Equal("access_denied", tester.GetDenyBehavior(John, NewObject(*adminURL, nil)))
+	s.Assert().Equal("redirect", tester.GetDenyBehavior(John, NewObject(*privateURL, nil)))
+	s.Assert().Equal("redirect", tester.GetDenyBehavior(John, NewObject(*otherURL, nil)))
+
+	// The rule denying the subjects of unknown country keeps its deny behavior.
+	decision := tester.GetDecision(John, NewObject(*localURL, nil))
+	s.Assert().Equal(Denied, decision.Level)
+	s.Assert().Equal("forbidden", decision.DenyBehavior())
+}
+
+func (s *AuthorizerSuite) TestShouldGetDefaultDenyBehavior() {
+	tester := NewAuthorizerBuilder().
+		WithDefaultPolicy("deny").
+		WithDefaultDenyBehavior("forbidden").
+		WithRule(schema.ACLRule{
+			Domains:      []string{"admin.example.com"},
+			Policy:       "deny",
+			DenyBehavior: "access_denied",
+		}).
+		WithRule(schema.ACLRule{
+			Domains: []string{"private.example.com"},
+			Policy:  "deny",
+		}).
+		Build()
+
+	adminURL, _ := url.ParseRequestURI("https://admin.example.com/")
+	privateURL, _ := url.ParseRequestURI("https://private.example.com/")
+	otherURL, _ := url.ParseRequestURI("https://other.example.com/")
+
+	// The default deny behavior only applies to the default policy, not to the rules without a deny behavior.
+	s.Assert().Equal("access_denied", tester.GetDenyBehavior(John, NewObject(*adminURL, nil)))
+	s.Assert().Equal("redirect", tester.GetDenyBehavior(John, NewObject(*privateURL, nil)))
+	s.Assert().Equal("forbidden", tester.GetDenyBehavior(John, NewObject(*otherURL, nil)))
+}
+
+func (s *AuthorizerSuite) TestShouldApplyDefaultPolicyOfDomain() {
+	tester := NewAuthorizerBuilder().
+		WithDefaultPolicy("deny").
+		WithRule(schema.ACLRule{
+			Domains: []string{"public.example.io"},
+			Policy:  "bypass",
+		}).
+		Build()
+
+	tester.SetDomains([]schema.DomainConfiguration{
+		{Domain: "example.com"},
+		{Domain: "example.io", DefaultPolicy: "one_factor"},
+		{Domain: "secure.example.io", DefaultPolicy: "two_factor"},
+	})
+
+	tester.CheckAuthorizations(s.T(), John, "https://app.example.com/", Denied)
+	tester.CheckAuthorizations(s.T(), John, "https://app.example.io/", OneFactor)
+	tester.CheckAuthorizations(s.T(), John, "https://app.secure.example.io/", TwoFactor)
+	tester.CheckAuthorizations(s.T(), John, "https://public.example.io/", Bypass)
+	tester.CheckAuthorizations(s.T(), John, "https://app.example.org/", Denied)
+
+	s.Assert().True(tester.IsSecondFactorEnabled())
+}
+
 func (s *AuthorizerSuite) TestPolicyToLevel() {
 	s.Assert().Equal(Bypass, PolicyToLevel("bypass"))
 	s.Assert().Equal(OneFactor, PolicyToLevel("one_factor"))
@@ -302,6 +841,13 @@ func (s *AuthorizerSuite) TestPolicyToLevel() {
 	s.Assert().Equal(Denied, PolicyToLevel("whatever"))
 }
 
+func (s *AuthorizerSuite) TestLevelToPolicy() {
+	s.Assert().Equal("bypass", LevelToPolicy(Bypass))
+	s.Assert().Equal("one_factor", LevelToPolicy(OneFactor))
+	s.Assert().Equal("two_factor", LevelToPolicy(TwoFactor))
+	s.Assert().Equal("deny", LevelToPolicy(Denied))
+}
+
 func TestRunSuite(t *testing.T) {
 	s := AuthorizerSuite{}
 	suite.Run(t, &s)
@@ -1,5 +1,7 @@
 package authorization
 
+import "time"
+
 // Level is the type representing an authorization level.
 type Level int
 
@@ -13,3 +15,14 @@ const (
 	// Denied denied level.
 	Denied Level = iota
 )
+
+const denyPolicy = "deny"
+
+// grantNamePrefix is the prefix of the names of the rules built from the access grants.
+const grantNamePrefix = "grant:"
+
+// GrantsReloadInterval is the interval at which the access grants are reloaded from the storage.
+const GrantsReloadInterval = 10 * time.Second
+
+// externalPolicyMaxResponseSize is the maximum size in bytes of the body read from the external policy endpoint.
+const externalPolicyMaxResponseSize = 64 * 1024
@@ -0,0 +1,27 @@
+package authorization
+
+import "strings"
+
+// isCountryMatching check whether the country of the user satisfies the countries conditions of a rule. An unknown
+// country satisfies the conditions so that the authorizer can deny the access instead of skipping the rule.
+func isCountryMatching(country string, countries, notCountries []string) bool {
+	if country == "" {
+		return true
+	}
+
+	if len(countries) > 0 {
+		return isCountryInList(country, countries)
+	}
+
+	return !isCountryInList(country, notCountries)
+}
+
+func isCountryInList(country string, countries []string) bool {
+	for _, c := range countries {
+		if strings.EqualFold(c, country) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,59 @@
+package authorization
+
+import (
+	"time"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// Decision the outcome of the access control for a subject and an object, it is looked up once per request and
+// provides everything the handlers need about the rule applied.
+type Decision struct {
+	// The index of the rule applied, -1 for the access grants and the default policy.
+	Index int
+	// The rule applied, nil when the default policy applies.
+	Rule *schema.ACLRule
+	// The level required to access the object.
+	Level Level
+
+	// The deny behavior applied when the default policy denies the access.
+	defaultDenyBehavior string
+}
+
+// RequiredMethods the second factor methods, one of which the subject must have used to access the object. An empty
+// list means any method is accepted.
+func (d Decision) RequiredMethods() []string {
+	if d.Rule == nil {
+		return nil
+	}
+
+	return d.Rule.RequiredMethods
+}
+
+// DenyBehavior the behavior applied when the subject is denied the access to the object by a rule or the default
+// policy, the anonymous users are redirected to the login portal and the identified users are forbidden the access by
+// default.
+func (d Decision) DenyBehavior() string {
+	switch {
+	case d.Rule != nil && d.Rule.DenyBehavior != "":
+		return d.Rule.DenyBehavior
+	case d.Rule == nil && d.defaultDenyBehavior != "":
+		return d.defaultDenyBehavior
+	}
+
+	return schema.DenyBehaviorRedirect
+}
+
+// MaxAuthenticationAge the maximum age of the last authentication of the subject to access the object. A zero
+// duration means the authentication never gets too old.
+func (d Decision) MaxAuthenticationAge() time.Duration {
+	if d.Rule == nil || d.Rule.MaxAuthenticationAge == "" {
+		return 0
+	}
+
+	// Skip Error Check since the rules are validated by schema.ACLRule.Validate when the configuration is loaded.
+	maxAge, _ := utils.ParseDurationString(d.Rule.MaxAuthenticationAge)
+
+	return maxAge
+}
@@ -0,0 +1,253 @@
+package authorization
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/logging"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// externalPolicy delegates the decision of the rules having the 'external' policy to an HTTP endpoint.
+type externalPolicy struct {
+	url           string
+	client        *http.Client
+	ttl           time.Duration
+	failurePolicy string
+	clock         utils.Clock
+	headers       []string
+
+	mutex     sync.Mutex
+	cacheSize int
+	cache     map[string]*list.Element
+	lru       *list.List
+}
+
+// externalDecision is a decision of the external endpoint cached until it expires.
+type externalDecision struct {
+	key       string
+	level     Level
+	expiresAt time.Time
+}
+
+// externalPolicyRequest is the body posted to the external endpoint.
+type externalPolicyRequest struct {
+	Subject externalPolicySubject `json:"subject"`
+	Object  externalPolicyObject  `json:"object"`
+}
+
+type externalPolicySubject struct {
+	Username string   `json:"username"`
+	Groups   []string `json:"groups"`
+	IP       string   `json:"ip"`
+	Country  string   `json:"country"`
+}
+
+type externalPolicyObject struct {
+	Domain  string      `json:"domain"`
+	Path    string      `json:"path"`
+	Method  string      `json:"method"`
+	Headers http.Header `json:"headers"`
+}
+
+// externalPolicyResponse is the body expected from the external endpoint.
+type externalPolicyResponse struct {
+	Policy string `json:"policy"`
+}
+
+func newExternalPolicy(configuration schema.ExternalPolicyConfiguration, clock utils.Clock) *externalPolicy {
+	// Skip Error Check since validator checks it.
+	timeout, _ := utils.ParseDurationString(configuration.Timeout)
+	ttl, _ := utils.ParseDurationString(configuration.CacheTTL)
+
+	cacheSize := configuration.CacheSize
+	if cacheSize <= 0 {
+		cacheSize = schema.DefaultExternalPolicyConfiguration.CacheSize
+	}
+
+	headers := make([]string, len(configuration.Headers))
+	for i, header := range configuration.Headers {
+		headers[i] = http.CanonicalHeaderKey(header)
+	}
+
+	return &externalPolicy{
+		url:           configuration.URL,
+		client:        &http.Client{Timeout: timeout},
+		ttl:           ttl,
+		failurePolicy: configuration.FailurePolicy,
+		clock:         clock,
+		headers:       headers,
+		cacheSize:     cacheSize,
+		cache:         make(map[string]*list.Element),
+		lru:           list.New(),
+	}
+}
+
+// getRequestMethod retrieve the method of the original request forwarded by the proxy.
+func getRequestMethod(headers http.Header) string {
+	if method := headers.Get("X-Forwarded-Method"); method != "" {
+		return method
+	}
+
+	return headers.Get("X-Original-Method")
+}
+
+// forwardedHeaders returns the headers of the request which are allowed to be forwarded to the external endpoint,
+// the other ones such as the cookies and the credentials never leave Authelia.
+func (e *externalPolicy) forwardedHeaders(headers http.Header) http.Header {
+	forwarded := http.Header{}
+
+	for _, name := range e.headers {
+		if values, ok := headers[name]; ok {
+			forwarded[name] = values
+		}
+	}
+
+	return forwarded
+}
+
+// cacheKey is the key of the decisions in the cache, it is made of everything sent to the endpoint.
+func (e *externalPolicy) cacheKey(subject Subject, object Object, method string, headers http.Header) string {
+	parts := []string{subject.Username, strings.Join(subject.Groups, ","), subject.IP.String(),
+		subject.Country, object.Domain, object.Path, method}
+
+	for _, name := range e.headers {
+		parts = append(parts, name+":"+strings.Join(headers[name], ","))
+	}
+
+	return strings.Join(parts, "|")
+}
+
+// getCachedLevel retrieve the cached decision of a key if it has not expired yet, the expired decision is removed.
+func (e *externalPolicy) getCachedLevel(key string, now time.Time) (Level, bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	element, ok := e.cache[key]
+	if !ok {
+		return Denied, false
+	}
+
+	decision := element.Value.(externalDecision)
+
+	if !now.Before(decision.expiresAt) {
+		e.lru.Remove(element)
+		delete(e.cache, key)
+
+		return Denied, false
+	}
+
+	e.lru.MoveToFront(element)
+
+	return decision.level, true
+}
+
+// cacheLevel caches a decision, the least recently used decisions are evicted once the cache is full.
+func (e *externalPolicy) cacheLevel(key string, level Level, now time.Time) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	decision := externalDecision{key: key, level: level, expiresAt: now.Add(e.ttl)}
+
+	if element, ok := e.cache[key]; ok {
+		element.Value = decision
+		e.lru.MoveToFront(element)
+
+		return
+	}
+
+	for e.lru.Len() >= e.cacheSize {
+		oldest := e.lru.Back()
+		e.lru.Remove(oldest)
+		delete(e.cache, oldest.Value.(externalDecision).key)
+	}
+
+	e.cache[key] = e.lru.PushFront(decision)
+}
+
+// GetRequiredLevel retrieve the required level of authorization decided by the external endpoint, the failure
+// policy applies when the endpoint cannot be reached or replies with an invalid decision.
+func (e *externalPolicy) GetRequiredLevel(subject Subject, object Object) Level {
+	method := getRequestMethod(object.Headers)
+	headers := e.forwardedHeaders(object.Headers)
+	key := e.cacheKey(subject, object, method, headers)
+	now := e.clock.Now()
+
+	if e.ttl > 0 {
+		if level, ok := e.getCachedLevel(key, now); ok {
+			return level
+		}
+	}
+
+	policy, err := e.query(subject, object, method, headers)
+	if err != nil {
+		logging.Logger().Errorf("Unable to retrieve the external policy of subject %s and object %s%s, applying "+
+			"failure policy %s: %s", subject.String(), object.Domain, object.Path, e.failurePolicy, err)
+
+		return PolicyToLevel(e.failurePolicy)
+	}
+
+	level := PolicyToLevel(policy)
+
+	if e.ttl > 0 {
+		e.cacheLevel(key, level, now)
+	}
+
+	return level
+}
+
+func (e *externalPolicy) query(subject Subject, object Object, method string, headers http.Header) (string, error) {
+	ip := ""
+	if subject.IP != nil {
+		ip = subject.IP.String()
+	}
+
+	body, err := json.Marshal(externalPolicyRequest{
+		Subject: externalPolicySubject{
+			Username: subject.Username,
+			Groups:   subject.Groups,
+			IP:       ip,
+			Country:  subject.Country,
+		},
+		Object: externalPolicyObject{
+			Domain:  object.Domain,
+			Path:    object.Path,
+			Method:  method,
+			Headers: headers,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := e.client.Post(e.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	var decision externalPolicyResponse
+
+	// The body is limited so that a faulty endpoint cannot exhaust the memory of Authelia.
+	if err := json.NewDecoder(io.LimitReader(resp.Body, externalPolicyMaxResponseSize)).Decode(&decision); err != nil {
+		return "", err
+	}
+
+	if !schema.IsPolicyValid(decision.Policy) {
+		return "", fmt.Errorf("invalid policy '%s'", decision.Policy)
+	}
+
+	return decision.Policy, nil
+}
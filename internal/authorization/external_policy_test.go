@@ -0,0 +1,270 @@
+package authorization
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+// externalPolicyServer is a fake external endpoint recording the requests it receives. The failures of the handler
+// are reported to the test goroutine by checkErrors since the handler runs in a goroutine of the server.
+type externalPolicyServer struct {
+	*httptest.Server
+
+	mutex    sync.Mutex
+	calls    int
+	requests []externalPolicyRequest
+	errors   []error
+}
+
+// newExternalPolicyServer creates a fake external endpoint, it never replies if block is true.
+func newExternalPolicyServer(block bool) *externalPolicyServer {
+	s := &externalPolicyServer{}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body externalPolicyRequest
+
+		err := json.NewDecoder(r.Body).Decode(&body)
+
+		s.mutex.Lock()
+		s.calls++
+		s.requests = append(s.requests, body)
+
+		if err != nil {
+			s.errors = append(s.errors, err)
+		}
+		s.mutex.Unlock()
+
+		if block {
+			<-r.Context().Done()
+			return
+		}
+
+		policy := "deny"
+
+		switch {
+		case body.Object.Method == "DELETE":
+			policy = "two_factor"
+		case body.Subject.Username == "john":
+			policy = "one_factor"
+		case body.Object.Path == "/public":
+			policy = "bypass"
+		}
+
+		if err := json.NewEncoder(w).Encode(externalPolicyResponse{Policy: policy}); err != nil {
+			s.mutex.Lock()
+			s.errors = append(s.errors, err)
+			s.mutex.Unlock()
+		}
+	}))
+
+	return s
+}
+
+func (s *externalPolicyServer) Calls() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.calls
+}
+
+func (s *externalPolicyServer) LastRequest() externalPolicyRequest {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.requests[len(s.requests)-1]
+}
+
+func (s *externalPolicyServer) checkErrors(t *testing.T) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, err := range s.errors {
+		assert.NoError(t, err)
+	}
+}
+
+func newExternalPolicyTester(url string, clock *FixedClock, external schema.ExternalPolicyConfiguration) *AuthorizerTester {
+	external.URL = url
+
+	return NewAuthorizerBuilder().
+		WithDefaultPolicy("deny").
+		WithClock(clock).
+		WithExternalPolicy(external).
+		WithRule(schema.ACLRule{
+			Domains: []string{"external.example.com"},
+			Policy:  "external",
+		}).
+		Build()
+}
+
+func TestShouldDelegateDecisionToExternalPolicy(t *testing.T) {
+	server := newExternalPolicyServer(false)
+	defer server.Close()
+
+	clock := &FixedClock{now: time.Date(2020, time.June, 1, 12, 0, 0, 0, time.UTC)}
+	tester := newExternalPolicyTester(server.URL, clock, schema.ExternalPolicyConfiguration{
+		Timeout: "5s", CacheTTL: "0", FailurePolicy: "deny",
+	})
+
+	tester.CheckAuthorizations(t, John, "https://external.example.com/", OneFactor)
+	tester.CheckAuthorizations(t, Bob, "https://external.example.com/", Denied)
+	tester.CheckAuthorizations(t, AnonymousUser, "https://external.example.com/public", Bypass)
+	tester.CheckAuthorizationsWithHeaders(t, Subject{Username: "bob"}, "https://external.example.com/",
+		http.Header{"X-Forwarded-Method": []string{"DELETE"}}, TwoFactor)
+
+	// The rules which are not external do not reach the endpoint.
+	tester.CheckAuthorizations(t, John, "https://other.example.com/", Denied)
+
+	assert.Equal(t, 4, server.Calls())
+	assert.True(t, tester.IsSecondFactorEnabled())
+	server.checkErrors(t)
+}
+
+func TestShouldOnlyForwardAllowedHeadersToExternalPolicy(t *testing.T) {
+	server := newExternalPolicyServer(false)
+	defer server.Close()
+
+	clock := &FixedClock{now: time.Date(2020, time.June, 1, 12, 0, 0, 0, time.UTC)}
+	tester := newExternalPolicyTester(server.URL, clock, schema.ExternalPolicyConfiguration{
+		Timeout: "5s", CacheTTL: "0", FailurePolicy: "deny", Headers: []string{"x-tenant"},
+	})
+
+	tester.CheckAuthorizationsWithHeaders(t, Subject{Username: "john"}, "https://external.example.com/", http.Header{
+		"Cookie":              []string{"authelia_session=secret"},
+		"Authorization":       []string{"Basic am9objpwYXNzd29yZA=="},
+		"Proxy-Authorization": []string{"Basic am9objpwYXNzd29yZA=="},
+		"X-Tenant":            []string{"acme"},
+	}, OneFactor)
+
+	assert.Equal(t, http.Header{"X-Tenant": []string{"acme"}}, server.LastRequest().Object.Headers)
+	server.checkErrors(t)
+}
+
+func TestShouldCacheExternalPolicyDecisions(t *testing.T) {
+	server := newExternalPolicyServer(false)
+	defer server.Close()
+
+	clock := &FixedClock{now: time.Date(2020, time.June, 1, 12, 0, 0, 0, time.UTC)}
+	tester := newExternalPolicyTester(server.URL, clock, schema.ExternalPolicyConfiguration{
+		Timeout: "5s", CacheTTL: "1m", FailurePolicy: "deny", Headers: []string{"X-Tenant"},
+	})
+
+	tester.CheckAuthorizations(t, John, "https://external.example.com/", OneFactor)
+	tester.CheckAuthorizations(t, John, "https://external.example.com/", OneFactor)
+	assert.Equal(t, 1, server.Calls())
+
+	// Another subject, object or forwarded header is not served from the cache.
+	tester.CheckAuthorizations(t, Bob, "https://external.example.com/", Denied)
+	tester.CheckAuthorizations(t, John, "https://external.example.com/other", OneFactor)
+	tester.CheckAuthorizationsWithHeaders(t, John, "https://external.example.com/",
+		http.Header{"X-Tenant": []string{"acme"}}, OneFactor)
+	assert.Equal(t, 4, server.Calls())
+
+	// The headers which are not forwarded do not change the decision.
+	tester.CheckAuthorizationsWithHeaders(t, John, "https://external.example.com/",
+		http.Header{"X-Other": []string{"value"}}, OneFactor)
+	assert.Equal(t, 4, server.Calls())
+
+	clock.now = clock.now.Add(2 * time.Minute)
+
+	tester.CheckAuthorizations(t, John, "https://external.example.com/", OneFactor)
+	assert.Equal(t, 5, server.Calls())
+	server.checkErrors(t)
+}
+
+func TestShouldEvictExternalPolicyDecisions(t *testing.T) {
+	server := newExternalPolicyServer(false)
+	defer server.Close()
+
+	clock := &FixedClock{now: time.Date(2020, time.June, 1, 12, 0, 0, 0, time.UTC)}
+	tester := newExternalPolicyTester(server.URL, clock, schema.ExternalPolicyConfiguration{
+		Timeout: "5s", CacheTTL: "1m", CacheSize: 2, FailurePolicy: "deny",
+	})
+
+	tester.CheckAuthorizations(t, John, "https://external.example.com/a", OneFactor)
+	tester.CheckAuthorizations(t, John, "https://external.example.com/b", OneFactor)
+	tester.CheckAuthorizations(t, John, "https://external.example.com/a", OneFactor)
+	assert.Equal(t, 2, server.Calls())
+
+	// The least recently used decision is evicted once the cache is full.
+	tester.CheckAuthorizations(t, John, "https://external.example.com/c", OneFactor)
+	assert.Equal(t, 2, tester.external.lru.Len())
+	assert.Equal(t, 3, server.Calls())
+
+	tester.CheckAuthorizations(t, John, "https://external.example.com/a", OneFactor)
+	assert.Equal(t, 3, server.Calls())
+
+	tester.CheckAuthorizations(t, John, "https://external.example.com/b", OneFactor)
+	assert.Equal(t, 4, server.Calls())
+
+	// The expired decisions are removed when they are looked up.
+	clock.now = clock.now.Add(2 * time.Minute)
+
+	tester.CheckAuthorizations(t, John, "https://external.example.com/a", OneFactor)
+	assert.Equal(t, 5, server.Calls())
+	assert.Len(t, tester.external.cache, 2)
+	server.checkErrors(t)
+}
+
+func TestShouldApplyFailurePolicyOnExternalPolicyTimeout(t *testing.T) {
+	server := newExternalPolicyServer(true)
+	defer server.Close()
+
+	clock := &FixedClock{now: time.Date(2020, time.June, 1, 12, 0, 0, 0, time.UTC)}
+
+	closed := newExternalPolicyTester(server.URL, clock, schema.ExternalPolicyConfiguration{
+		Timeout: "1s", CacheTTL: "1m", FailurePolicy: "deny",
+	})
+	closed.external.client.Timeout = 10 * time.Millisecond
+	closed.CheckAuthorizations(t, John, "https://external.example.com/", Denied)
+
+	open := newExternalPolicyTester(server.URL, clock, schema.ExternalPolicyConfiguration{
+		Timeout: "1s", CacheTTL: "1m", FailurePolicy: "one_factor",
+	})
+	open.external.client.Timeout = 10 * time.Millisecond
+	open.CheckAuthorizations(t, Bob, "https://external.example.com/", OneFactor)
+
+	// The failures are not cached.
+	open.CheckAuthorizations(t, Bob, "https://external.example.com/", OneFactor)
+	assert.Equal(t, 3, server.Calls())
+	server.checkErrors(t)
+}
+
+func TestShouldApplyFailurePolicyOnInvalidExternalPolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"policy": "external"}`))
+	}))
+	defer server.Close()
+
+	clock := &FixedClock{now: time.Date(2020, time.June, 1, 12, 0, 0, 0, time.UTC)}
+	tester := newExternalPolicyTester(server.URL, clock, schema.ExternalPolicyConfiguration{
+		Timeout: "1s", CacheTTL: "1m", FailurePolicy: "two_factor",
+	})
+
+	tester.CheckAuthorizations(t, John, "https://external.example.com/", TwoFactor)
+}
+
+func TestShouldApplyFailurePolicyOnOversizedExternalPolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		padding := strings.Repeat(" ", externalPolicyMaxResponseSize)
+		_, _ = fmt.Fprintf(w, `{%s"policy": "bypass"}`, padding)
+	}))
+	defer server.Close()
+
+	clock := &FixedClock{now: time.Date(2020, time.June, 1, 12, 0, 0, 0, time.UTC)}
+	tester := newExternalPolicyTester(server.URL, clock, schema.ExternalPolicyConfiguration{
+		Timeout: "1s", CacheTTL: "1m", FailurePolicy: "two_factor",
+	})
+
+	tester.CheckAuthorizations(t, John, "https://external.example.com/", TwoFactor)
+}
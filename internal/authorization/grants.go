@@ -0,0 +1,113 @@
+package authorization
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/logging"
+	"github.com/authelia/authelia/internal/models"
+)
+
+// GrantsProvider is the provider of the temporary access grants, usually the storage.
+type GrantsProvider interface {
+	LoadActiveAccessGrants(now time.Time) ([]models.AccessGrant, error)
+}
+
+// grantToRule converts an access grant into a rule applying to the subject of the grant until it expires.
+func grantToRule(grant models.AccessGrant) aclRule {
+	rule := schema.ACLRule{
+		Name:     fmt.Sprintf("%s%s", grantNamePrefix, grant.ID),
+		Domains:  []string{grant.Domain},
+		Subjects: [][]string{{grant.Subject}},
+		Policy:   grant.Policy,
+	}
+
+	if grant.Resource != "" {
+		rule.Resources = []string{grant.Resource}
+	}
+
+	return aclRule{
+		ACLRule:   rule,
+		index:     -1,
+		expiresAt: grant.ExpiresAt,
+	}
+}
+
+// SetGrants replaces the access grants checked by the authorizer before the rules of the configuration.
+func (p *Authorizer) SetGrants(grants []models.AccessGrant) {
+	rules := make([]aclRule, 0, len(grants))
+
+	for _, grant := range grants {
+		rules = append(rules, grantToRule(grant))
+	}
+
+	p.grantsMutex.Lock()
+	p.grants = rules
+	p.grantsMutex.Unlock()
+}
+
+// ReloadGrants loads the access grants which have not expired yet from the provider.
+func (p *Authorizer) ReloadGrants(provider GrantsProvider) error {
+	grants, err := provider.LoadActiveAccessGrants(p.clock.Now())
+	if err != nil {
+		return err
+	}
+
+	p.SetGrants(grants)
+
+	return nil
+}
+
+// StartGrantsReload reloads the access grants from the provider every interval until StopGrantsReload is called.
+func (p *Authorizer) StartGrantsReload(provider GrantsProvider, interval time.Duration) {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for {
+			select {
+			case <-p.clock.After(interval):
+			case <-stop:
+				return
+			}
+
+			if err := p.ReloadGrants(provider); err != nil {
+				logging.Logger().Errorf("Unable to reload access grants: %s", err)
+			}
+		}
+	}()
+
+	p.stopGrantsReload = func() {
+		close(stop)
+		<-done
+	}
+}
+
+// StopGrantsReload stops the reload of the access grants started with StartGrantsReload, it waits for the reload in
+// progress if any.
+func (p *Authorizer) StopGrantsReload() {
+	if p.stopGrantsReload != nil {
+		p.stopGrantsReload()
+		p.stopGrantsReload = nil
+	}
+}
+
+// getActiveGrants returns the access grants which have not expired yet at the given time, the expiry is checked here
+// too since the grants are only reloaded periodically.
+func (p *Authorizer) getActiveGrants(now time.Time) []aclRule {
+	p.grantsMutex.RLock()
+	defer p.grantsMutex.RUnlock()
+
+	grants := make([]aclRule, 0, len(p.grants))
+
+	for _, grant := range p.grants {
+		if now.Before(grant.expiresAt) {
+			grants = append(grants, grant)
+		}
+	}
+
+	return grants
+}
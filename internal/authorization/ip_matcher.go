@@ -3,36 +3,45 @@ package authorization
 import (
 	"net"
 	"strings"
+
+	"github.com/authelia/authelia/internal/utils"
 )
 
-// isIPMatching check whether user's IP is in one of the network ranges.
-func isIPMatching(ip net.IP, networks []string) bool {
-	// If no network is provided in the rule, we match any network
+// parseNetworks parses the networks of a rule, expanding the network aliases, into a list of networks.
+// It returns nil if no network is provided meaning any network matches.
+func parseNetworks(networks []string, aliases map[string][]string) []*net.IPNet {
 	if len(networks) == 0 {
-		return true
+		return nil
 	}
 
-	for _, network := range networks {
-		if !strings.Contains(network, "/") {
-			if ip.String() == network {
-				return true
-			}
+	ipNets := []*net.IPNet{}
 
-			continue
+	for _, network := range networks {
+		expanded, ok := aliases[strings.ToLower(network)]
+		if !ok {
+			expanded = []string{network}
 		}
 
-		_, ipNet, err := net.ParseCIDR(network)
+		for _, n := range expanded {
+			ipNet, err := utils.ParseNetwork(n)
+			if err != nil {
+				// Rules are validated at startup so this should never happen.
+				continue
+			}
 
-		if err != nil {
-			// TODO(c.michaud): make sure the rule is valid at startup to
-			// to such a case here.
-			continue
+			ipNets = append(ipNets, ipNet)
 		}
+	}
 
-		if ipNet.Contains(ip) {
-			return true
-		}
+	return ipNets
+}
+
+// isIPMatching check whether user's IP is in one of the network ranges.
+func isIPMatching(ip net.IP, networks []*net.IPNet) bool {
+	// If no network is provided in the rule, we match any network
+	if networks == nil {
+		return true
 	}
 
-	return false
+	return utils.IsIPInNetworks(ip, networks)
 }
@@ -9,15 +9,29 @@ import (
 
 func TestIPMatcher(t *testing.T) {
 	// Default policy is 'allow all ips' if no IP is defined
-	assert.True(t, isIPMatching(net.ParseIP("127.0.0.1"), []string{}))
+	assert.True(t, isIPMatching(net.ParseIP("127.0.0.1"), parseNetworks([]string{}, nil)))
 
-	assert.True(t, isIPMatching(net.ParseIP("127.0.0.1"), []string{"127.0.0.1"}))
-	assert.False(t, isIPMatching(net.ParseIP("127.1"), []string{"127.0.0.1"}))
-	assert.False(t, isIPMatching(net.ParseIP("not-an-ip"), []string{"127.0.0.1"}))
+	assert.True(t, isIPMatching(net.ParseIP("127.0.0.1"), parseNetworks([]string{"127.0.0.1"}, nil)))
+	assert.False(t, isIPMatching(net.ParseIP("127.1"), parseNetworks([]string{"127.0.0.1"}, nil)))
+	assert.False(t, isIPMatching(net.ParseIP("not-an-ip"), parseNetworks([]string{"127.0.0.1"}, nil)))
 
-	assert.False(t, isIPMatching(net.ParseIP("127.0.0.1"), []string{"10.0.0.1"}))
-	assert.False(t, isIPMatching(net.ParseIP("127.0.0.1"), []string{"10.0.0.0/8"}))
+	assert.False(t, isIPMatching(net.ParseIP("127.0.0.1"), parseNetworks([]string{"10.0.0.1"}, nil)))
+	assert.False(t, isIPMatching(net.ParseIP("127.0.0.1"), parseNetworks([]string{"10.0.0.0/8"}, nil)))
 
-	assert.True(t, isIPMatching(net.ParseIP("10.230.5.1"), []string{"10.0.0.0/8"}))
-	assert.True(t, isIPMatching(net.ParseIP("10.230.5.1"), []string{"192.168.0.0/24", "10.0.0.0/8"}))
+	assert.True(t, isIPMatching(net.ParseIP("10.230.5.1"), parseNetworks([]string{"10.0.0.0/8"}, nil)))
+	assert.True(t, isIPMatching(net.ParseIP("10.230.5.1"), parseNetworks([]string{"192.168.0.0/24", "10.0.0.0/8"}, nil)))
+}
+
+func TestIPMatcherWithNetworkAliases(t *testing.T) {
+	aliases := map[string][]string{
+		"office": {"192.168.1.0/24", "10.0.0.1"},
+		"vpn":    {"172.16.0.0/12"},
+	}
+
+	assert.True(t, isIPMatching(net.ParseIP("192.168.1.20"), parseNetworks([]string{"office"}, aliases)))
+	assert.True(t, isIPMatching(net.ParseIP("10.0.0.1"), parseNetworks([]string{"Office"}, aliases)))
+	assert.False(t, isIPMatching(net.ParseIP("10.0.0.2"), parseNetworks([]string{"office"}, aliases)))
+	assert.True(t, isIPMatching(net.ParseIP("172.20.1.1"), parseNetworks([]string{"office", "vpn"}, aliases)))
+	assert.True(t, isIPMatching(net.ParseIP("127.0.0.1"), parseNetworks([]string{"vpn", "127.0.0.1"}, aliases)))
+	assert.False(t, isIPMatching(net.ParseIP("127.0.0.1"), parseNetworks([]string{"unknown"}, aliases)))
 }
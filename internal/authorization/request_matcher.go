@@ -0,0 +1,77 @@
+package authorization
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+// requestMatcher is a parsed condition on a request header or query parameter.
+type requestMatcher struct {
+	name    string
+	value   string
+	pattern *regexp.Regexp
+}
+
+// parseRequestMatchers parses the header or query parameter conditions of a rule. Validator already checked the
+// patterns compile so they are compiled once for all here.
+func parseRequestMatchers(matchers []schema.ACLRuleMatcher) []requestMatcher {
+	parsed := make([]requestMatcher, 0, len(matchers))
+
+	for _, m := range matchers {
+		matcher := requestMatcher{name: m.Name, value: m.Value}
+
+		if m.Pattern != "" {
+			matcher.pattern = regexp.MustCompile(m.Pattern)
+		}
+
+		parsed = append(parsed, matcher)
+	}
+
+	return parsed
+}
+
+// isMatching check whether one of the values of the parameter satisfies the condition. A missing parameter never
+// satisfies it.
+func (m requestMatcher) isMatching(values []string) bool {
+	for _, value := range values {
+		switch {
+		case m.pattern != nil:
+			if m.pattern.MatchString(value) {
+				return true
+			}
+		case m.value != "":
+			if value == m.value {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+
+	return false
+}
+
+// isHeadersMatching check whether the headers of the request satisfy all the header conditions of a rule.
+func isHeadersMatching(headers http.Header, matchers []requestMatcher) bool {
+	for _, m := range matchers {
+		if !m.isMatching(headers.Values(m.name)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isQueryMatching check whether the query string of the request satisfies all the query conditions of a rule.
+func isQueryMatching(query url.Values, matchers []requestMatcher) bool {
+	for _, m := range matchers {
+		if !m.isMatching(query[m.name]) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,140 @@
+package authorization
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+// timeRange is a range of minutes of the day, it spans midnight when the end is before the start.
+type timeRange struct {
+	start int
+	end   int
+}
+
+// schedule is the parsed time window during which an ACL rule is active.
+type schedule struct {
+	days     map[time.Weekday]bool
+	times    []timeRange
+	location *time.Location
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// parseMinuteOfDay parses a time of the form 15:04 into the number of minutes since midnight.
+func parseMinuteOfDay(value string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, err
+	}
+
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// parseTimeRange parses a time range of the form 09:00-18:00.
+func parseTimeRange(value string) (*timeRange, error) {
+	bounds := strings.Split(value, "-")
+	if len(bounds) != 2 {
+		return nil, fmt.Errorf("Time range %s must be of the form 09:00-18:00", value)
+	}
+
+	start, err := parseMinuteOfDay(bounds[0])
+	if err != nil {
+		return nil, err
+	}
+
+	end, err := parseMinuteOfDay(bounds[1])
+	if err != nil {
+		return nil, err
+	}
+
+	return &timeRange{start: start, end: end}, nil
+}
+
+// parseSchedule parses the schedule of a rule, it returns nil if the rule is always active.
+func parseSchedule(configuration *schema.ACLRuleSchedule) *schedule {
+	if configuration == nil {
+		return nil
+	}
+
+	s := &schedule{location: time.Local}
+
+	if configuration.TimeZone != "" {
+		location, err := time.LoadLocation(configuration.TimeZone)
+		if err == nil {
+			s.location = location
+		}
+	}
+
+	if len(configuration.Days) > 0 {
+		s.days = make(map[time.Weekday]bool)
+
+		for _, day := range configuration.Days {
+			if weekday, ok := weekdays[strings.ToLower(day)]; ok {
+				s.days[weekday] = true
+			}
+		}
+	}
+
+	for _, value := range configuration.Times {
+		r, err := parseTimeRange(value)
+		if err != nil {
+			// Rules are validated at startup so this should never happen.
+			continue
+		}
+
+		s.times = append(s.times, *r)
+	}
+
+	return s
+}
+
+func (s *schedule) isDayMatching(day time.Weekday) bool {
+	return s.days == nil || s.days[day]
+}
+
+// isScheduleMatching check whether the time is inside the time window of the schedule. A time range spanning
+// midnight belongs to the day on which it starts.
+func isScheduleMatching(s *schedule, now time.Time) bool {
+	// If no schedule is provided in the rule, the rule is always active.
+	if s == nil {
+		return true
+	}
+
+	now = now.In(s.location)
+	minute := now.Hour()*60 + now.Minute()
+	day := now.Weekday()
+
+	if len(s.times) == 0 {
+		return s.isDayMatching(day)
+	}
+
+	for _, r := range s.times {
+		switch {
+		case r.start <= r.end:
+			if minute >= r.start && minute < r.end && s.isDayMatching(day) {
+				return true
+			}
+		case minute >= r.start:
+			if s.isDayMatching(day) {
+				return true
+			}
+		case minute < r.end:
+			if s.isDayMatching((day + 6) % 7) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
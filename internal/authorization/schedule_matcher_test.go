@@ -0,0 +1,65 @@
+package authorization
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+func TestShouldMatchAnyTimeWithoutSchedule(t *testing.T) {
+	assert.True(t, isScheduleMatching(parseSchedule(nil), time.Now()))
+}
+
+func TestShouldMatchScheduleDays(t *testing.T) {
+	s := parseSchedule(&schema.ACLRuleSchedule{
+		Days:     []string{"Monday", "friday"},
+		TimeZone: "UTC",
+	})
+
+	// 2020-06-01 is a Monday.
+	assert.True(t, isScheduleMatching(s, time.Date(2020, 6, 1, 3, 0, 0, 0, time.UTC)))
+	assert.False(t, isScheduleMatching(s, time.Date(2020, 6, 2, 3, 0, 0, 0, time.UTC)))
+	assert.True(t, isScheduleMatching(s, time.Date(2020, 6, 5, 23, 59, 0, 0, time.UTC)))
+}
+
+func TestShouldMatchScheduleTimes(t *testing.T) {
+	s := parseSchedule(&schema.ACLRuleSchedule{
+		Days:     []string{"monday"},
+		Times:    []string{"09:00-18:00"},
+		TimeZone: "UTC",
+	})
+
+	assert.False(t, isScheduleMatching(s, time.Date(2020, 6, 1, 8, 59, 0, 0, time.UTC)))
+	assert.True(t, isScheduleMatching(s, time.Date(2020, 6, 1, 9, 0, 0, 0, time.UTC)))
+	assert.True(t, isScheduleMatching(s, time.Date(2020, 6, 1, 17, 59, 0, 0, time.UTC)))
+	assert.False(t, isScheduleMatching(s, time.Date(2020, 6, 1, 18, 0, 0, 0, time.UTC)))
+	assert.False(t, isScheduleMatching(s, time.Date(2020, 6, 2, 10, 0, 0, 0, time.UTC)))
+}
+
+func TestShouldMatchScheduleTimesSpanningMidnight(t *testing.T) {
+	s := parseSchedule(&schema.ACLRuleSchedule{
+		Days:     []string{"saturday"},
+		Times:    []string{"22:00-02:00"},
+		TimeZone: "UTC",
+	})
+
+	// 2020-06-06 is a Saturday.
+	assert.True(t, isScheduleMatching(s, time.Date(2020, 6, 6, 23, 0, 0, 0, time.UTC)))
+	assert.True(t, isScheduleMatching(s, time.Date(2020, 6, 7, 1, 0, 0, 0, time.UTC)))
+	assert.False(t, isScheduleMatching(s, time.Date(2020, 6, 6, 1, 0, 0, 0, time.UTC)))
+	assert.False(t, isScheduleMatching(s, time.Date(2020, 6, 7, 23, 0, 0, 0, time.UTC)))
+}
+
+func TestShouldMatchScheduleInTimeZone(t *testing.T) {
+	s := parseSchedule(&schema.ACLRuleSchedule{
+		Times:    []string{"09:00-18:00"},
+		TimeZone: "America/New_York",
+	})
+
+	// 13:00 UTC is 09:00 in New York during daylight saving time.
+	assert.True(t, isScheduleMatching(s, time.Date(2020, 6, 1, 13, 0, 0, 0, time.UTC)))
+	assert.False(t, isScheduleMatching(s, time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)))
+}
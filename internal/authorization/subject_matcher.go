@@ -1,11 +1,54 @@
 package authorization
 
 import (
+	"path"
 	"strings"
 
 	"github.com/authelia/authelia/internal/utils"
 )
 
+// isSingleSubjectMatching check whether the subject matches a single positive subject of a rule.
+func isSingleSubjectMatching(subject Subject, ruleSubject string) bool {
+	if strings.HasPrefix(ruleSubject, userPrefix) {
+		user := strings.Trim(ruleSubject[len(userPrefix):], " ")
+		return user == subject.Username
+	}
+
+	if strings.HasPrefix(ruleSubject, groupPrefix) {
+		group := strings.Trim(ruleSubject[len(groupPrefix):], " ")
+		return utils.IsStringInSlice(group, subject.Groups)
+	}
+
+	if strings.HasPrefix(ruleSubject, emailPrefix) {
+		pattern := strings.ToLower(strings.Trim(ruleSubject[len(emailPrefix):], " "))
+		return isEmailMatching(pattern, subject.Emails)
+	}
+
+	if strings.HasPrefix(ruleSubject, attributePrefix) {
+		parts := strings.SplitN(ruleSubject[len(attributePrefix):], "=", 2)
+		if len(parts) != 2 {
+			return false
+		}
+
+		return utils.IsStringInSlice(parts[1], subject.Attributes[strings.Trim(parts[0], " ")])
+	}
+
+	return false
+}
+
+// isEmailMatching check whether one of the emails of the subject matches the glob pattern, case insensitively.
+func isEmailMatching(pattern string, emails []string) bool {
+	for _, email := range emails {
+		if matched, _ := path.Match(pattern, strings.ToLower(email)); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isSubjectMatching check whether the subject matches all the subjects of a rule. A negated subject matches
+// identified users who do not match the subject, it never matches anonymous users.
 func isSubjectMatching(subject Subject, subjectRule []string) bool {
 	for _, ruleSubject := range subjectRule {
 		// If no subject is provided in the rule, we match any user.
@@ -13,21 +56,17 @@ func isSubjectMatching(subject Subject, subjectRule []string) bool {
 			continue
 		}
 
-		if strings.HasPrefix(ruleSubject, userPrefix) {
-			user := strings.Trim(ruleSubject[len(userPrefix):], " ")
-			if user == subject.Username {
+		if strings.HasPrefix(ruleSubject, negationPrefix) {
+			if subject.Username != "" && !isSingleSubjectMatching(subject, ruleSubject[len(negationPrefix):]) {
 				continue
 			}
-		}
 
-		if strings.HasPrefix(ruleSubject, groupPrefix) {
-			group := strings.Trim(ruleSubject[len(groupPrefix):], " ")
-			if utils.IsStringInSlice(group, subject.Groups) {
-				continue
-			}
+			return false
 		}
 
-		return false
+		if !isSingleSubjectMatching(subject, ruleSubject) {
+			return false
+		}
 	}
 
 	return true
@@ -0,0 +1,171 @@
+package commands
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/authelia/authelia/internal/configuration"
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/storage"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+var (
+	grantsConfigPath string
+	grantSubject     string
+	grantDomain      string
+	grantResource    string
+	grantPolicy      string
+	grantDuration    string
+)
+
+func init() {
+	GrantsCmd.PersistentFlags().StringVar(&grantsConfigPath, "config", "", "Configuration file")
+
+	err := GrantsCmd.MarkPersistentFlagRequired("config")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	GrantsAddCmd.Flags().StringVar(&grantSubject, "subject", "", "The user or group the access is granted to, e.g. user:john or group:contractors")
+	GrantsAddCmd.Flags().StringVar(&grantDomain, "domain", "", "The domain the access is granted to")
+	GrantsAddCmd.Flags().StringVar(&grantResource, "resource", "", "The pattern the path of the resources must match, any resource if not provided")
+	GrantsAddCmd.Flags().StringVar(&grantPolicy, "policy", "one_factor", "The policy applied to the subject, either 'bypass', 'one_factor', 'two_factor' or 'deny'")
+	GrantsAddCmd.Flags().StringVar(&grantDuration, "duration", "", "The duration of the grant in duration notation, e.g. 48h")
+
+	for _, flag := range []string{"subject", "domain", "duration"} {
+		if err := GrantsAddCmd.MarkFlagRequired(flag); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	GrantsCmd.AddCommand(GrantsAddCmd, GrantsListCmd, GrantsRevokeCmd)
+}
+
+// GrantsCmd is the parent command of the commands managing the temporary access grants.
+var GrantsCmd = &cobra.Command{
+	Use:   "grants",
+	Short: "Commands related to the temporary access grants",
+}
+
+// GrantsAddCmd grants a temporary access to a domain.
+var GrantsAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Grant a temporary access to a domain",
+	Run: func(cobraCmd *cobra.Command, args []string) {
+		duration, err := utils.ParseDurationString(grantDuration)
+		if err != nil {
+			log.Fatalf("Error occurred parsing duration string: %s", err)
+		}
+
+		if err := validateGrant(grantSubject, grantResource, grantPolicy, duration); err != nil {
+			log.Fatal(err)
+		}
+
+		grant := models.AccessGrant{
+			ID:        utils.RandomString(16, utils.AlphaNumericCharacters),
+			Subject:   grantSubject,
+			Domain:    grantDomain,
+			Resource:  grantResource,
+			Policy:    grantPolicy,
+			ExpiresAt: utils.RealClock{}.Now().Add(duration),
+		}
+
+		if err := newGrantsStorageProvider().SaveAccessGrant(grant); err != nil {
+			log.Fatalf("Unable to save the access grant: %s", err)
+		}
+
+		fmt.Printf("Access grant %s has been added, it expires at %s\n", grant.ID, grant.ExpiresAt.Format(time.RFC3339))
+	},
+}
+
+// GrantsListCmd lists the access grants which have not expired yet.
+var GrantsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the access grants which have not expired yet",
+	Run: func(cobraCmd *cobra.Command, args []string) {
+		grants, err := newGrantsStorageProvider().LoadActiveAccessGrants(utils.RealClock{}.Now())
+		if err != nil {
+			log.Fatalf("Unable to load the access grants: %s", err)
+		}
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "ID\tSUBJECT\tDOMAIN\tRESOURCE\tPOLICY\tEXPIRES AT")
+
+		for _, grant := range grants {
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\n", grant.ID, grant.Subject, grant.Domain, grant.Resource,
+				grant.Policy, grant.ExpiresAt.Format(time.RFC3339))
+		}
+
+		if err := writer.Flush(); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+// GrantsRevokeCmd revokes an access grant before it expires.
+var GrantsRevokeCmd = &cobra.Command{
+	Use:   "revoke [id]",
+	Short: "Revoke an access grant before it expires",
+	Run: func(cobraCmd *cobra.Command, args []string) {
+		deleted, err := newGrantsStorageProvider().DeleteAccessGrant(args[0])
+		if err != nil {
+			log.Fatalf("Unable to revoke the access grant: %s", err)
+		}
+
+		if !deleted {
+			log.Fatalf("Access grant %s does not exist", args[0])
+		}
+
+		fmt.Printf("Access grant %s has been revoked\n", args[0])
+	},
+	Args: cobra.ExactArgs(1),
+}
+
+func validateGrant(subject, resource, policy string, duration time.Duration) error {
+	if subject == "" || !schema.IsSubjectValid(subject) {
+		return fmt.Errorf("Subject must start with 'user:', 'group:', 'email:' or 'attr:', optionally negated with '!'")
+	}
+
+	if !schema.IsPolicyValid(policy) {
+		return fmt.Errorf("Policy must either be 'deny', 'two_factor', 'one_factor' or 'bypass'")
+	}
+
+	if resource != "" {
+		if _, err := regexp.Compile(resource); err != nil {
+			return fmt.Errorf("Resource must be a valid pattern: %s", err)
+		}
+	}
+
+	if duration <= 0 {
+		return fmt.Errorf("Duration must be above 0")
+	}
+
+	return nil
+}
+
+// newGrantsStorageProvider reads the configuration and connects to the storage holding the access grants.
+func newGrantsStorageProvider() storage.Provider {
+	config, errs := configuration.Read(grantsConfigPath)
+	if len(errs) != 0 {
+		for _, err := range errs {
+			log.Println(err)
+		}
+
+		log.Fatalf("Unable to read the configuration %s", grantsConfigPath)
+	}
+
+	provider := storage.NewProvider(config.Storage)
+	if provider == nil {
+		log.Fatal("Unrecognized storage backend")
+	}
+
+	return provider
+}
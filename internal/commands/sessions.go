@@ -0,0 +1,159 @@
+package commands
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/configuration"
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/session"
+	"github.com/authelia/authelia/internal/storage"
+)
+
+var sessionsConfigPath string
+
+func init() {
+	SessionsCmd.PersistentFlags().StringVar(&sessionsConfigPath, "config", "", "Configuration file")
+
+	err := SessionsCmd.MarkPersistentFlagRequired("config")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	SessionsCmd.AddCommand(SessionsListCmd, SessionsRevokeCmd)
+}
+
+// SessionsCmd is the parent command of the commands managing the active sessions of the users.
+var SessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "Commands related to the active sessions of the users",
+}
+
+// SessionsListCmd lists the active sessions of a user.
+var SessionsListCmd = &cobra.Command{
+	Use:   "list [username]",
+	Short: "List the active sessions of a user",
+	Run: func(cobraCmd *cobra.Command, args []string) {
+		config := readSessionsConfiguration()
+		username := getSessionsUsername(config, args[0])
+
+		sessions, err := newSessionsProvider(config, newSessionsStorageProvider(config)).GetActiveSessions(username)
+		if err != nil {
+			log.Fatalf("Unable to retrieve the active sessions: %s", err)
+		}
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "ID\tCREATED AT\tLAST ACTIVITY\tIP\tUSER AGENT")
+
+		for _, s := range sessions {
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", s.ID, time.Unix(s.CreatedAt, 0).Format(time.RFC3339),
+				time.Unix(s.LastActivity, 0).Format(time.RFC3339), s.IP, s.UserAgent)
+		}
+
+		if err := writer.Flush(); err != nil {
+			log.Fatal(err)
+		}
+	},
+	Args: cobra.ExactArgs(1),
+}
+
+// SessionsRevokeCmd revokes all the active sessions of a user.
+var SessionsRevokeCmd = &cobra.Command{
+	Use:   "revoke [username]",
+	Short: "Revoke all the active sessions of a user",
+	Run: func(cobraCmd *cobra.Command, args []string) {
+		config := readSessionsConfiguration()
+		username := getSessionsUsername(config, args[0])
+
+		storageProvider := newSessionsStorageProvider(config)
+
+		// Bumping the generation invalidates the sessions of the user whatever the session provider, including the
+		// ones held in the memory of a running instance.
+		if err := storageProvider.IncrementSessionGeneration(username); err != nil {
+			log.Fatalf("Unable to invalidate the sessions: %s", err)
+		}
+
+		if config.Session.Redis == nil && config.Session.SQL == nil {
+			fmt.Printf("The sessions of user %s have been invalidated\n", username)
+			return
+		}
+
+		revoked, err := newSessionsProvider(config, storageProvider).RevokeActiveSessions(username)
+		if err != nil {
+			log.Fatalf("Unable to revoke the active sessions: %s", err)
+		}
+
+		fmt.Printf("%d session(s) of user %s have been revoked\n", revoked, username)
+	},
+	Args: cobra.ExactArgs(1),
+}
+
+// readSessionsConfiguration reads the configuration given to the sessions commands.
+func readSessionsConfiguration() *schema.Configuration {
+	config, errs := configuration.Read(sessionsConfigPath)
+	if len(errs) != 0 {
+		for _, err := range errs {
+			log.Println(err)
+		}
+
+		log.Fatalf("Unable to read the configuration %s", sessionsConfigPath)
+	}
+
+	return config
+}
+
+// getSessionsUsername returns the username of the authentication backend matching the one given to the sessions
+// commands, since the sessions are recorded with it while the backend may match the usernames regardless of their case.
+// The given username is kept when the user doesn't exist in the backend anymore.
+func getSessionsUsername(config *schema.Configuration, username string) string {
+	var userProvider authentication.UserProvider
+
+	switch {
+	case config.AuthenticationBackend.File != nil:
+		userProvider = authentication.NewFileUserProvider(config.AuthenticationBackend.File)
+	case config.AuthenticationBackend.Ldap != nil:
+		userProvider = authentication.NewLDAPUserProvider(*config.AuthenticationBackend.Ldap)
+	default:
+		log.Fatal("Unrecognized authentication backend")
+	}
+
+	details, err := userProvider.GetDetails(username)
+
+	switch {
+	case err == authentication.ErrUserNotFound:
+		log.Printf("User %s doesn't exist in the authentication backend", username)
+		return username
+	case err != nil:
+		log.Fatalf("Unable to retrieve the details of user %s: %s", username, err)
+	}
+
+	return details.Username
+}
+
+// newSessionsStorageProvider connects to the storage holding the session generations and the sessions of the SQL
+// session provider.
+func newSessionsStorageProvider(config *schema.Configuration) storage.Provider {
+	provider := storage.NewProvider(config.Storage)
+	if provider == nil {
+		log.Fatal("Unrecognized storage backend")
+	}
+
+	return provider
+}
+
+// newSessionsProvider connects to the store holding the sessions.
+func newSessionsProvider(config *schema.Configuration, storageProvider storage.Provider) *session.Provider {
+	// The sessions of the memory provider only live in the memory of the running instance.
+	if config.Session.Redis == nil && config.Session.SQL == nil {
+		log.Fatal("Listing the sessions from the command line requires the Redis or SQL session provider, " +
+			"the sessions of the memory provider can only be listed from the portal")
+	}
+
+	return session.NewProvider(config.Session, config.Domains, config.Server.TrustedProxies, storageProvider)
+}
@@ -3,16 +3,46 @@ package schema
 import (
 	"fmt"
 	"net"
+	"net/url"
+	"path"
+	"regexp"
 	"strings"
+	"time"
+
+	"github.com/authelia/authelia/internal/utils"
 )
 
 // ACLRule represents one ACL rule entry; "weak" coerces a single value into slice.
 type ACLRule struct {
-	Domains   []string   `mapstructure:"domain,weak"`
-	Policy    string     `mapstructure:"policy"`
-	Subjects  [][]string `mapstructure:"subject,weak"`
-	Networks  []string   `mapstructure:"networks"`
-	Resources []string   `mapstructure:"resources"`
+	Name                 string           `mapstructure:"name"`
+	Domains              []string         `mapstructure:"domain,weak"`
+	Policy               string           `mapstructure:"policy"`
+	Subjects             [][]string       `mapstructure:"subject,weak"`
+	Networks             []string         `mapstructure:"networks"`
+	Resources            []string         `mapstructure:"resources"`
+	RequiredMethods      []string         `mapstructure:"required_methods"`
+	MaxAuthenticationAge string           `mapstructure:"max_authentication_age"`
+	Schedule             *ACLRuleSchedule `mapstructure:"schedule"`
+	Countries            []string         `mapstructure:"countries"`
+	NotCountries         []string         `mapstructure:"not_countries"`
+	Headers              []ACLRuleMatcher `mapstructure:"headers"`
+	Query                []ACLRuleMatcher `mapstructure:"query"`
+	DenyBehavior         string           `mapstructure:"deny_behavior"`
+}
+
+// ACLRuleMatcher represents a condition on a request header or query parameter. The parameter must be equal to the
+// value if provided, match the pattern if provided or only be present otherwise.
+type ACLRuleMatcher struct {
+	Name    string `mapstructure:"name"`
+	Value   string `mapstructure:"value"`
+	Pattern string `mapstructure:"pattern"`
+}
+
+// ACLRuleSchedule represents the time window during which an ACL rule is active.
+type ACLRuleSchedule struct {
+	Days     []string `mapstructure:"days"`
+	Times    []string `mapstructure:"times"`
+	TimeZone string   `mapstructure:"time_zone"`
 }
 
 // IsPolicyValid check if policy is valid.
@@ -20,14 +50,81 @@ func IsPolicyValid(policy string) bool {
 	return policy == denyPolicy || policy == "one_factor" || policy == "two_factor" || policy == "bypass"
 }
 
-// IsSubjectValid check if a subject is valid.
+// IsDenyBehaviorValid check if a deny behavior is valid.
+func IsDenyBehaviorValid(behavior string) bool {
+	return utils.IsStringInSlice(behavior, []string{DenyBehaviorRedirect, DenyBehaviorForbidden, DenyBehaviorAccessDenied})
+}
+
+// IsSubjectValid check if a subject is valid, it can be negated with a leading '!'. The email subjects must be valid
+// glob patterns and the attribute subjects must be of the form 'attr:<name>=<value>'.
 func IsSubjectValid(subject string) bool {
-	return subject == "" || strings.HasPrefix(subject, "user:") || strings.HasPrefix(subject, "group:")
+	if strings.HasPrefix(subject, "!") {
+		subject = subject[1:]
+	} else if subject == "" {
+		return true
+	}
+
+	switch {
+	case strings.HasPrefix(subject, "user:"), strings.HasPrefix(subject, "group:"):
+		return true
+	case strings.HasPrefix(subject, "email:"):
+		pattern := strings.TrimPrefix(subject, "email:")
+		_, err := path.Match(pattern, "")
+
+		return pattern != "" && err == nil
+	case strings.HasPrefix(subject, "attr:"):
+		parts := strings.SplitN(strings.TrimPrefix(subject, "attr:"), "=", 2)
+
+		return len(parts) == 2 && parts[0] != ""
+	}
+
+	return false
+}
+
+// IsDayValid check if a day of the week is valid.
+func IsDayValid(day string) bool {
+	return utils.IsStringInSlice(strings.ToLower(day), weekdays)
 }
 
-// IsNetworkValid check if a network is valid.
+// IsTimeRangeValid check if a time range of the form 09:00-18:00 is valid.
+func IsTimeRangeValid(timeRange string) bool {
+	bounds := strings.Split(timeRange, "-")
+	if len(bounds) != 2 {
+		return false
+	}
+
+	for _, bound := range bounds {
+		if _, err := time.Parse("15:04", strings.TrimSpace(bound)); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsCountryValid check if a country is a valid ISO 3166-1 alpha-2 code.
+func IsCountryValid(country string) bool {
+	if len(country) != 2 {
+		return false
+	}
+
+	for _, c := range strings.ToUpper(country) {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsNetworkValid check if a network is valid, either a single IP address or a network in CIDR notation.
 func IsNetworkValid(network string) bool {
+	if !strings.Contains(network, "/") {
+		return net.ParseIP(network) != nil
+	}
+
 	_, _, err := net.ParseCIDR(network)
+
 	return err == nil
 }
 
@@ -37,29 +134,191 @@ func (r *ACLRule) Validate(validator *StructValidator) {
 		validator.Push(fmt.Errorf("Domain must be provided"))
 	}
 
-	if !IsPolicyValid(r.Policy) {
-		validator.Push(fmt.Errorf("A policy must either be 'deny', 'two_factor', 'one_factor' or 'bypass'"))
+	if !IsPolicyValid(r.Policy) && r.Policy != ExternalPolicy {
+		validator.Push(fmt.Errorf("A policy must either be 'deny', 'two_factor', 'one_factor', 'bypass' or 'external'"))
 	}
 
 	for i, subjectRule := range r.Subjects {
 		for j, subject := range subjectRule {
 			if !IsSubjectValid(subject) {
-				validator.Push(fmt.Errorf("Subject %d-%d must start with 'user:' or 'group:'", i, j))
+				validator.Push(fmt.Errorf("Subject %d-%d must start with 'user:', 'group:', 'email:' or 'attr:', optionally negated with '!'", i, j))
 			}
 		}
 	}
 
-	for i, network := range r.Networks {
-		if !IsNetworkValid(network) {
-			validator.Push(fmt.Errorf("Network %d must be a valid CIDR", i))
+	if r.DenyBehavior != "" {
+		if r.Policy != denyPolicy {
+			validator.Push(fmt.Errorf("Deny behavior can only be used with the 'deny' policy"))
+		}
+
+		if !IsDenyBehaviorValid(r.DenyBehavior) {
+			validator.Push(fmt.Errorf("Deny behavior must either be 'redirect', 'forbidden' or 'access_denied'"))
+		}
+	}
+
+	if r.MaxAuthenticationAge != "" {
+		if r.Policy != "one_factor" && r.Policy != "two_factor" {
+			validator.Push(fmt.Errorf("Max authentication age can only be used with the 'one_factor' or 'two_factor' policy"))
+		}
+
+		if _, err := utils.ParseDurationString(r.MaxAuthenticationAge); err != nil {
+			validator.Push(fmt.Errorf("Error occurred parsing max authentication age string: %s", err))
+		}
+	}
+
+	if r.Schedule != nil {
+		r.Schedule.Validate(validator)
+	}
+
+	if len(r.Countries) > 0 && len(r.NotCountries) > 0 {
+		validator.Push(fmt.Errorf("Countries and not countries cannot be both used in the same rule"))
+	}
+
+	for i, country := range r.Countries {
+		if !IsCountryValid(country) {
+			validator.Push(fmt.Errorf("Country %d must be a two letters ISO 3166-1 code", i))
+		}
+	}
+
+	for i, country := range r.NotCountries {
+		if !IsCountryValid(country) {
+			validator.Push(fmt.Errorf("Not country %d must be a two letters ISO 3166-1 code", i))
+		}
+	}
+
+	for i, matcher := range r.Headers {
+		matcher.Validate(validator, fmt.Sprintf("Header %d", i))
+	}
+
+	for i, matcher := range r.Query {
+		matcher.Validate(validator, fmt.Sprintf("Query parameter %d", i))
+	}
+}
+
+// Validate validate a header or query parameter condition of an ACL rule, the errors are prefixed by the label.
+func (m *ACLRuleMatcher) Validate(validator *StructValidator, label string) {
+	if m.Name == "" {
+		validator.Push(fmt.Errorf("%s must have a name", label))
+	}
+
+	if m.Value != "" && m.Pattern != "" {
+		validator.Push(fmt.Errorf("%s cannot have both a value and a pattern", label))
+	}
+
+	if m.Pattern != "" {
+		if _, err := regexp.Compile(m.Pattern); err != nil {
+			validator.Push(fmt.Errorf("%s has an invalid pattern: %s", label, err))
+		}
+	}
+}
+
+// Validate validate the schedule of an ACL rule.
+func (s *ACLRuleSchedule) Validate(validator *StructValidator) {
+	if len(s.Days) == 0 && len(s.Times) == 0 {
+		validator.Push(fmt.Errorf("Schedule must define days or times"))
+	}
+
+	for i, day := range s.Days {
+		if !IsDayValid(day) {
+			validator.Push(fmt.Errorf("Schedule day %d must be a day of the week such as 'monday'", i))
+		}
+	}
+
+	for i, timeRange := range s.Times {
+		if !IsTimeRangeValid(timeRange) {
+			validator.Push(fmt.Errorf("Schedule time %d must be a time range of the form '09:00-18:00'", i))
+		}
+	}
+
+	if s.TimeZone != "" {
+		if _, err := time.LoadLocation(s.TimeZone); err != nil {
+			validator.Push(fmt.Errorf("Schedule time zone %s is not valid: %s", s.TimeZone, err))
+		}
+	}
+}
+
+// ExternalPolicyConfiguration represents the configuration of the service deciding the 'external' policy.
+type ExternalPolicyConfiguration struct {
+	URL           string   `mapstructure:"url"`
+	Timeout       string   `mapstructure:"timeout"`
+	CacheTTL      string   `mapstructure:"cache_ttl"`
+	CacheSize     int      `mapstructure:"cache_size"`
+	FailurePolicy string   `mapstructure:"failure_policy"`
+	Headers       []string `mapstructure:"headers"`
+}
+
+// DefaultExternalPolicyConfiguration represents default configuration parameters for the external policy.
+var DefaultExternalPolicyConfiguration = ExternalPolicyConfiguration{
+	Timeout:       "5s",
+	CacheTTL:      "1m",
+	CacheSize:     10000,
+	FailurePolicy: denyPolicy,
+}
+
+// externalPolicyForbiddenHeaders are the headers carrying the credentials of the user, they are never forwarded to
+// the external policy.
+var externalPolicyForbiddenHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization"}
+
+// Validate validate and update the configuration of the external policy.
+func (e *ExternalPolicyConfiguration) Validate(validator *StructValidator) {
+	if e.URL == "" {
+		validator.Push(fmt.Errorf("An url must be provided for the external policy"))
+	} else if u, err := url.ParseRequestURI(e.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		validator.Push(fmt.Errorf("The url of the external policy must be a valid http or https url"))
+	}
+
+	if e.Timeout == "" {
+		e.Timeout = DefaultExternalPolicyConfiguration.Timeout
+	} else if _, err := utils.ParseDurationString(e.Timeout); err != nil {
+		validator.Push(fmt.Errorf("Error occurred parsing external policy timeout string: %s", err))
+	}
+
+	if e.CacheTTL == "" {
+		e.CacheTTL = DefaultExternalPolicyConfiguration.CacheTTL
+	} else if _, err := utils.ParseDurationString(e.CacheTTL); err != nil {
+		validator.Push(fmt.Errorf("Error occurred parsing external policy cache_ttl string: %s", err))
+	}
+
+	if e.CacheSize == 0 {
+		e.CacheSize = DefaultExternalPolicyConfiguration.CacheSize
+	} else if e.CacheSize < 0 {
+		validator.Push(fmt.Errorf("The cache size of the external policy must be greater than 0"))
+	}
+
+	if e.FailurePolicy == "" {
+		e.FailurePolicy = DefaultExternalPolicyConfiguration.FailurePolicy
+	} else if !IsPolicyValid(e.FailurePolicy) {
+		validator.Push(fmt.Errorf("The failure policy of the external policy must either be 'deny', 'two_factor', 'one_factor' or 'bypass'"))
+	}
+
+	for _, header := range e.Headers {
+		for _, forbidden := range externalPolicyForbiddenHeaders {
+			if strings.EqualFold(header, forbidden) {
+				validator.Push(fmt.Errorf("The header %s carries credentials and cannot be forwarded to the external policy", header))
+			}
 		}
 	}
 }
 
 // AccessControlConfiguration represents the configuration related to ACLs.
 type AccessControlConfiguration struct {
-	DefaultPolicy string    `mapstructure:"default_policy"`
-	Rules         []ACLRule `mapstructure:"rules"`
+	DefaultPolicy string `mapstructure:"default_policy"`
+	// The deny behavior applied when the default policy of the protected domain denies the access.
+	DefaultDenyBehavior string                       `mapstructure:"default_deny_behavior"`
+	Networks            map[string][]string          `mapstructure:"networks"`
+	External            *ExternalPolicyConfiguration `mapstructure:"external"`
+	Rules               []ACLRule                    `mapstructure:"rules"`
+}
+
+// HasCountryConditions returns true if at least one rule has a condition on the country of the client.
+func (acc *AccessControlConfiguration) HasCountryConditions() bool {
+	for _, rule := range acc.Rules {
+		if len(rule.Countries) > 0 || len(rule.NotCountries) > 0 {
+			return true
+		}
+	}
+
+	return false
 }
 
 // Validate validate the access control configuration.
@@ -71,4 +330,53 @@ func (acc *AccessControlConfiguration) Validate(validator *StructValidator) {
 	if !IsPolicyValid(acc.DefaultPolicy) {
 		validator.Push(fmt.Errorf("'default_policy' must either be 'deny', 'two_factor', 'one_factor' or 'bypass'"))
 	}
+
+	if acc.DefaultDenyBehavior != "" && !IsDenyBehaviorValid(acc.DefaultDenyBehavior) {
+		validator.Push(fmt.Errorf("'default_deny_behavior' must either be 'redirect', 'forbidden' or 'access_denied'"))
+	}
+
+	for name, networks := range acc.Networks {
+		if len(networks) == 0 {
+			validator.Push(fmt.Errorf("Network alias %s must contain at least one network", name))
+		}
+
+		for i, network := range networks {
+			if !IsNetworkValid(network) {
+				validator.Push(fmt.Errorf("Network %d of network alias %s must be a valid IP or CIDR", i, name))
+			}
+		}
+	}
+
+	if acc.External != nil {
+		acc.External.Validate(validator)
+	}
+
+	names := make(map[string]int)
+
+	for i, rule := range acc.Rules {
+		ruleValidator := NewStructValidator()
+		rule.Validate(ruleValidator)
+
+		if rule.Name != "" {
+			if j, ok := names[rule.Name]; ok {
+				ruleValidator.Push(fmt.Errorf("Name %s is already used by rule %d", rule.Name, j))
+			} else {
+				names[rule.Name] = i
+			}
+		}
+
+		if rule.Policy == ExternalPolicy && acc.External == nil {
+			ruleValidator.Push(fmt.Errorf("An external policy configuration must be provided to use the 'external' policy"))
+		}
+
+		for j, network := range rule.Networks {
+			if _, ok := acc.Networks[strings.ToLower(network)]; !ok && !IsNetworkValid(network) {
+				ruleValidator.Push(fmt.Errorf("Network %d must be a valid IP, CIDR or network alias", j))
+			}
+		}
+
+		for _, err := range ruleValidator.Errors() {
+			validator.Push(fmt.Errorf("Rule %d: %s", i, err))
+		}
+	}
 }
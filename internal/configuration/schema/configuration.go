@@ -21,4 +21,6 @@ type Configuration struct {
 	Storage               StorageConfiguration               `mapstructure:"storage"`
 	Notifier              *NotifierConfiguration             `mapstructure:"notifier"`
 	Server                ServerConfiguration                `mapstructure:"server"`
+	GeoIP                 *GeoIPConfiguration                `mapstructure:"geoip"`
+	Domains               []DomainConfiguration              `mapstructure:"domains"`
 }
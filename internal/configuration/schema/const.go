@@ -6,6 +6,21 @@ import (
 
 const denyPolicy = "deny"
 
+// DenyBehaviorRedirect redirects the anonymous users denied the access by a rule to the login portal and
+// forbids the access to the identified users, this is the default behavior.
+const DenyBehaviorRedirect = "redirect"
+
+// DenyBehaviorForbidden forbids the access to any user denied the access by a rule, even anonymous.
+const DenyBehaviorForbidden = "forbidden"
+
+// DenyBehaviorAccessDenied redirects any user denied the access by a rule to the access denied page of the portal.
+const DenyBehaviorAccessDenied = "access_denied"
+
+// ExternalPolicy is the policy of the rules whose decision is delegated to an external service.
+const ExternalPolicy = "external"
+
+var weekdays = []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}
+
 const argon2id = "argon2id"
 
 // ProfileRefreshDisabled represents a value for refresh_interval that disables the check entirely.
@@ -25,3 +40,33 @@ const LDAPImplementationCustom = "custom"
 
 // LDAPImplementationActiveDirectory is the string for the Active Directory LDAP implementation.
 const LDAPImplementationActiveDirectory = "activedirectory"
+
+// RedisSentinelPortDefault is the port of the Redis Sentinel nodes when none is given.
+const RedisSentinelPortDefault = 26379
+
+// SessionMaxConcurrentStrategyReject is the strategy rejecting the logins of the users who reached the maximum number
+// of concurrent sessions.
+const SessionMaxConcurrentStrategyReject = "reject"
+
+// SessionMaxConcurrentStrategyEvictOldest is the strategy destroying the oldest sessions of the users who reached the
+// maximum number of concurrent sessions to make room for the new one.
+const SessionMaxConcurrentStrategyEvictOldest = "evict_oldest"
+
+// SessionBindingActionDegrade is the action treating the requests of a client which does not match the binding of the
+// session as unauthenticated, the session itself is kept.
+const SessionBindingActionDegrade = "degrade"
+
+// SessionBindingActionDestroy is the action destroying the session when a client which does not match its binding
+// uses it, the user must then authenticate again.
+const SessionBindingActionDestroy = "destroy"
+
+// SessionSameSiteLax is the SameSite mode of the session cookie sending it on the top-level navigations from other
+// sites.
+const SessionSameSiteLax = "lax"
+
+// SessionSameSiteStrict is the SameSite mode of the session cookie sending it only on the requests from the same site.
+const SessionSameSiteStrict = "strict"
+
+// SessionSameSiteNone is the SameSite mode of the session cookie sending it on all the requests, including the
+// cross-site ones.
+const SessionSameSiteNone = "none"
@@ -0,0 +1,14 @@
+package schema
+
+// DomainConfiguration represents the configuration of a protected root domain, its default policy, its login portal
+// and the settings of its session cookie.
+type DomainConfiguration struct {
+	Domain        string `mapstructure:"domain"`
+	DefaultPolicy string `mapstructure:"default_policy"`
+	PortalURL     string `mapstructure:"portal_url"`
+
+	Expiration         string `mapstructure:"expiration"`
+	Inactivity         string `mapstructure:"inactivity"`
+	RememberMeDuration string `mapstructure:"remember_me_duration"`
+	SameSite           string `mapstructure:"same_site"`
+}
@@ -0,0 +1,12 @@
+package schema
+
+// GeoIPConfiguration represents the configuration of the GeoIP database used to locate the clients.
+type GeoIPConfiguration struct {
+	Path           string `mapstructure:"path"`
+	ReloadInterval string `mapstructure:"reload_interval"`
+}
+
+// DefaultGeoIPConfiguration represents default configuration parameters for the GeoIP database.
+var DefaultGeoIPConfiguration = GeoIPConfiguration{
+	ReloadInterval: "1m",
+}
@@ -5,6 +5,12 @@ type ServerConfiguration struct {
 	Path            string `mapstructure:"path"`
 	ReadBufferSize  int    `mapstructure:"read_buffer_size"`
 	WriteBufferSize int    `mapstructure:"write_buffer_size"`
+
+	// TrustedProxies are the IPs or CIDRs of the proxies allowed to set the X-Forwarded-For header.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+
+	// ExplainNetworks are the IPs or CIDRs of the clients receiving the explanation of the verify decisions.
+	ExplainNetworks []string `mapstructure:"explain_networks"`
 }
 
 // DefaultServerConfiguration represents the default values of the ServerConfiguration.
@@ -1,28 +1,116 @@
 package schema
 
+// RedisNode represents a Redis node given by its host and port.
+type RedisNode struct {
+	Host string `mapstructure:"host"`
+	Port int64  `mapstructure:"port"`
+}
+
+// RedisHighAvailabilityConfiguration represents the configuration related to a Redis deployment monitored by Sentinel.
+type RedisHighAvailabilityConfiguration struct {
+	SentinelName     string      `mapstructure:"sentinel_name"`
+	SentinelPassword string      `mapstructure:"sentinel_password"`
+	Nodes            []RedisNode `mapstructure:"nodes"`
+	RouteByLatency   bool        `mapstructure:"route_by_latency"`
+	RouteRandomly    bool        `mapstructure:"route_randomly"`
+}
+
+// RedisTLSConfiguration represents the configuration of the TLS connections to redis.
+type RedisTLSConfiguration struct {
+	ServerName     string `mapstructure:"server_name"`
+	SkipVerify     bool   `mapstructure:"skip_verify"`
+	MinimumVersion string `mapstructure:"minimum_version"`
+	TrustedCert    string `mapstructure:"trusted_cert"`
+	Certificate    string `mapstructure:"certificate"`
+	Key            string `mapstructure:"key"`
+}
+
 // RedisSessionConfiguration represents the configuration related to redis session store.
 type RedisSessionConfiguration struct {
-	Host          string `mapstructure:"host"`
-	Port          int64  `mapstructure:"port"`
-	Password      string `mapstructure:"password"`
-	DatabaseIndex int    `mapstructure:"database_index"`
+	Host             string                              `mapstructure:"host"`
+	Port             int64                               `mapstructure:"port"`
+	Username         string                              `mapstructure:"username"`
+	Password         string                              `mapstructure:"password"`
+	DatabaseIndex    int                                 `mapstructure:"database_index"`
+	PoolSize         int                                 `mapstructure:"pool_size"`
+	IdleTimeout      string                              `mapstructure:"idle_timeout"`
+	DialTimeout      string                              `mapstructure:"dial_timeout"`
+	ReadTimeout      string                              `mapstructure:"read_timeout"`
+	WriteTimeout     string                              `mapstructure:"write_timeout"`
+	TLS              *RedisTLSConfiguration              `mapstructure:"tls"`
+	HighAvailability *RedisHighAvailabilityConfiguration `mapstructure:"high_availability"`
+}
+
+// SQLSessionConfiguration represents the configuration related to the session store backed by the SQL storage.
+type SQLSessionConfiguration struct {
+	GCInterval string `mapstructure:"gc_interval"`
+}
+
+// MemorySessionConfiguration represents the configuration of the session store kept in memory.
+type MemorySessionConfiguration struct {
+	SnapshotPath string `mapstructure:"snapshot_path"`
+}
+
+// SessionBindingConfiguration represents the configuration binding the sessions to the clients they were opened from.
+type SessionBindingConfiguration struct {
+	IPv4PrefixLength int    `mapstructure:"ipv4_prefix_length"`
+	IPv6PrefixLength int    `mapstructure:"ipv6_prefix_length"`
+	UserAgent        bool   `mapstructure:"user_agent"`
+	Action           string `mapstructure:"action"`
 }
 
 // SessionConfiguration represents the configuration related to user sessions.
 type SessionConfiguration struct {
-	Name               string                     `mapstructure:"name"`
-	Secret             string                     `mapstructure:"secret"`
-	Expiration         string                     `mapstructure:"expiration"`
-	Inactivity         string                     `mapstructure:"inactivity"`
-	RememberMeDuration string                     `mapstructure:"remember_me_duration"`
-	Domain             string                     `mapstructure:"domain"`
-	Redis              *RedisSessionConfiguration `mapstructure:"redis"`
+	Name                  string                       `mapstructure:"name"`
+	Secret                string                       `mapstructure:"secret"`
+	PreviousSecrets       []string                     `mapstructure:"previous_secrets"`
+	Expiration            string                       `mapstructure:"expiration"`
+	Inactivity            string                       `mapstructure:"inactivity"`
+	RememberMeDuration    string                       `mapstructure:"remember_me_duration"`
+	Domain                string                       `mapstructure:"domain"`
+	SameSite              string                       `mapstructure:"same_site"`
+	CookiePath            string                       `mapstructure:"cookie_path"`
+	MaxConcurrent         int                          `mapstructure:"max_concurrent"`
+	MaxConcurrentStrategy string                       `mapstructure:"max_concurrent_strategy"`
+	Binding               *SessionBindingConfiguration `mapstructure:"binding"`
+	Redis                 *RedisSessionConfiguration   `mapstructure:"redis"`
+	SQL                   *SQLSessionConfiguration     `mapstructure:"sql"`
+	Memory                *MemorySessionConfiguration  `mapstructure:"memory"`
 }
 
 // DefaultSessionConfiguration is the default session configuration.
 var DefaultSessionConfiguration = SessionConfiguration{
-	Name:               "authelia_session",
-	Expiration:         "1h",
-	Inactivity:         "5m",
-	RememberMeDuration: "1M",
+	Name:                  "authelia_session",
+	Expiration:            "1h",
+	Inactivity:            "5m",
+	RememberMeDuration:    "1M",
+	SameSite:              SessionSameSiteLax,
+	CookiePath:            "/",
+	MaxConcurrentStrategy: SessionMaxConcurrentStrategyEvictOldest,
+}
+
+// DefaultSessionBindingConfiguration is the default configuration of the binding of the sessions.
+var DefaultSessionBindingConfiguration = SessionBindingConfiguration{
+	Action: SessionBindingActionDegrade,
+}
+
+// DefaultRedisConfiguration is the default redis configuration.
+var DefaultRedisConfiguration = RedisSessionConfiguration{
+	PoolSize:    8,
+	IdleTimeout: "5m",
+}
+
+// DefaultSQLSessionConfiguration is the default configuration of the session store backed by the SQL storage.
+var DefaultSQLSessionConfiguration = SQLSessionConfiguration{
+	GCInterval: "5m",
+}
+
+// DefaultRedisTLSConfiguration is the default TLS configuration of the connections to redis.
+var DefaultRedisTLSConfiguration = RedisTLSConfiguration{
+	MinimumVersion: "TLS1.2",
+}
+
+// IsSameSiteValid check if the SameSite mode of the session cookie is valid.
+func IsSameSiteValid(sameSite string) bool {
+	return sameSite == SessionSameSiteLax || sameSite == SessionSameSiteStrict || sameSite == SessionSameSiteNone
 }
@@ -0,0 +1,28 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// ValidateAccessControlRequiredMethods validates the second factor methods required by the access control rules are
+// methods supported by Authelia and are only required by the rules with the two_factor policy. They are validated here
+// rather than with the other fields of the rules since the methods are defined by the authentication package.
+func ValidateAccessControlRequiredMethods(rules []schema.ACLRule, validator *schema.StructValidator) {
+	for i, rule := range rules {
+		if len(rule.RequiredMethods) > 0 && rule.Policy != "two_factor" {
+			validator.Push(fmt.Errorf("Rule %d: Required methods can only be used with the 'two_factor' policy", i))
+		}
+
+		for j, method := range rule.RequiredMethods {
+			if !utils.IsStringInSlice(method, authentication.PossibleMethods) {
+				validator.Push(fmt.Errorf("Rule %d: Required method %d must be one of %s", i, j,
+					strings.Join(authentication.PossibleMethods, ", ")))
+			}
+		}
+	}
+}
@@ -50,12 +50,19 @@ func ValidateConfiguration(configuration *schema.Configuration, validator *schem
 
 	ValidateAuthenticationBackend(&configuration.AuthenticationBackend, validator)
 
-	if configuration.AccessControl.DefaultPolicy == "" {
-		configuration.AccessControl.DefaultPolicy = "deny"
+	configuration.AccessControl.Validate(validator)
+
+	ValidateAccessControlRequiredMethods(configuration.AccessControl.Rules, validator)
+
+	if configuration.Session.Domain == "" && len(configuration.Domains) != 0 {
+		// The first protected domain is the default domain of the session.
+		configuration.Session.Domain = configuration.Domains[0].Domain
 	}
 
 	ValidateSession(&configuration.Session, validator)
 
+	ValidateDomains(configuration.Domains, configuration.Session, validator)
+
 	if configuration.Regulation == nil {
 		configuration.Regulation = &schema.DefaultRegulationConfiguration
 	}
@@ -66,6 +73,12 @@ func ValidateConfiguration(configuration *schema.Configuration, validator *schem
 
 	ValidateStorage(configuration.Storage, validator)
 
+	if configuration.GeoIP != nil {
+		ValidateGeoIP(configuration.GeoIP, validator)
+	} else if configuration.AccessControl.HasCountryConditions() {
+		validator.Push(fmt.Errorf("A geoip configuration must be provided to use countries in access control rules"))
+	}
+
 	if configuration.Notifier == nil {
 		validator.Push(fmt.Errorf("A notifier configuration must be provided"))
 	} else {
@@ -1,6 +1,7 @@
 package validator
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -103,6 +104,228 @@ func TestShouldAddDefaultAccessControl(t *testing.T) {
 	assert.Equal(t, "deny", config.AccessControl.DefaultPolicy)
 }
 
+func TestShouldValidateAccessControlNetworkAliases(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := newDefaultConfig()
+	config.AccessControl.Networks = map[string][]string{
+		"office": {"192.168.1.0/24", "10.0.0.1"},
+	}
+	config.AccessControl.Rules = []schema.ACLRule{{
+		Domains:  []string{"secure.example.com"},
+		Policy:   "one_factor",
+		Networks: []string{"office", "172.16.0.0/12"},
+	}}
+
+	ValidateConfiguration(&config, validator)
+	require.Len(t, validator.Errors(), 0)
+}
+
+func TestShouldRaiseErrorOnInvalidAccessControlNetworks(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := newDefaultConfig()
+	config.AccessControl.Networks = map[string][]string{
+		"office": {"192.168.1.0/33"},
+	}
+	config.AccessControl.Rules = []schema.ACLRule{{
+		Domains:  []string{"secure.example.com"},
+		Policy:   "one_factor",
+		Networks: []string{"office", "vpn"},
+	}}
+
+	ValidateConfiguration(&config, validator)
+	require.Len(t, validator.Errors(), 2)
+	assert.EqualError(t, validator.Errors()[0], "Network 0 of network alias office must be a valid IP or CIDR")
+	assert.EqualError(t, validator.Errors()[1], "Rule 0: Network 1 must be a valid IP, CIDR or network alias")
+}
+
+func TestShouldRaiseErrorWhenCountriesAreUsedWithoutGeoIP(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := newDefaultConfig()
+	config.AccessControl.Rules = []schema.ACLRule{{
+		Domains:   []string{"secure.example.com"},
+		Policy:    "one_factor",
+		Countries: []string{"FR", "France"},
+	}}
+
+	ValidateConfiguration(&config, validator)
+	require.Len(t, validator.Errors(), 2)
+	assert.EqualError(t, validator.Errors()[0], "Rule 0: Country 1 must be a two letters ISO 3166-1 code")
+	assert.EqualError(t, validator.Errors()[1], "A geoip configuration must be provided to use countries in access control rules")
+}
+
+func TestShouldRaiseErrorOnInvalidAccessControlRequestMatchers(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := newDefaultConfig()
+	config.AccessControl.Rules = []schema.ACLRule{{
+		Domains: []string{"api.example.com"},
+		Policy:  "one_factor",
+		Headers: []schema.ACLRuleMatcher{
+			{Name: "Accept", Value: "application/json"},
+			{Value: "application/json"},
+			{Name: "Accept", Value: "application/json", Pattern: "json"},
+		},
+		Query: []schema.ACLRuleMatcher{
+			{Name: "api_key"},
+			{Name: "format", Pattern: "(json"},
+		},
+	}}
+
+	ValidateConfiguration(&config, validator)
+	require.Len(t, validator.Errors(), 3)
+	assert.EqualError(t, validator.Errors()[0], "Rule 0: Header 1 must have a name")
+	assert.EqualError(t, validator.Errors()[1], "Rule 0: Header 2 cannot have both a value and a pattern")
+	assert.EqualError(t, validator.Errors()[2],
+		"Rule 0: Query parameter 1 has an invalid pattern: error parsing regexp: missing closing ): `(json`")
+}
+
+func TestShouldRaiseErrorOnDuplicateAccessControlRuleNames(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := newDefaultConfig()
+	config.AccessControl.Rules = []schema.ACLRule{
+		{Name: "admin", Domains: []string{"admin.example.com"}, Policy: "two_factor"},
+		{Name: "public", Domains: []string{"public.example.com"}, Policy: "bypass"},
+		{Name: "admin", Domains: []string{"*.example.com"}, Policy: "one_factor"},
+	}
+
+	ValidateConfiguration(&config, validator)
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "Rule 2: Name admin is already used by rule 0")
+}
+
+func TestShouldValidateEmailAndAttributeSubjects(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := newDefaultConfig()
+	config.AccessControl.Rules = []schema.ACLRule{
+		{
+			Domains:  []string{"app.example.com"},
+			Subjects: [][]string{{"email:*@partner.com", "!attr:title=intern"}, {"attr:department=finance"}},
+			Policy:   "one_factor",
+		},
+		{
+			Domains:  []string{"app.example.com"},
+			Subjects: [][]string{{"email:[a-", "attr:department", "attr:=finance", "email:"}},
+			Policy:   "one_factor",
+		},
+	}
+
+	ValidateConfiguration(&config, validator)
+	require.Len(t, validator.Errors(), 4)
+
+	for i, err := range validator.Errors() {
+		assert.EqualError(t, err, fmt.Sprintf("Rule 1: Subject 0-%d must start with 'user:', 'group:', 'email:' or 'attr:', optionally negated with '!'", i))
+	}
+}
+
+func TestShouldRaiseErrorOnInvalidRequiredMethods(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := newDefaultConfig()
+	config.AccessControl.Rules = []schema.ACLRule{
+		{Domains: []string{"admin.example.com"}, Policy: "two_factor", RequiredMethods: []string{"u2f", "mobile_push"}},
+		{Domains: []string{"secure.example.com"}, Policy: "one_factor", RequiredMethods: []string{"totp"}},
+		{Domains: []string{"public.example.com"}, Policy: "two_factor", RequiredMethods: []string{"sms"}},
+	}
+
+	ValidateConfiguration(&config, validator)
+	require.Len(t, validator.Errors(), 2)
+	assert.EqualError(t, validator.Errors()[0], "Rule 1: Required methods can only be used with the 'two_factor' policy")
+	assert.EqualError(t, validator.Errors()[1], "Rule 2: Required method 0 must be one of totp, u2f, mobile_push")
+}
+
+func TestShouldRaiseErrorOnInvalidMaxAuthenticationAge(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := newDefaultConfig()
+	config.AccessControl.Rules = []schema.ACLRule{
+		{Domains: []string{"payroll.example.com"}, Policy: "two_factor", MaxAuthenticationAge: "10m"},
+		{Domains: []string{"public.example.com"}, Policy: "bypass", MaxAuthenticationAge: "1h"},
+		{Domains: []string{"admin.example.com"}, Policy: "one_factor", MaxAuthenticationAge: "ten minutes"},
+	}
+
+	ValidateConfiguration(&config, validator)
+	require.Len(t, validator.Errors(), 2)
+	assert.EqualError(t, validator.Errors()[0], "Rule 1: Max authentication age can only be used with the 'one_factor' or 'two_factor' policy")
+	assert.EqualError(t, validator.Errors()[1], "Rule 2: Error occurred parsing max authentication age string: Could not convert the input string of ten minutes into a duration")
+}
+
+func TestShouldRaiseErrorOnInvalidDenyBehavior(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := newDefaultConfig()
+	config.AccessControl.Rules = []schema.ACLRule{
+		{Domains: []string{"admin.example.com"}, Policy: "deny", DenyBehavior: "access_denied"},
+		{Domains: []string{"app.example.com"}, Policy: "one_factor", DenyBehavior: "forbidden"},
+		{Domains: []string{"app.example.com"}, Policy: "deny", DenyBehavior: "teapot"},
+	}
+
+	ValidateConfiguration(&config, validator)
+	require.Len(t, validator.Errors(), 2)
+	assert.EqualError(t, validator.Errors()[0], "Rule 1: Deny behavior can only be used with the 'deny' policy")
+	assert.EqualError(t, validator.Errors()[1], "Rule 2: Deny behavior must either be 'redirect', 'forbidden' or 'access_denied'")
+}
+
+func TestShouldRaiseErrorOnInvalidDefaultDenyBehavior(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := newDefaultConfig()
+	config.AccessControl.DefaultDenyBehavior = "teapot"
+
+	ValidateConfiguration(&config, validator)
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "'default_deny_behavior' must either be 'redirect', 'forbidden' or 'access_denied'")
+}
+
+func TestShouldRaiseErrorOnInvalidExternalPolicy(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := newDefaultConfig()
+	config.AccessControl.Rules = []schema.ACLRule{
+		{Domains: []string{"app.example.com"}, Policy: "external"},
+	}
+
+	ValidateConfiguration(&config, validator)
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "Rule 0: An external policy configuration must be provided to use the 'external' policy")
+
+	validator = schema.NewStructValidator()
+	config.AccessControl.External = &schema.ExternalPolicyConfiguration{
+		URL:           "ftp://policy.example.com",
+		Timeout:       "5 minutes",
+		CacheTTL:      "1 hour",
+		CacheSize:     -1,
+		FailurePolicy: "external",
+		Headers:       []string{"X-Tenant", "cookie", "Authorization"},
+	}
+
+	ValidateConfiguration(&config, validator)
+	require.Len(t, validator.Errors(), 7)
+	assert.EqualError(t, validator.Errors()[0], "The url of the external policy must be a valid http or https url")
+	assert.EqualError(t, validator.Errors()[1], "Error occurred parsing external policy timeout string: Could not convert the input string of 5 minutes into a duration")
+	assert.EqualError(t, validator.Errors()[2], "Error occurred parsing external policy cache_ttl string: Could not convert the input string of 1 hour into a duration")
+	assert.EqualError(t, validator.Errors()[3], "The cache size of the external policy must be greater than 0")
+	assert.EqualError(t, validator.Errors()[4], "The failure policy of the external policy must either be 'deny', 'two_factor', 'one_factor' or 'bypass'")
+	assert.EqualError(t, validator.Errors()[5], "The header cookie carries credentials and cannot be forwarded to the external policy")
+	assert.EqualError(t, validator.Errors()[6], "The header Authorization carries credentials and cannot be forwarded to the external policy")
+}
+
+func TestShouldSetDefaultExternalPolicyValues(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := newDefaultConfig()
+	config.AccessControl.External = &schema.ExternalPolicyConfiguration{URL: "https://policy.example.com/decide"}
+	config.AccessControl.Rules = []schema.ACLRule{
+		{Domains: []string{"app.example.com"}, Policy: "external"},
+	}
+
+	ValidateConfiguration(&config, validator)
+	require.Len(t, validator.Errors(), 0)
+	assert.Equal(t, schema.DefaultExternalPolicyConfiguration.Timeout, config.AccessControl.External.Timeout)
+	assert.Equal(t, schema.DefaultExternalPolicyConfiguration.CacheTTL, config.AccessControl.External.CacheTTL)
+	assert.Equal(t, 10000, config.AccessControl.External.CacheSize)
+	assert.Len(t, config.AccessControl.External.Headers, 0)
+	assert.Equal(t, "deny", config.AccessControl.External.FailurePolicy)
+
+	validator = schema.NewStructValidator()
+	config.AccessControl.DefaultPolicy = "external"
+
+	ValidateConfiguration(&config, validator)
+	require.Len(t, validator.Errors(), 1)
+}
+
 func TestShouldRaiseErrorWhenTLSCertWithoutKeyIsProvided(t *testing.T) {
 	validator := schema.NewStructValidator()
 	config := newDefaultConfig()
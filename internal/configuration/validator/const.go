@@ -16,6 +16,8 @@ var validKeys = []string{
 	"server.read_buffer_size",
 	"server.write_buffer_size",
 	"server.path",
+	"server.trusted_proxies",
+	"server.explain_networks",
 
 	// TOTP Keys.
 	"totp.issuer",
@@ -25,13 +27,34 @@ var validKeys = []string{
 	// Access Control Keys.
 	"access_control.rules",
 	"access_control.default_policy",
+	"access_control.default_deny_behavior",
+	"access_control.networks",
+	"access_control.external.url",
+	"access_control.external.timeout",
+	"access_control.external.cache_ttl",
+	"access_control.external.cache_size",
+	"access_control.external.failure_policy",
+	"access_control.external.headers",
+
+	// GeoIP Keys.
+	"geoip.path",
+	"geoip.reload_interval",
 
 	// Session Keys.
 	"session.name",
 	"session.secret",
+	"session.previous_secrets",
 	"session.expiration",
 	"session.inactivity",
 	"session.remember_me_duration",
+	"session.same_site",
+	"session.cookie_path",
+	"session.max_concurrent",
+	"session.max_concurrent_strategy",
+	"session.binding.ipv4_prefix_length",
+	"session.binding.ipv6_prefix_length",
+	"session.binding.user_agent",
+	"session.binding.action",
 	"session.domain",
 
 	// Redis Session Keys.
@@ -39,6 +62,28 @@ var validKeys = []string{
 	"session.redis.port",
 	"session.redis.password",
 	"session.redis.database_index",
+	"session.redis.username",
+	"session.redis.pool_size",
+	"session.redis.idle_timeout",
+	"session.redis.dial_timeout",
+	"session.redis.read_timeout",
+	"session.redis.write_timeout",
+	"session.redis.tls.server_name",
+	"session.redis.tls.skip_verify",
+	"session.redis.tls.minimum_version",
+	"session.redis.tls.trusted_cert",
+	"session.redis.tls.certificate",
+	"session.redis.tls.key",
+	"session.redis.high_availability.sentinel_name",
+	"session.redis.high_availability.sentinel_password",
+	"session.redis.high_availability.nodes",
+	"session.redis.high_availability.route_by_latency",
+	"session.redis.high_availability.route_randomly",
+	"session.sql.gc_interval",
+	"session.memory.snapshot_path",
+
+	// Domains Keys.
+	"domains",
 
 	// Local Storage Keys.
 	"storage.local.path",
@@ -105,6 +150,7 @@ var validKeys = []string{
 	"authentication_backend.ldap.group_name_attribute",
 	"authentication_backend.ldap.mail_attribute",
 	"authentication_backend.ldap.display_name_attribute",
+	"authentication_backend.ldap.extra_attributes",
 	"authentication_backend.ldap.user",
 	"authentication_backend.ldap.password",
 
@@ -124,6 +170,7 @@ var validKeys = []string{
 	"authelia.authentication_backend.ldap.password",
 	"authelia.notifier.smtp.password",
 	"authelia.session.redis.password",
+	"authelia.session.redis.high_availability.sentinel_password",
 	"authelia.storage.mysql.password",
 	"authelia.storage.postgres.password",
 	"authelia.jwt_secret.file",
@@ -132,10 +179,16 @@ var validKeys = []string{
 	"authelia.authentication_backend.ldap.password.file",
 	"authelia.notifier.smtp.password.file",
 	"authelia.session.redis.password.file",
+	"authelia.session.redis.high_availability.sentinel_password.file",
 	"authelia.storage.mysql.password.file",
 	"authelia.storage.postgres.password.file",
 }
 
+// validKeyPrefixes are the prefixes of the keys of maps whose entries are named by the user.
+var validKeyPrefixes = []string{
+	"access_control.networks.",
+}
+
 var specificErrorKeys = map[string]string{
 	"logs_file_path":   "config key replaced: logs_file is now log_file",
 	"logs_level":       "config key replaced: logs_level is now log_level",
@@ -0,0 +1,69 @@
+package validator
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// ValidateDomains validates and update the configuration of the protected domains, the settings of the session
+// cookie default to the ones of the session.
+func ValidateDomains(configuration []schema.DomainConfiguration, session schema.SessionConfiguration,
+	validator *schema.StructValidator) {
+	if len(configuration) == 0 {
+		return
+	}
+
+	var domains []string
+
+	for i := range configuration {
+		domain := &configuration[i]
+
+		switch {
+		case domain.Domain == "":
+			validator.Push(fmt.Errorf("Domain %d: A domain must be provided", i))
+		case strings.Contains(domain.Domain, "*"):
+			validator.Push(fmt.Errorf("Domain %d: The domain must be a root domain instead of a wildcard domain", i))
+		case utils.IsStringInSlice(domain.Domain, domains):
+			validator.Push(fmt.Errorf("Domain %d: The domain %s is already protected", i, domain.Domain))
+		}
+
+		domains = append(domains, domain.Domain)
+
+		if domain.DefaultPolicy != "" && !schema.IsPolicyValid(domain.DefaultPolicy) {
+			validator.Push(fmt.Errorf("Domain %d: The default policy must either be 'deny', 'two_factor', 'one_factor' or 'bypass'", i))
+		}
+
+		if domain.PortalURL != "" {
+			portalURL, err := url.ParseRequestURI(domain.PortalURL)
+			if err != nil || !utils.IsRedirectionSafe(*portalURL, domain.Domain) {
+				validator.Push(fmt.Errorf("Domain %d: The portal url must be a https url under the domain %s", i, domain.Domain))
+			}
+		}
+
+		if domain.SameSite == "" {
+			domain.SameSite = session.SameSite
+		} else if !schema.IsSameSiteValid(domain.SameSite) {
+			validator.Push(fmt.Errorf("Domain %d: The same_site must be one of 'lax', 'strict' or 'none'", i))
+		}
+
+		validateDomainDuration(&domain.Expiration, session.Expiration, "expiration", i, validator)
+		validateDomainDuration(&domain.Inactivity, session.Inactivity, "inactivity", i, validator)
+		validateDomainDuration(&domain.RememberMeDuration, session.RememberMeDuration, "remember_me_duration", i, validator)
+	}
+
+	if !utils.IsStringInSlice(session.Domain, domains) {
+		validator.Push(fmt.Errorf("The domain of the session must be one of the protected domains"))
+	}
+}
+
+func validateDomainDuration(duration *string, defaultDuration, name string, index int, validator *schema.StructValidator) {
+	if *duration == "" {
+		*duration = defaultDuration
+	} else if _, err := utils.ParseDurationString(*duration); err != nil {
+		validator.Push(fmt.Errorf("Domain %d: Error occurred parsing %s string: %s", index, name, err))
+	}
+}
@@ -0,0 +1,98 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+func newDefaultDomainsSession() schema.SessionConfiguration {
+	return schema.SessionConfiguration{
+		Domain:             "example.com",
+		Expiration:         "1h",
+		Inactivity:         "5m",
+		RememberMeDuration: "1M",
+	}
+}
+
+func TestShouldSetDefaultDomainCookieSettings(t *testing.T) {
+	validator := schema.NewStructValidator()
+	domains := []schema.DomainConfiguration{
+		{Domain: "example.com", DefaultPolicy: "two_factor", PortalURL: "https://login.example.com"},
+		{Domain: "example.io", Inactivity: "10m"},
+	}
+
+	ValidateDomains(domains, newDefaultDomainsSession(), validator)
+
+	require.Len(t, validator.Errors(), 0)
+	assert.Equal(t, "1h", domains[0].Expiration)
+	assert.Equal(t, "5m", domains[0].Inactivity)
+	assert.Equal(t, "1M", domains[0].RememberMeDuration)
+	assert.Equal(t, "10m", domains[1].Inactivity)
+}
+
+func TestShouldRaiseErrorsOnInvalidDomains(t *testing.T) {
+	validator := schema.NewStructValidator()
+	domains := []schema.DomainConfiguration{
+		{Domain: "example.com", DefaultPolicy: "external", PortalURL: "https://login.example.io"},
+		{Domain: "*.example.io", Expiration: testBadTimer},
+		{Domain: "example.com", PortalURL: "http://login.example.com"},
+		{},
+	}
+
+	ValidateDomains(domains, newDefaultDomainsSession(), validator)
+
+	require.Len(t, validator.Errors(), 7)
+	assert.EqualError(t, validator.Errors()[0], "Domain 0: The default policy must either be 'deny', 'two_factor', 'one_factor' or 'bypass'")
+	assert.EqualError(t, validator.Errors()[1], "Domain 0: The portal url must be a https url under the domain example.com")
+	assert.EqualError(t, validator.Errors()[2], "Domain 1: The domain must be a root domain instead of a wildcard domain")
+	assert.EqualError(t, validator.Errors()[3], "Domain 1: Error occurred parsing expiration string: Could not convert the input string of -1 into a duration")
+	assert.EqualError(t, validator.Errors()[4], "Domain 2: The domain example.com is already protected")
+	assert.EqualError(t, validator.Errors()[5], "Domain 2: The portal url must be a https url under the domain example.com")
+	assert.EqualError(t, validator.Errors()[6], "Domain 3: A domain must be provided")
+}
+
+func TestShouldRaiseErrorWhenSessionDomainIsNotProtected(t *testing.T) {
+	validator := schema.NewStructValidator()
+	domains := []schema.DomainConfiguration{{Domain: "example.io"}}
+
+	ValidateDomains(domains, newDefaultDomainsSession(), validator)
+
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "The domain of the session must be one of the protected domains")
+}
+
+func TestShouldDefaultSessionDomainToFirstProtectedDomain(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := newDefaultConfig()
+	config.Session.Domain = ""
+	config.Domains = []schema.DomainConfiguration{{Domain: "example.io"}, {Domain: "example.com"}}
+
+	ValidateConfiguration(&config, validator)
+
+	require.Len(t, validator.Errors(), 0)
+	assert.Equal(t, "example.io", config.Session.Domain)
+	assert.Equal(t, schema.DefaultSessionConfiguration.Expiration, config.Domains[1].Expiration)
+}
+
+func TestShouldValidateDomainSameSite(t *testing.T) {
+	validator := schema.NewStructValidator()
+	session := newDefaultDomainsSession()
+	session.SameSite = schema.SessionSameSiteLax
+
+	domains := []schema.DomainConfiguration{
+		{Domain: "example.com"},
+		{Domain: "admin.example.io", SameSite: schema.SessionSameSiteStrict},
+		{Domain: "example.org", SameSite: "relaxed"},
+	}
+
+	ValidateDomains(domains, session, validator)
+
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "Domain 2: The same_site must be one of 'lax', 'strict' or 'none'")
+	assert.Equal(t, schema.SessionSameSiteLax, domains[0].SameSite)
+	assert.Equal(t, schema.SessionSameSiteStrict, domains[1].SameSite)
+}
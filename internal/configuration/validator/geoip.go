@@ -0,0 +1,21 @@
+package validator
+
+import (
+	"fmt"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// ValidateGeoIP validates and update GeoIP configuration.
+func ValidateGeoIP(configuration *schema.GeoIPConfiguration, validator *schema.StructValidator) {
+	if configuration.Path == "" {
+		validator.Push(fmt.Errorf("A path to the GeoIP database must be provided"))
+	}
+
+	if configuration.ReloadInterval == "" {
+		configuration.ReloadInterval = schema.DefaultGeoIPConfiguration.ReloadInterval
+	} else if _, err := utils.ParseDurationString(configuration.ReloadInterval); err != nil {
+		validator.Push(fmt.Errorf("Error occurred parsing GeoIP reload_interval string: %s", err))
+	}
+}
@@ -0,0 +1,43 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+func TestShouldSetDefaultGeoIPReloadInterval(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.GeoIPConfiguration{Path: "/var/lib/GeoIP/GeoLite2-Country.mmdb"}
+
+	ValidateGeoIP(&config, validator)
+
+	assert.Len(t, validator.Errors(), 0)
+	assert.Equal(t, schema.DefaultGeoIPConfiguration.ReloadInterval, config.ReloadInterval)
+}
+
+func TestShouldRaiseErrorWhenGeoIPPathIsNotProvided(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.GeoIPConfiguration{}
+
+	ValidateGeoIP(&config, validator)
+
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "A path to the GeoIP database must be provided")
+}
+
+func TestShouldRaiseErrorWhenGeoIPReloadIntervalIsInvalid(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.GeoIPConfiguration{
+		Path:           "/var/lib/GeoIP/GeoLite2-Country.mmdb",
+		ReloadInterval: testBadTimer,
+	}
+
+	ValidateGeoIP(&config, validator)
+
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "Error occurred parsing GeoIP reload_interval string: Could not convert the input string of -1 into a duration")
+}
@@ -3,6 +3,7 @@ package validator
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/authelia/authelia/internal/configuration/schema"
 	"github.com/authelia/authelia/internal/utils"
@@ -13,7 +14,7 @@ func ValidateKeys(validator *schema.StructValidator, keys []string) {
 	var errStrings []string
 
 	for _, key := range keys {
-		if utils.IsStringInSlice(key, validKeys) {
+		if utils.IsStringInSlice(key, validKeys) || isKeyWithValidPrefix(key) {
 			continue
 		}
 
@@ -30,3 +31,13 @@ func ValidateKeys(validator *schema.StructValidator, keys []string) {
 		validator.Push(errors.New(err))
 	}
 }
+
+func isKeyWithValidPrefix(key string) bool {
+	for _, prefix := range validKeyPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -18,6 +18,14 @@ func TestShouldValidateGoodKeys(t *testing.T) {
 	require.Len(t, val.Errors(), 0)
 }
 
+func TestShouldValidateKeysWithValidPrefix(t *testing.T) {
+	configKeys := []string{"access_control.networks.office", "access_control.networks.vpn"}
+	val := schema.NewStructValidator()
+	ValidateKeys(val, configKeys)
+
+	require.Len(t, val.Errors(), 0)
+}
+
 func TestShouldNotValidateBadKeys(t *testing.T) {
 	configKeys := validKeys
 	configKeys = append(configKeys, "bad_key")
@@ -21,6 +21,11 @@ func ValidateSecrets(configuration *schema.Configuration, validator *schema.Stru
 
 	if configuration.Session.Redis != nil {
 		configuration.Session.Redis.Password = getSecretValue("session.redis.password", validator, viper)
+
+		if configuration.Session.Redis.HighAvailability != nil {
+			configuration.Session.Redis.HighAvailability.SentinelPassword =
+				getSecretValue("session.redis.high_availability.sentinel_password", validator, viper)
+		}
 	}
 
 	if configuration.AuthenticationBackend.Ldap != nil {
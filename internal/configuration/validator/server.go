@@ -35,4 +35,16 @@ func ValidateServer(configuration *schema.ServerConfiguration, validator *schema
 	} else if configuration.WriteBufferSize < 0 {
 		validator.Push(fmt.Errorf("server write buffer size must be above 0"))
 	}
+
+	for i, proxy := range configuration.TrustedProxies {
+		if !schema.IsNetworkValid(proxy) {
+			validator.Push(fmt.Errorf("server trusted proxy %d must be a valid IP or CIDR", i))
+		}
+	}
+
+	for i, network := range configuration.ExplainNetworks {
+		if !schema.IsNetworkValid(network) {
+			validator.Push(fmt.Errorf("server explain network %d must be a valid IP or CIDR", i))
+		}
+	}
 }
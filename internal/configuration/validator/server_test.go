@@ -49,3 +49,24 @@ func TestShouldRaiseOnForwardSlashInPath(t *testing.T) {
 	assert.Len(t, validator.Errors(), 1)
 	assert.Error(t, validator.Errors()[0], "server path must not contain any forward slashes")
 }
+
+func TestShouldRaiseOnInvalidTrustedProxies(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.ServerConfiguration{
+		TrustedProxies: []string{"10.0.0.0/8", "192.168.1.1", "proxy.example.com", "172.16.0.0/33"},
+	}
+	ValidateServer(&config, validator)
+	require.Len(t, validator.Errors(), 2)
+	assert.EqualError(t, validator.Errors()[0], "server trusted proxy 2 must be a valid IP or CIDR")
+	assert.EqualError(t, validator.Errors()[1], "server trusted proxy 3 must be a valid IP or CIDR")
+}
+
+func TestShouldRaiseOnInvalidExplainNetworks(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.ServerConfiguration{
+		ExplainNetworks: []string{"10.0.0.0/8", "ops"},
+	}
+	ValidateServer(&config, validator)
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "server explain network 1 must be a valid IP or CIDR")
+}
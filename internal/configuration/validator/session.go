@@ -3,6 +3,8 @@ package validator
 import (
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/authelia/authelia/internal/configuration/schema"
@@ -20,9 +22,74 @@ func ValidateSession(configuration *schema.SessionConfiguration, validator *sche
 			validator.Push(errors.New("Set secret of the session object"))
 		}
 
-		if !strings.HasPrefix(configuration.Redis.Host, "/") && configuration.Redis.Port == 0 {
+		if configuration.Redis.HighAvailability != nil {
+			validateRedisHighAvailability(configuration.Redis, validator)
+		} else if !strings.HasPrefix(configuration.Redis.Host, "/") && configuration.Redis.Port == 0 {
 			validator.Push(errors.New("A redis port different than 0 must be provided"))
 		}
+
+		validateRedisConnection(configuration.Redis, validator)
+	}
+
+	if configuration.SQL != nil {
+		// The secret is already checked for redis.
+		if configuration.Redis != nil {
+			validator.Push(errors.New("Session redis and sql providers can't be configured at the same time"))
+		} else if configuration.Secret == "" {
+			validator.Push(errors.New("Set secret of the session object"))
+		}
+
+		if configuration.SQL.GCInterval == "" {
+			configuration.SQL.GCInterval = schema.DefaultSQLSessionConfiguration.GCInterval
+		} else if interval, err := utils.ParseDurationString(configuration.SQL.GCInterval); err != nil {
+			validator.Push(fmt.Errorf("Error occurred parsing session sql gc_interval string: %s", err))
+		} else if interval <= 0 {
+			validator.Push(errors.New("Session sql gc_interval must be greater than 0"))
+		}
+	}
+
+	if configuration.Memory != nil {
+		validateMemorySession(configuration, validator)
+	}
+
+	for i, secret := range configuration.PreviousSecrets {
+		if secret == "" {
+			validator.Push(fmt.Errorf("Session previous_secrets entry %d must not be empty", i+1))
+		}
+	}
+
+	if len(configuration.PreviousSecrets) != 0 && configuration.Secret == "" {
+		validator.Push(errors.New("Session previous_secrets can't be used without the secret"))
+	}
+
+	if configuration.SameSite == "" {
+		configuration.SameSite = schema.DefaultSessionConfiguration.SameSite
+	} else if !schema.IsSameSiteValid(configuration.SameSite) {
+		validator.Push(fmt.Errorf("Session same_site must be one of 'lax', 'strict' or 'none' but it is configured as '%s'", configuration.SameSite))
+	}
+
+	if configuration.CookiePath == "" {
+		configuration.CookiePath = schema.DefaultSessionConfiguration.CookiePath
+	} else if !strings.HasPrefix(configuration.CookiePath, "/") || strings.ContainsAny(configuration.CookiePath, "; \t\r\n") {
+		validator.Push(fmt.Errorf("Session cookie_path must be an absolute path without spaces or semicolons but it is configured as '%s'", configuration.CookiePath))
+	}
+
+	if configuration.MaxConcurrent < 0 {
+		validator.Push(errors.New("Session max_concurrent must be 0 or greater"))
+	}
+
+	switch configuration.MaxConcurrentStrategy {
+	case "":
+		configuration.MaxConcurrentStrategy = schema.DefaultSessionConfiguration.MaxConcurrentStrategy
+	case schema.SessionMaxConcurrentStrategyReject, schema.SessionMaxConcurrentStrategyEvictOldest:
+	default:
+		validator.Push(fmt.Errorf("Session max_concurrent_strategy must be one of '%s' or '%s' but it is configured as '%s'",
+			schema.SessionMaxConcurrentStrategyReject, schema.SessionMaxConcurrentStrategyEvictOldest,
+			configuration.MaxConcurrentStrategy))
+	}
+
+	if configuration.Binding != nil {
+		validateSessionBinding(configuration.Binding, validator)
 	}
 
 	if configuration.Expiration == "" {
@@ -51,3 +118,132 @@ func ValidateSession(configuration *schema.SessionConfiguration, validator *sche
 		validator.Push(errors.New("The domain of the session must be the root domain you're protecting instead of a wildcard domain"))
 	}
 }
+
+// validateRedisConnection validates and updates the settings of the connections to redis.
+func validateRedisConnection(configuration *schema.RedisSessionConfiguration, validator *schema.StructValidator) {
+	if configuration.PoolSize == 0 {
+		configuration.PoolSize = schema.DefaultRedisConfiguration.PoolSize
+	} else if configuration.PoolSize < 0 {
+		validator.Push(fmt.Errorf("Session redis pool_size must be a positive number but it is %d", configuration.PoolSize))
+	}
+
+	if configuration.IdleTimeout == "" {
+		configuration.IdleTimeout = schema.DefaultRedisConfiguration.IdleTimeout
+	}
+
+	timeouts := []struct {
+		name  string
+		value string
+	}{
+		{"idle_timeout", configuration.IdleTimeout},
+		{"dial_timeout", configuration.DialTimeout},
+		{"read_timeout", configuration.ReadTimeout},
+		{"write_timeout", configuration.WriteTimeout},
+	}
+
+	for _, timeout := range timeouts {
+		if _, err := utils.ParseDurationString(timeout.value); err != nil {
+			validator.Push(fmt.Errorf("Error occurred parsing session redis %s string: %s", timeout.name, err))
+		}
+	}
+
+	if configuration.TLS == nil {
+		return
+	}
+
+	// The certificate of the server is verified against the host unless another name is provided. The same name is used
+	// for every node of a Sentinel deployment since the Redis client only knows the addresses of the other nodes.
+	if configuration.TLS.ServerName == "" && !strings.HasPrefix(configuration.Host, "/") {
+		configuration.TLS.ServerName = configuration.Host
+	}
+
+	if configuration.TLS.MinimumVersion == "" {
+		configuration.TLS.MinimumVersion = schema.DefaultRedisTLSConfiguration.MinimumVersion
+	} else if _, err := utils.TLSStringToTLSConfigVersion(configuration.TLS.MinimumVersion); err != nil {
+		validator.Push(fmt.Errorf("error occurred validating the session redis tls minimum_version key with value %s: %v", configuration.TLS.MinimumVersion, err))
+	}
+
+	if (configuration.TLS.Certificate == "") != (configuration.TLS.Key == "") {
+		validator.Push(errors.New("Session redis tls requires both the certificate and the key of the client"))
+	}
+
+	for _, path := range []string{configuration.TLS.TrustedCert, configuration.TLS.Certificate, configuration.TLS.Key} {
+		if path == "" {
+			continue
+		}
+
+		if exists, _ := utils.FileExists(path); !exists {
+			validator.Push(fmt.Errorf("Session redis tls file %s does not exist", path))
+		}
+	}
+}
+
+// validateRedisHighAvailability validates the Sentinel configuration, the host and port of the redis section are the
+// first Sentinel node.
+func validateRedisHighAvailability(configuration *schema.RedisSessionConfiguration, validator *schema.StructValidator) {
+	if configuration.HighAvailability.SentinelName == "" {
+		validator.Push(errors.New("Session redis high_availability requires the sentinel_name of the master"))
+	}
+
+	if configuration.Host == "" && len(configuration.HighAvailability.Nodes) == 0 {
+		validator.Push(errors.New("Session redis high_availability requires a host or at least one node"))
+	}
+
+	if configuration.Host != "" && configuration.Port == 0 {
+		configuration.Port = schema.RedisSentinelPortDefault
+	}
+
+	for i, node := range configuration.HighAvailability.Nodes {
+		if node.Host == "" {
+			validator.Push(fmt.Errorf("Session redis high_availability node %d requires a host", i+1))
+		}
+
+		if node.Port == 0 {
+			configuration.HighAvailability.Nodes[i].Port = schema.RedisSentinelPortDefault
+		}
+	}
+}
+
+func validateSessionBinding(configuration *schema.SessionBindingConfiguration, validator *schema.StructValidator) {
+	if configuration.IPv4PrefixLength < 0 || configuration.IPv4PrefixLength > 32 {
+		validator.Push(fmt.Errorf("Session binding ipv4_prefix_length must be between 0 and 32 but it is configured as %d", configuration.IPv4PrefixLength))
+	}
+
+	if configuration.IPv6PrefixLength < 0 || configuration.IPv6PrefixLength > 128 {
+		validator.Push(fmt.Errorf("Session binding ipv6_prefix_length must be between 0 and 128 but it is configured as %d", configuration.IPv6PrefixLength))
+	}
+
+	if configuration.IPv4PrefixLength == 0 && configuration.IPv6PrefixLength == 0 && !configuration.UserAgent {
+		validator.Push(errors.New("Session binding requires an ipv4_prefix_length, an ipv6_prefix_length or user_agent"))
+	}
+
+	switch configuration.Action {
+	case "":
+		configuration.Action = schema.DefaultSessionBindingConfiguration.Action
+	case schema.SessionBindingActionDegrade, schema.SessionBindingActionDestroy:
+	default:
+		validator.Push(fmt.Errorf("Session binding action must be one of '%s' or '%s' but it is configured as '%s'",
+			schema.SessionBindingActionDegrade, schema.SessionBindingActionDestroy, configuration.Action))
+	}
+}
+
+func validateMemorySession(configuration *schema.SessionConfiguration, validator *schema.StructValidator) {
+	if configuration.Redis != nil || configuration.SQL != nil {
+		validator.Push(errors.New("Session memory can't be configured with the redis or sql providers"))
+	}
+
+	if configuration.Memory.SnapshotPath == "" {
+		validator.Push(errors.New("Session memory requires a snapshot_path"))
+		return
+	}
+
+	// The secret is already checked for redis and sql.
+	if configuration.Secret == "" && configuration.Redis == nil && configuration.SQL == nil {
+		validator.Push(errors.New("Set secret of the session object"))
+	}
+
+	info, err := os.Stat(filepath.Dir(configuration.Memory.SnapshotPath))
+	if err != nil || !info.IsDir() {
+		validator.Push(fmt.Errorf("Session memory snapshot_path must be in an existing directory but it is configured as '%s'", configuration.Memory.SnapshotPath))
+	}
+}
@@ -1,9 +1,12 @@
 package validator
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/authelia/authelia/internal/configuration/schema"
 )
@@ -110,6 +113,154 @@ func TestShouldRaiseErrorWhenRedisHasHostnameButNoPort(t *testing.T) {
 	assert.EqualError(t, validator.Errors()[0], "A redis port different than 0 must be provided")
 }
 
+func TestShouldSetDefaultSQLSessionGCInterval(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := newDefaultSessionConfig()
+	config.SQL = &schema.SQLSessionConfiguration{}
+
+	ValidateSession(&config, validator)
+
+	assert.Len(t, validator.Errors(), 0)
+	assert.Equal(t, "5m", config.SQL.GCInterval)
+}
+
+func TestShouldRaiseErrorWhenSQLSessionHasNoSecret(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := newDefaultSessionConfig()
+	config.Secret = ""
+	config.SQL = &schema.SQLSessionConfiguration{}
+
+	ValidateSession(&config, validator)
+
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "Set secret of the session object")
+}
+
+func TestShouldRaiseErrorsWhenSQLSessionIsMisconfigured(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := newDefaultSessionConfig()
+	config.Secret = ""
+	config.Redis = &schema.RedisSessionConfiguration{
+		Host: "redis.localhost",
+		Port: 6379,
+	}
+	config.SQL = &schema.SQLSessionConfiguration{GCInterval: "0"}
+
+	ValidateSession(&config, validator)
+
+	require.Len(t, validator.Errors(), 3)
+	assert.EqualError(t, validator.Errors()[0], "Set secret of the session object")
+	assert.EqualError(t, validator.Errors()[1], "Session redis and sql providers can't be configured at the same time")
+	assert.EqualError(t, validator.Errors()[2], "Session sql gc_interval must be greater than 0")
+}
+
+func TestShouldSetDefaultRedisConnectionSettings(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := newDefaultSessionConfig()
+	config.Redis = &schema.RedisSessionConfiguration{
+		Host: "redis.localhost",
+		Port: 6379,
+		TLS:  &schema.RedisTLSConfiguration{},
+	}
+
+	ValidateSession(&config, validator)
+
+	assert.Len(t, validator.Errors(), 0)
+	assert.Equal(t, 8, config.Redis.PoolSize)
+	assert.Equal(t, "5m", config.Redis.IdleTimeout)
+	assert.Equal(t, "TLS1.2", config.Redis.TLS.MinimumVersion)
+	assert.Equal(t, "redis.localhost", config.Redis.TLS.ServerName)
+}
+
+func TestShouldKeepRedisTLSServerName(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := newDefaultSessionConfig()
+	config.Redis = &schema.RedisSessionConfiguration{
+		Host: "10.0.0.5",
+		Port: 6379,
+		TLS:  &schema.RedisTLSConfiguration{ServerName: "redis.example.com"},
+	}
+
+	ValidateSession(&config, validator)
+
+	assert.Len(t, validator.Errors(), 0)
+	assert.Equal(t, "redis.example.com", config.Redis.TLS.ServerName)
+}
+
+func TestShouldRaiseErrorsWhenRedisConnectionSettingsAreInvalid(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := newDefaultSessionConfig()
+	config.Redis = &schema.RedisSessionConfiguration{
+		Host:        "redis.localhost",
+		Port:        6379,
+		PoolSize:    -1,
+		DialTimeout: "ten seconds",
+		TLS: &schema.RedisTLSConfiguration{
+			MinimumVersion: "SSL3.0",
+			TrustedCert:    "/path/not/found/ca.pem",
+			Certificate:    "/path/not/found/cert.pem",
+		},
+	}
+
+	ValidateSession(&config, validator)
+
+	require.Len(t, validator.Errors(), 6)
+	assert.EqualError(t, validator.Errors()[0], "Session redis pool_size must be a positive number but it is -1")
+	assert.EqualError(t, validator.Errors()[1], "Error occurred parsing session redis dial_timeout string: Could not convert the input string of ten seconds into a duration")
+	assert.EqualError(t, validator.Errors()[2], "error occurred validating the session redis tls minimum_version key with value SSL3.0: supplied TLS version isn't supported")
+	assert.EqualError(t, validator.Errors()[3], "Session redis tls requires both the certificate and the key of the client")
+	assert.EqualError(t, validator.Errors()[4], "Session redis tls file /path/not/found/ca.pem does not exist")
+	assert.EqualError(t, validator.Errors()[5], "Session redis tls file /path/not/found/cert.pem does not exist")
+}
+
+func TestShouldSetDefaultPortOfRedisSentinelNodes(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := newDefaultSessionConfig()
+	config.Redis = &schema.RedisSessionConfiguration{
+		Host: "sentinel-node1",
+		HighAvailability: &schema.RedisHighAvailabilityConfiguration{
+			SentinelName: "mymaster",
+			Nodes: []schema.RedisNode{
+				{Host: "sentinel-node2", Port: 26380},
+				{Host: "sentinel-node3"},
+			},
+		},
+	}
+
+	ValidateSession(&config, validator)
+
+	assert.Len(t, validator.Errors(), 0)
+	assert.Equal(t, int64(26379), config.Redis.Port)
+	assert.Equal(t, int64(26380), config.Redis.HighAvailability.Nodes[0].Port)
+	assert.Equal(t, int64(26379), config.Redis.HighAvailability.Nodes[1].Port)
+}
+
+func TestShouldRaiseErrorsWhenRedisSentinelIsMisconfigured(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := newDefaultSessionConfig()
+	config.Redis = &schema.RedisSessionConfiguration{
+		HighAvailability: &schema.RedisHighAvailabilityConfiguration{},
+	}
+
+	ValidateSession(&config, validator)
+
+	require.Len(t, validator.Errors(), 2)
+	assert.EqualError(t, validator.Errors()[0], "Session redis high_availability requires the sentinel_name of the master")
+	assert.EqualError(t, validator.Errors()[1], "Session redis high_availability requires a host or at least one node")
+
+	validator.Clear()
+
+	config.Redis.HighAvailability = &schema.RedisHighAvailabilityConfiguration{
+		SentinelName: "mymaster",
+		Nodes:        []schema.RedisNode{{Port: 26379}},
+	}
+
+	ValidateSession(&config, validator)
+
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "Session redis high_availability node 1 requires a host")
+}
+
 func TestShouldRaiseErrorWhenDomainNotSet(t *testing.T) {
 	validator := schema.NewStructValidator()
 	config := newDefaultSessionConfig()
@@ -165,3 +316,143 @@ func TestShouldSetDefaultRememberMeDuration(t *testing.T) {
 	assert.Len(t, validator.Errors(), 0)
 	assert.Equal(t, config.RememberMeDuration, schema.DefaultSessionConfiguration.RememberMeDuration)
 }
+
+func TestShouldAcceptPreviousSessionSecrets(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := newDefaultSessionConfig()
+	config.PreviousSecrets = []string{"old_secret", "older_secret"}
+
+	ValidateSession(&config, validator)
+
+	assert.Len(t, validator.Errors(), 0)
+}
+
+func TestShouldRaiseErrorsWhenPreviousSessionSecretsAreMisconfigured(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := newDefaultSessionConfig()
+	config.Secret = ""
+	config.PreviousSecrets = []string{"old_secret", ""}
+
+	ValidateSession(&config, validator)
+
+	require.Len(t, validator.Errors(), 2)
+	assert.EqualError(t, validator.Errors()[0], "Session previous_secrets entry 2 must not be empty")
+	assert.EqualError(t, validator.Errors()[1], "Session previous_secrets can't be used without the secret")
+}
+
+func TestShouldSetDefaultSessionMaxConcurrentStrategy(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := newDefaultSessionConfig()
+	config.MaxConcurrent = 3
+
+	ValidateSession(&config, validator)
+
+	assert.Len(t, validator.Errors(), 0)
+	assert.Equal(t, schema.SessionMaxConcurrentStrategyEvictOldest, config.MaxConcurrentStrategy)
+}
+
+func TestShouldRaiseErrorsWhenSessionMaxConcurrentIsMisconfigured(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := newDefaultSessionConfig()
+	config.MaxConcurrent = -1
+	config.MaxConcurrentStrategy = "evict_newest"
+
+	ValidateSession(&config, validator)
+
+	require.Len(t, validator.Errors(), 2)
+	assert.EqualError(t, validator.Errors()[0], "Session max_concurrent must be 0 or greater")
+	assert.EqualError(t, validator.Errors()[1], "Session max_concurrent_strategy must be one of 'reject' or 'evict_oldest' but it is configured as 'evict_newest'")
+}
+
+func TestShouldSetDefaultSessionBindingAction(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := newDefaultSessionConfig()
+	config.Binding = &schema.SessionBindingConfiguration{IPv4PrefixLength: 24, IPv6PrefixLength: 64}
+
+	ValidateSession(&config, validator)
+
+	assert.Len(t, validator.Errors(), 0)
+	assert.Equal(t, schema.SessionBindingActionDegrade, config.Binding.Action)
+}
+
+func TestShouldRaiseErrorsWhenSessionBindingIsMisconfigured(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := newDefaultSessionConfig()
+	config.Binding = &schema.SessionBindingConfiguration{IPv4PrefixLength: 33, IPv6PrefixLength: -1, Action: "block"}
+
+	ValidateSession(&config, validator)
+
+	require.Len(t, validator.Errors(), 3)
+	assert.EqualError(t, validator.Errors()[0], "Session binding ipv4_prefix_length must be between 0 and 32 but it is configured as 33")
+	assert.EqualError(t, validator.Errors()[1], "Session binding ipv6_prefix_length must be between 0 and 128 but it is configured as -1")
+	assert.EqualError(t, validator.Errors()[2], "Session binding action must be one of 'degrade' or 'destroy' but it is configured as 'block'")
+}
+
+func TestShouldRaiseErrorWhenSessionBindingBindsNothing(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := newDefaultSessionConfig()
+	config.Binding = &schema.SessionBindingConfiguration{}
+
+	ValidateSession(&config, validator)
+
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "Session binding requires an ipv4_prefix_length, an ipv6_prefix_length or user_agent")
+}
+
+func TestShouldSetDefaultSessionCookieAttributes(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := newDefaultSessionConfig()
+
+	ValidateSession(&config, validator)
+
+	assert.Len(t, validator.Errors(), 0)
+	assert.Equal(t, schema.SessionSameSiteLax, config.SameSite)
+	assert.Equal(t, "/", config.CookiePath)
+}
+
+func TestShouldRaiseErrorsWhenSessionCookieAttributesAreInvalid(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := newDefaultSessionConfig()
+	config.SameSite = "relaxed"
+	config.CookiePath = "auth; Domain=evil.com"
+
+	ValidateSession(&config, validator)
+
+	require.Len(t, validator.Errors(), 2)
+	assert.EqualError(t, validator.Errors()[0], "Session same_site must be one of 'lax', 'strict' or 'none' but it is configured as 'relaxed'")
+	assert.EqualError(t, validator.Errors()[1], "Session cookie_path must be an absolute path without spaces or semicolons but it is configured as 'auth; Domain=evil.com'")
+}
+
+func TestShouldValidateMemorySessionSnapshot(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := newDefaultSessionConfig()
+	config.Memory = &schema.MemorySessionConfiguration{SnapshotPath: filepath.Join(os.TempDir(), "sessions.db")}
+
+	ValidateSession(&config, validator)
+
+	assert.Len(t, validator.Errors(), 0)
+}
+
+func TestShouldRaiseErrorsWhenMemorySessionIsMisconfigured(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := newDefaultSessionConfig()
+	config.Secret = ""
+	config.Memory = &schema.MemorySessionConfiguration{SnapshotPath: "/path/not/found/sessions.db"}
+
+	ValidateSession(&config, validator)
+
+	require.Len(t, validator.Errors(), 2)
+	assert.EqualError(t, validator.Errors()[0], "Set secret of the session object")
+	assert.EqualError(t, validator.Errors()[1], "Session memory snapshot_path must be in an existing directory but it is configured as '/path/not/found/sessions.db'")
+
+	validator = schema.NewStructValidator()
+	config = newDefaultSessionConfig()
+	config.SQL = &schema.SQLSessionConfiguration{}
+	config.Memory = &schema.MemorySessionConfiguration{}
+
+	ValidateSession(&config, validator)
+
+	require.Len(t, validator.Errors(), 2)
+	assert.EqualError(t, validator.Errors()[0], "Session memory can't be configured with the redis or sql providers")
+	assert.EqualError(t, validator.Errors()[1], "Session memory requires a snapshot_path")
+}
@@ -0,0 +1,6 @@
+package geoip
+
+import "errors"
+
+// ErrCountryNotFound is returned when the country of an IP address is not in the database.
+var ErrCountryNotFound = errors.New("country not found")
@@ -0,0 +1,121 @@
+package geoip
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/logging"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// countryRecord is the part of a record of a MaxMind database holding the country.
+type countryRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+}
+
+// MaxMindProvider a GeoIP provider reading a local database in the MaxMind DB format.
+type MaxMindProvider struct {
+	path string
+
+	mutex   sync.RWMutex
+	reader  *maxminddb.Reader
+	modTime time.Time
+}
+
+// NewMaxMindProvider create a MaxMindProvider reading the database at the configured path.
+func NewMaxMindProvider(configuration schema.GeoIPConfiguration) (*MaxMindProvider, error) {
+	provider := &MaxMindProvider{
+		path: configuration.Path,
+	}
+
+	if _, err := provider.Reload(); err != nil {
+		return nil, err
+	}
+
+	return provider, nil
+}
+
+// Reload reopens the database if the file has been modified since it has been loaded and tells whether it did.
+func (p *MaxMindProvider) Reload() (bool, error) {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return false, fmt.Errorf("Unable to stat GeoIP database %s: %s", p.path, err)
+	}
+
+	p.mutex.RLock()
+	upToDate := p.reader != nil && info.ModTime().Equal(p.modTime)
+	p.mutex.RUnlock()
+
+	if upToDate {
+		return false, nil
+	}
+
+	reader, err := maxminddb.Open(p.path)
+	if err != nil {
+		return false, fmt.Errorf("Unable to open GeoIP database %s: %s", p.path, err)
+	}
+
+	p.mutex.Lock()
+	previous := p.reader
+	p.reader = reader
+	p.modTime = info.ModTime()
+	p.mutex.Unlock()
+
+	if previous != nil {
+		if err := previous.Close(); err != nil {
+			logging.Logger().Warnf("Unable to close previous GeoIP database: %s", err)
+		}
+	}
+
+	return true, nil
+}
+
+// StartAutoReload reloads the database every interval when the file changes until the process exits.
+func (p *MaxMindProvider) StartAutoReload(clock utils.Clock, interval time.Duration) {
+	go func() {
+		for {
+			<-clock.After(interval)
+
+			reloaded, err := p.Reload()
+			if err != nil {
+				logging.Logger().Errorf("Unable to reload GeoIP database: %s", err)
+				continue
+			}
+
+			if reloaded {
+				logging.Logger().Infof("GeoIP database %s has been reloaded", p.path)
+			}
+		}
+	}()
+}
+
+// Country returns the ISO 3166-1 alpha-2 code of the country of the IP address.
+func (p *MaxMindProvider) Country(ip net.IP) (string, error) {
+	if ip == nil {
+		return "", ErrCountryNotFound
+	}
+
+	var record countryRecord
+
+	p.mutex.RLock()
+	err := p.reader.Lookup(ip, &record)
+	p.mutex.RUnlock()
+
+	if err != nil {
+		return "", err
+	}
+
+	if record.Country.ISOCode == "" {
+		return "", ErrCountryNotFound
+	}
+
+	return record.Country.ISOCode, nil
+}
@@ -0,0 +1,180 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+// encodeString encodes a short string in the MaxMind DB data format.
+func encodeString(s string) []byte {
+	return append([]byte{byte(2<<5 | len(s))}, s...)
+}
+
+// encodeUint16 encodes an unsigned integer in the MaxMind DB data format.
+func encodeUint16(v uint16) []byte {
+	return []byte{byte(5<<5 | 2), byte(v >> 8), byte(v)}
+}
+
+// encodeUint32 encodes an unsigned integer in the MaxMind DB data format.
+func encodeUint32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+
+	return append([]byte{byte(6<<5 | 4)}, b...)
+}
+
+// encodeMap encodes a map whose values are already encoded in the MaxMind DB data format.
+func encodeMap(keys []string, values [][]byte) []byte {
+	b := []byte{byte(7<<5 | len(keys))}
+
+	for i, key := range keys {
+		b = append(b, encodeString(key)...)
+		b = append(b, values[i]...)
+	}
+
+	return b
+}
+
+// writeTestDatabase writes an IPv4 MaxMind database locating the networks in the given countries.
+func writeTestDatabase(t *testing.T, path string, countries map[string]string) {
+	type node struct{ records [2]int }
+
+	// Records are node indexes, -1 for an empty record and -2-i for the data of the i-th country.
+	nodes := []node{{records: [2]int{-1, -1}}}
+	data := []byte{}
+	dataOffsets := []int{}
+
+	for network, country := range countries {
+		_, ipNet, err := net.ParseCIDR(network)
+		require.NoError(t, err)
+
+		ones, _ := ipNet.Mask.Size()
+		ip := ipNet.IP.To4()
+
+		dataOffsets = append(dataOffsets, len(data))
+		data = append(data, encodeMap([]string{"country"}, [][]byte{
+			encodeMap([]string{"iso_code"}, [][]byte{encodeString(country)}),
+		})...)
+
+		current := 0
+
+		for i := 0; i < ones; i++ {
+			bit := int(ip[i/8]>>(7-uint(i%8))) & 1
+
+			if i == ones-1 {
+				nodes[current].records[bit] = -2 - (len(dataOffsets) - 1)
+				break
+			}
+
+			if nodes[current].records[bit] < 0 {
+				nodes = append(nodes, node{records: [2]int{-1, -1}})
+				nodes[current].records[bit] = len(nodes) - 1
+			}
+
+			current = nodes[current].records[bit]
+		}
+	}
+
+	nodeCount := len(nodes)
+	buffer := bytes.Buffer{}
+
+	for _, n := range nodes {
+		for _, record := range n.records {
+			value := record
+
+			switch {
+			case record == -1:
+				value = nodeCount
+			case record < -1:
+				value = nodeCount + 16 + dataOffsets[-2-record]
+			}
+
+			buffer.Write([]byte{byte(value >> 16), byte(value >> 8), byte(value)})
+		}
+	}
+
+	buffer.Write(make([]byte, 16))
+	buffer.Write(data)
+	buffer.WriteString("\xAB\xCD\xEFMaxMind.com")
+	buffer.Write(encodeMap(
+		[]string{"binary_format_major_version", "binary_format_minor_version", "database_type", "ip_version",
+			"node_count", "record_size"},
+		[][]byte{encodeUint16(2), encodeUint16(0), encodeString("Test-Country"), encodeUint16(4),
+			encodeUint32(uint32(nodeCount)), encodeUint16(24)}))
+
+	require.NoError(t, ioutil.WriteFile(path, buffer.Bytes(), 0600))
+}
+
+func TestShouldLocateCountryOfIP(t *testing.T) {
+	dir, err := ioutil.TempDir("", "authelia-geoip")
+	require.NoError(t, err)
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "country.mmdb")
+	writeTestDatabase(t, path, map[string]string{
+		"81.2.69.0/24": "GB",
+		"2.125.0.0/16": "FR",
+	})
+
+	provider, err := NewMaxMindProvider(schema.GeoIPConfiguration{Path: path})
+	require.NoError(t, err)
+
+	country, err := provider.Country(net.ParseIP("81.2.69.142"))
+	require.NoError(t, err)
+	assert.Equal(t, "GB", country)
+
+	country, err = provider.Country(net.ParseIP("2.125.160.216"))
+	require.NoError(t, err)
+	assert.Equal(t, "FR", country)
+
+	_, err = provider.Country(net.ParseIP("10.0.0.1"))
+	assert.Equal(t, ErrCountryNotFound, err)
+
+	_, err = provider.Country(nil)
+	assert.Equal(t, ErrCountryNotFound, err)
+}
+
+func TestShouldReloadDatabaseWhenFileChanges(t *testing.T) {
+	dir, err := ioutil.TempDir("", "authelia-geoip")
+	require.NoError(t, err)
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "country.mmdb")
+	writeTestDatabase(t, path, map[string]string{"81.2.69.0/24": "GB"})
+
+	provider, err := NewMaxMindProvider(schema.GeoIPConfiguration{Path: path})
+	require.NoError(t, err)
+
+	reloaded, err := provider.Reload()
+	require.NoError(t, err)
+	assert.False(t, reloaded)
+
+	writeTestDatabase(t, path, map[string]string{"81.2.69.0/24": "IE"})
+	require.NoError(t, os.Chtimes(path, time.Now().Add(time.Minute), time.Now().Add(time.Minute)))
+
+	reloaded, err = provider.Reload()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+
+	country, err := provider.Country(net.ParseIP("81.2.69.142"))
+	require.NoError(t, err)
+	assert.Equal(t, "IE", country)
+}
+
+func TestShouldFailToLoadMissingDatabase(t *testing.T) {
+	_, err := NewMaxMindProvider(schema.GeoIPConfiguration{Path: "/path/to/nowhere.mmdb"})
+	assert.EqualError(t, err, "Unable to stat GeoIP database /path/to/nowhere.mmdb: stat /path/to/nowhere.mmdb: no such file or directory")
+}
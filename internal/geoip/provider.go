@@ -0,0 +1,9 @@
+package geoip
+
+import "net"
+
+// Provider interface for locating the country of an IP address.
+type Provider interface {
+	// Country returns the ISO 3166-1 alpha-2 code of the country of the IP address.
+	Country(ip net.IP) (string, error)
+}
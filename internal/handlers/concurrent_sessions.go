@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"bytes"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/notification"
+	"github.com/authelia/authelia/internal/session"
+	"github.com/authelia/authelia/internal/templates"
+)
+
+// enforceMaxConcurrentSessions limits the number of active sessions of a user who is logging in. The session is
+// recorded in the index of the active sessions before they are counted so that the concurrent logins of the user see
+// each other and can't exceed the maximum together. Depending on the strategy, errMaxConcurrentSessions is returned to
+// reject the login or the oldest sessions are destroyed to make room for the new one and the user is notified.
+func enforceMaxConcurrentSessions(ctx *middlewares.AutheliaCtx, userDetails *authentication.UserDetails) error {
+	maxSessions := ctx.Configuration.Session.MaxConcurrent
+	if maxSessions == 0 {
+		return nil
+	}
+
+	id, err := reserveActiveSession(ctx, userDetails.Username)
+	if err != nil {
+		return err
+	}
+
+	sessions, err := ctx.Providers.SessionProvider.GetActiveSessions(userDetails.Username)
+	if err != nil {
+		return err
+	}
+
+	if len(sessions) <= maxSessions {
+		return nil
+	}
+
+	reject := ctx.Configuration.Session.MaxConcurrentStrategy == schema.SessionMaxConcurrentStrategyReject
+
+	// The sessions are ordered by creation, the ties being broken by their identifiers so that all the concurrent
+	// logins agree on the sessions which are kept: the oldest ones with the reject strategy and the newest ones
+	// otherwise.
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].CreatedAt != sessions[j].CreatedAt {
+			return (sessions[i].CreatedAt < sessions[j].CreatedAt) == reject
+		}
+
+		return (sessions[i].ID < sessions[j].ID) == reject
+	})
+
+	if !isActiveSessionIn(sessions[:maxSessions], id) {
+		if err := ctx.Providers.SessionProvider.RemoveActiveSession(userDetails.Username, id); err != nil {
+			return err
+		}
+
+		return errMaxConcurrentSessions
+	}
+
+	// The newer sessions are the concurrent logins which reject themselves.
+	if reject {
+		return nil
+	}
+
+	evictedSessions := make([]session.ActiveSession, 0, len(sessions)-maxSessions)
+
+	for _, evicted := range sessions[maxSessions:] {
+		revoked, err := ctx.Providers.SessionProvider.RevokeActiveSession(userDetails.Username, evicted.ID)
+		if err != nil {
+			return err
+		}
+
+		if !revoked {
+			continue
+		}
+
+		ctx.Logger.Infof("Session %s of user %s has been evicted since the user reached the maximum of %d concurrent sessions",
+			evicted.ID, userDetails.Username, maxSessions)
+
+		evictedSessions = append(evictedSessions, evicted)
+	}
+
+	// The login doesn't wait for the emails to be sent.
+	if len(evictedSessions) != 0 {
+		go notifySessionsEvicted(ctx.Logger, ctx.Providers.Notifier, userDetails, evictedSessions)
+	}
+
+	return nil
+}
+
+// reserveActiveSession records the session of the request in the index of the active sessions of the user and returns
+// its public identifier.
+func reserveActiveSession(ctx *middlewares.AutheliaCtx, username string) (string, error) {
+	userSession := session.NewDefaultUserSession()
+	userSession.Username = username
+
+	err := ctx.Providers.SessionProvider.UpdateActiveSession(ctx.RequestCtx, userSession, ctx.RemoteIP().String(),
+		ctx.Clock.Now())
+	if err != nil {
+		return "", err
+	}
+
+	return ctx.Providers.SessionProvider.GetActiveSessionID(ctx.RequestCtx)
+}
+
+func isActiveSessionIn(sessions []session.ActiveSession, id string) bool {
+	for _, activeSession := range sessions {
+		if activeSession.ID == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// notifySessionsEvicted sends an email to the user for each of their evicted sessions. The errors are only logged
+// since the login is already done.
+func notifySessionsEvicted(logger *logrus.Entry, notifier notification.Notifier, userDetails *authentication.UserDetails,
+	evictedSessions []session.ActiveSession) {
+	if len(userDetails.Emails) == 0 {
+		logger.Debugf("User %s has no email to be notified of the eviction of their session", userDetails.Username)
+		return
+	}
+
+	for _, evicted := range evictedSessions {
+		bufText := new(bytes.Buffer)
+
+		err := templates.PlainTextSessionEvictedEmailTemplate.Execute(bufText, map[string]interface{}{
+			"created":   time.Unix(evicted.CreatedAt, 0).UTC().Format(time.RFC1123),
+			"ip":        evicted.IP,
+			"userAgent": evicted.UserAgent,
+		})
+		if err != nil {
+			logger.Errorf("Unable to notify user %s of the eviction of their session: %s", userDetails.Username, err)
+			continue
+		}
+
+		err = notifier.Send(userDetails.Emails[0], sessionEvictedMailTitle, bufText.String(), "")
+		if err != nil {
+			logger.Errorf("Unable to notify user %s of the eviction of their session: %s", userDetails.Username, err)
+		}
+	}
+}
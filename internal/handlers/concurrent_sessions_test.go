@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"github.com/valyala/fasthttp"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/mocks"
+	"github.com/authelia/authelia/internal/session"
+)
+
+type ConcurrentSessionsSuite struct {
+	suite.Suite
+	mock *mocks.MockAutheliaCtx
+
+	userDetails *authentication.UserDetails
+}
+
+func (s *ConcurrentSessionsSuite) SetupTest() {
+	s.mock = mocks.NewMockAutheliaCtx(s.T())
+	s.userDetails = &authentication.UserDetails{
+		Username: testUsername,
+		Emails:   []string{"john@example.com"},
+	}
+
+	// The session of the user logging in is stored before the limit is enforced.
+	s.Require().NoError(s.mock.Ctx.SaveSession(session.NewDefaultUserSession()))
+}
+
+func (s *ConcurrentSessionsSuite) TearDownTest() {
+	s.mock.Close()
+}
+
+// login opens a session of the user from another browser and returns its public identifier.
+func (s *ConcurrentSessionsSuite) login(ip string, createdAt time.Time) string {
+	provider := s.mock.Ctx.Providers.SessionProvider
+	otherCtx := &fasthttp.RequestCtx{}
+
+	userSession, err := provider.GetSession(otherCtx)
+	s.Require().NoError(err)
+
+	userSession.Username = testUsername
+	userSession.AuthenticationLevel = authentication.OneFactor
+	s.Require().NoError(provider.SaveSession(otherCtx, userSession))
+	s.Require().NoError(provider.UpdateActiveSession(otherCtx, userSession, ip, createdAt))
+
+	id, err := provider.GetActiveSessionID(otherCtx)
+	s.Require().NoError(err)
+
+	return id
+}
+
+func (s *ConcurrentSessionsSuite) activeSessionIDs() []string {
+	sessions, err := s.mock.Ctx.Providers.SessionProvider.GetActiveSessions(testUsername)
+	s.Require().NoError(err)
+
+	ids := make([]string, 0, len(sessions))
+	for _, activeSession := range sessions {
+		ids = append(ids, activeSession.ID)
+	}
+
+	return ids
+}
+
+func (s *ConcurrentSessionsSuite) currentSessionID() string {
+	id, err := s.mock.Ctx.Providers.SessionProvider.GetActiveSessionID(s.mock.Ctx.RequestCtx)
+	s.Require().NoError(err)
+
+	return id
+}
+
+func (s *ConcurrentSessionsSuite) TestShouldNotLimitSessionsByDefault() {
+	s.login("192.168.0.1", time.Unix(1000, 0))
+	s.login("192.168.0.2", time.Unix(2000, 0))
+
+	s.NoError(enforceMaxConcurrentSessions(s.mock.Ctx, s.userDetails))
+	s.Len(s.activeSessionIDs(), 2)
+}
+
+func (s *ConcurrentSessionsSuite) TestShouldAllowLoginBelowTheMaximum() {
+	s.mock.Ctx.Configuration.Session.MaxConcurrent = 2
+	s.mock.Ctx.Configuration.Session.MaxConcurrentStrategy = schema.SessionMaxConcurrentStrategyReject
+
+	s.login("192.168.0.1", time.Unix(1000, 0))
+
+	s.NoError(enforceMaxConcurrentSessions(s.mock.Ctx, s.userDetails))
+	s.Len(s.activeSessionIDs(), 2)
+}
+
+func (s *ConcurrentSessionsSuite) TestShouldRejectLoginWhenMaximumIsReached() {
+	s.mock.Ctx.Configuration.Session.MaxConcurrent = 2
+	s.mock.Ctx.Configuration.Session.MaxConcurrentStrategy = schema.SessionMaxConcurrentStrategyReject
+
+	s.login("192.168.0.1", time.Unix(1000, 0))
+	s.login("192.168.0.2", time.Unix(2000, 0))
+
+	s.Equal(errMaxConcurrentSessions, enforceMaxConcurrentSessions(s.mock.Ctx, s.userDetails))
+	s.Len(s.activeSessionIDs(), 2)
+	s.NotContains(s.activeSessionIDs(), s.currentSessionID())
+}
+
+func (s *ConcurrentSessionsSuite) TestShouldKeepSessionReservedBeforeConcurrentLogin() {
+	s.mock.Ctx.Configuration.Session.MaxConcurrent = 1
+	s.mock.Ctx.Configuration.Session.MaxConcurrentStrategy = schema.SessionMaxConcurrentStrategyReject
+
+	// Another login of the user reserved its session right after this one.
+	concurrent := s.login("192.168.0.1", time.Now().Add(time.Minute))
+
+	s.NoError(enforceMaxConcurrentSessions(s.mock.Ctx, s.userDetails))
+	s.ElementsMatch([]string{concurrent, s.currentSessionID()}, s.activeSessionIDs())
+}
+
+func (s *ConcurrentSessionsSuite) TestShouldEvictOldestSessionsWhenMaximumIsReached() {
+	s.mock.Ctx.Configuration.Session.MaxConcurrent = 2
+	s.mock.Ctx.Configuration.Session.MaxConcurrentStrategy = schema.SessionMaxConcurrentStrategyEvictOldest
+
+	s.login("192.168.0.2", time.Unix(2000, 0))
+	newest := s.login("192.168.0.3", time.Unix(3000, 0))
+	s.login("192.168.0.1", time.Unix(1000, 0))
+
+	sent := make(chan struct{}, 2)
+
+	s.mock.NotifierMock.EXPECT().
+		Send(gomock.Eq("john@example.com"), gomock.Eq(sessionEvictedMailTitle), gomock.Any(), gomock.Eq("")).
+		DoAndReturn(func(recipient, subject, body, htmlBody string) error {
+			sent <- struct{}{}
+			return nil
+		}).
+		Times(2)
+
+	s.NoError(enforceMaxConcurrentSessions(s.mock.Ctx, s.userDetails))
+	s.ElementsMatch([]string{newest, s.currentSessionID()}, s.activeSessionIDs())
+
+	// The emails are sent in the background.
+	for i := 0; i < 2; i++ {
+		select {
+		case <-sent:
+		case <-time.After(time.Second):
+			s.FailNow("The user has not been notified of the evicted sessions")
+		}
+	}
+}
+
+func (s *ConcurrentSessionsSuite) TestShouldEvictSessionWhenNotificationFails() {
+	s.mock.Ctx.Configuration.Session.MaxConcurrent = 1
+	s.mock.Ctx.Configuration.Session.MaxConcurrentStrategy = schema.SessionMaxConcurrentStrategyEvictOldest
+
+	s.login("192.168.0.1", time.Unix(1000, 0))
+
+	s.mock.NotifierMock.EXPECT().
+		Send(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(fmt.Errorf("failed"))
+
+	s.NoError(enforceMaxConcurrentSessions(s.mock.Ctx, s.userDetails))
+	s.Equal([]string{s.currentSessionID()}, s.activeSessionIDs())
+
+	s.Eventually(func() bool {
+		entry := s.mock.Hook.LastEntry()
+		return entry != nil && entry.Message == "Unable to notify user john of the eviction of their session: failed"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (s *ConcurrentSessionsSuite) TestShouldRejectFirstFactorWhenMaximumIsReached() {
+	s.mock.Ctx.Configuration.Session.MaxConcurrent = 1
+	s.mock.Ctx.Configuration.Session.MaxConcurrentStrategy = schema.SessionMaxConcurrentStrategyReject
+
+	s.login("192.168.0.1", time.Unix(1000, 0))
+
+	s.mock.UserProviderMock.
+		EXPECT().
+		CheckUserPassword(gomock.Eq(testUsername), gomock.Eq("hello")).
+		Return(true, nil)
+
+	s.mock.UserProviderMock.
+		EXPECT().
+		GetDetails(gomock.Eq(testUsername)).
+		Return(s.userDetails, nil)
+
+	s.mock.StorageProviderMock.
+		EXPECT().
+		AppendAuthenticationLog(gomock.Any()).
+		Return(nil)
+
+	s.mock.StorageProviderMock.
+		EXPECT().
+		LoadSessionGeneration(gomock.Eq(testUsername)).
+		Return(0, nil)
+
+	s.mock.Ctx.Request.SetBodyString(`{
+		"username": "john",
+		"password": "hello"
+	}`)
+	FirstFactorPost(0, false)(s.mock.Ctx)
+
+	s.mock.Assert401KO(s.T(), maxConcurrentSessionsMessage)
+	assert.Equal(s.T(), "", s.mock.Ctx.GetSession().Username)
+	assert.Len(s.T(), s.activeSessionIDs(), 1)
+}
+
+func TestRunConcurrentSessionsSuite(t *testing.T) {
+	suite.Run(t, new(ConcurrentSessionsSuite))
+}
@@ -22,6 +22,32 @@ const remoteNameHeader = "Remote-Name"
 const remoteEmailHeader = "Remote-Email"
 const remoteGroupsHeader = "Remote-Groups"
 
+const explainRuleHeader = "X-Authelia-Explain-Rule"
+const explainRequiredLevelHeader = "X-Authelia-Explain-Required-Level"
+const explainCurrentLevelHeader = "X-Authelia-Explain-Current-Level"
+const explainReasonHeader = "X-Authelia-Explain-Reason"
+
+// The reasons of the decisions of the verify endpoint given in explain mode.
+const (
+	reasonAuthorized           = "authorized"
+	reasonForbidden            = "forbidden"
+	reasonInactive             = "inactive"
+	reasonRevoked              = "revoked"
+	reasonBindingMismatch      = "binding_mismatch"
+	reasonUnauthorized         = "unauthorized"
+	reasonNeedsAuthentication  = "needs_authentication"
+	reasonNeedsTwoFactor       = "needs_two_factor"
+	reasonNeedsRequiredMethod  = "needs_required_method"
+	reasonAuthenticationTooOld = "authentication_too_old"
+)
+
+const defaultPolicyRule = "default_policy"
+
+// accessDeniedPath is the path of the access denied page of the login portal. It is a route of the portal served by
+// Authelia itself, see AccessDeniedRoute in web/src/Routes.ts, so it is not configurable. The portal is chosen per
+// domain with portal_url instead.
+const accessDeniedPath = "/denied"
+
 var protoHostSeparator = []byte("://")
 
 const (
@@ -31,6 +57,8 @@ const (
 	NotAuthorized authorizationMatching = iota
 	// Authorized means the user is authorized given her current permissions.
 	Authorized authorizationMatching = iota
+	// AccessDenied means the user is forbidden the access to a resource and is sent to the access denied page.
+	AccessDenied authorizationMatching = iota
 )
 
 const operationFailedMessage = "Operation failed."
@@ -40,6 +68,8 @@ const unableToRegisterOneTimePasswordMessage = "Unable to set up one-time passwo
 const unableToRegisterSecurityKeyMessage = "Unable to register your security key."
 const unableToResetPasswordMessage = "Unable to reset your password."
 const mfaValidationFailedMessage = "Authentication failed, please retry later."
+const sessionEvictedMailTitle = "One of your sessions has been closed"
+const maxConcurrentSessionsMessage = "You have reached the maximum number of active sessions. Log out from another device and retry."
 
 const ldapPasswordComplexityCode = "0000052D."
 
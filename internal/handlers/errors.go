@@ -11,3 +11,7 @@ const UnauthorizedError = "You're not authorized."
 
 var errMissingXForwardedHost = errors.New("Missing header X-Forwarded-Host")
 var errMissingXForwardedProto = errors.New("Missing header X-Forwarded-Proto")
+var errSessionInactive = errors.New("has been inactive for too long")
+var errSessionStale = errors.New("has a session of a previous generation")
+var errSessionBindingMismatch = errors.New("has a session bound to another client")
+var errMaxConcurrentSessions = errors.New("reached the maximum number of concurrent sessions")
@@ -20,7 +20,7 @@ func (s *SecondFactorAvailableMethodsFixture) SetupTest() {
 	s.mock.Ctx.Providers.Authorizer = authorization.NewAuthorizer(schema.AccessControlConfiguration{
 		DefaultPolicy: "deny",
 		Rules:         []schema.ACLRule{},
-	})
+	}, &s.mock.Clock)
 }
 
 func (s *SecondFactorAvailableMethodsFixture) TearDownTest() {
@@ -82,7 +82,7 @@ func (s *SecondFactorAvailableMethodsFixture) TestShouldCheckSecondFactorIsDisab
 				Policy:  "bypass",
 			},
 		},
-	})
+	}, &s.mock.Clock)
 	ConfigurationGet(s.mock.Ctx)
 	s.mock.Assert200OK(s.T(), ConfigurationBody{
 		AvailableMethods:    []string{"totp", "u2f"},
@@ -113,7 +113,7 @@ func (s *SecondFactorAvailableMethodsFixture) TestShouldCheckSecondFactorIsEnabl
 				Policy:  "bypass",
 			},
 		},
-	})
+	}, &s.mock.Clock)
 	ConfigurationGet(s.mock.Ctx)
 	s.mock.Assert200OK(s.T(), ConfigurationBody{
 		AvailableMethods:    []string{"totp", "u2f"},
@@ -144,7 +144,7 @@ func (s *SecondFactorAvailableMethodsFixture) TestShouldCheckSecondFactorIsEnabl
 				Policy:  "bypass",
 			},
 		},
-	})
+	}, &s.mock.Clock)
 	ConfigurationGet(s.mock.Ctx)
 	s.mock.Assert200OK(s.T(), ConfigurationBody{
 		AvailableMethods:    []string{"totp", "u2f"},
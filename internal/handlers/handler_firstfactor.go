@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"math"
 	"math/rand"
+	"net/url"
+	"strings"
 	"sync"
 	"time"
 
@@ -127,6 +129,21 @@ func FirstFactorPost(msInitialDelay time.Duration, delayEnabled bool) middleware
 
 		ctx.Logger.Debugf("Credentials validation of user %s is ok", bodyJSON.Username)
 
+		refreshed, err := refreshFirstFactor(ctx, bodyJSON)
+
+		if err != nil {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to refresh the first factor of user %s: %s", bodyJSON.Username, err.Error()), authenticationFailedMessage)
+			return
+		}
+
+		if refreshed != nil {
+			successful = true
+
+			Handle1FAResponse(ctx, bodyJSON.TargetURL, *refreshed)
+
+			return
+		}
+
 		// Reset all values from previous session before regenerating the cookie.
 		err = ctx.SaveSession(session.NewDefaultUserSession())
 
@@ -143,11 +160,13 @@ func FirstFactorPost(msInitialDelay time.Duration, delayEnabled bool) middleware
 		}
 
 		// Check if bodyJSON.KeepMeLoggedIn can be deref'd and derive the value based on the configuration and JSON data
-		keepMeLoggedIn := ctx.Providers.SessionProvider.RememberMe != 0 && bodyJSON.KeepMeLoggedIn != nil && *bodyJSON.KeepMeLoggedIn
+		// The remember me duration is the one of the domain the user is logging into.
+		rememberMe := ctx.Providers.SessionProvider.GetRememberMe(ctx.RequestCtx)
+		keepMeLoggedIn := rememberMe != 0 && bodyJSON.KeepMeLoggedIn != nil && *bodyJSON.KeepMeLoggedIn
 
 		// Set the cookie to expire if remember me is enabled and the user has asked us to
 		if keepMeLoggedIn {
-			err = ctx.Providers.SessionProvider.UpdateExpiration(ctx.RequestCtx, ctx.Providers.SessionProvider.RememberMe)
+			err = ctx.Providers.SessionProvider.UpdateExpiration(ctx.RequestCtx, rememberMe)
 			if err != nil {
 				handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to update expiration timer for user %s: %s", bodyJSON.Username, err.Error()), authenticationFailedMessage)
 				return
@@ -164,15 +183,43 @@ func FirstFactorPost(msInitialDelay time.Duration, delayEnabled bool) middleware
 
 		ctx.Logger.Tracef("Details for user %s => groups: %s, emails %s", bodyJSON.Username, userDetails.Groups, userDetails.Emails)
 
+		// The session is stamped with the current generation so that it is invalidated by the next increment.
+		generation, err := ctx.Providers.StorageProvider.LoadSessionGeneration(userDetails.Username)
+
+		if err != nil {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to load the session generation of user %s: %s", bodyJSON.Username, err.Error()), authenticationFailedMessage)
+			return
+		}
+
+		// The number of active sessions of the user is limited, the login is either rejected or replaces the oldest ones.
+		err = enforceMaxConcurrentSessions(ctx, userDetails)
+
+		if err == errMaxConcurrentSessions {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("User %s %s", bodyJSON.Username, err), maxConcurrentSessionsMessage)
+			return
+		}
+
+		if err != nil {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to enforce the maximum number of concurrent sessions of user %s: %s", bodyJSON.Username, err.Error()), authenticationFailedMessage)
+			return
+		}
+
 		// And set those information in the new session.
 		userSession := ctx.GetSession()
 		userSession.Username = userDetails.Username
 		userSession.DisplayName = userDetails.DisplayName
 		userSession.Groups = userDetails.Groups
 		userSession.Emails = userDetails.Emails
+		userSession.Attributes = userDetails.Attributes
 		userSession.AuthenticationLevel = authentication.OneFactor
+		userSession.FirstFactorAuthnTimestamp = ctx.Clock.Now().Unix()
 		userSession.LastActivity = time.Now().Unix()
 		userSession.KeepMeLoggedIn = keepMeLoggedIn
+		userSession.Generation = generation
+
+		// The session is bound to the client logging in if configured so.
+		ctx.BindSession(&userSession)
+
 		refresh, refreshInterval := getProfileRefreshSettings(ctx.Configuration.AuthenticationBackend)
 
 		if refresh {
@@ -188,6 +235,61 @@ func FirstFactorPost(msInitialDelay time.Duration, delayEnabled bool) middleware
 
 		successful = true
 
-		Handle1FAResponse(ctx, bodyJSON.TargetURL, userSession.Username, userSession.Groups)
+		Handle1FAResponse(ctx, bodyJSON.TargetURL, userSession)
+	}
+}
+
+// refreshFirstFactor refreshes in place the first factor of a session which already belongs to the user, for instance
+// when the first factor is too old to access a resource. The session keeps its second factor and its binding and
+// returns nil when it must be reset like on a new login: when the session belongs to another user, has been
+// invalidated, has been inactive for too long or is bound to another client.
+func refreshFirstFactor(ctx *middlewares.AutheliaCtx, bodyJSON firstFactorRequestBody) (*session.UserSession, error) {
+	userSession := ctx.GetSession()
+
+	if userSession.Username == "" || !strings.EqualFold(userSession.Username, bodyJSON.Username) ||
+		userSession.AuthenticationLevel == authentication.NotAuthenticated || ctx.IsSessionBindingMismatched() {
+		return nil, nil
+	}
+
+	userDetails, err := ctx.Providers.UserProvider.GetDetails(bodyJSON.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	if userDetails.Username != userSession.Username {
+		return nil, nil
+	}
+
+	generation, err := ctx.Providers.StorageProvider.LoadSessionGeneration(userDetails.Username)
+	if err != nil {
+		return nil, err
 	}
+
+	if userSession.Generation < generation {
+		return nil, nil
+	}
+
+	if !userSession.KeepMeLoggedIn {
+		targetURL, _ := url.ParseRequestURI(bodyJSON.TargetURL)
+
+		inactive, err := hasUserBeenInactiveTooLong(ctx, targetURL)
+		if err != nil || inactive {
+			return nil, err
+		}
+	}
+
+	userSession.DisplayName = userDetails.DisplayName
+	userSession.Groups = userDetails.Groups
+	userSession.Emails = userDetails.Emails
+	userSession.Attributes = userDetails.Attributes
+	userSession.FirstFactorAuthnTimestamp = ctx.Clock.Now().Unix()
+	userSession.LastActivity = ctx.Clock.Now().Unix()
+
+	if err = ctx.SaveSession(userSession); err != nil {
+		return nil, err
+	}
+
+	ctx.Logger.Debugf("First factor of user %s has been refreshed in the current session", userSession.Username)
+
+	return &userSession, nil
 }
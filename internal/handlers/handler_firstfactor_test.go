@@ -167,6 +167,11 @@ func (s *FirstFactorSuite) TestShouldAuthenticateUserWithRememberMeChecked() {
 		AppendAuthenticationLog(gomock.Any()).
 		Return(nil)
 
+	s.mock.StorageProviderMock.
+		EXPECT().
+		LoadSessionGeneration(gomock.Eq("test")).
+		Return(0, nil)
+
 	s.mock.Ctx.Request.SetBodyString(`{
 		"username": "test",
 		"password": "hello",
@@ -207,6 +212,11 @@ func (s *FirstFactorSuite) TestShouldAuthenticateUserWithRememberMeUnchecked() {
 		AppendAuthenticationLog(gomock.Any()).
 		Return(nil)
 
+	s.mock.StorageProviderMock.
+		EXPECT().
+		LoadSessionGeneration(gomock.Eq("test")).
+		Return(0, nil)
+
 	s.mock.Ctx.Request.SetBodyString(`{
 		"username": "test",
 		"password": "hello",
@@ -250,6 +260,11 @@ func (s *FirstFactorSuite) TestShouldSaveUsernameFromAuthenticationBackendInSess
 		AppendAuthenticationLog(gomock.Any()).
 		Return(nil)
 
+	s.mock.StorageProviderMock.
+		EXPECT().
+		LoadSessionGeneration(gomock.Eq("Test")).
+		Return(0, nil)
+
 	s.mock.Ctx.Request.SetBodyString(`{
 		"username": "test",
 		"password": "hello",
@@ -270,6 +285,107 @@ func (s *FirstFactorSuite) TestShouldSaveUsernameFromAuthenticationBackendInSess
 	assert.Equal(s.T(), []string{"dev", "admins"}, session.Groups)
 }
 
+func (s *FirstFactorSuite) TestShouldRefreshFirstFactorOfCurrentSession() {
+	s.mock.Ctx.Clock = &s.mock.Clock
+
+	userSession := s.mock.Ctx.GetSession()
+	userSession.Username = "test"
+	userSession.AuthenticationLevel = authentication.TwoFactor
+	userSession.AuthenticationMethods = []string{authentication.TOTP}
+	userSession.FirstFactorAuthnTimestamp = s.mock.Clock.Now().Add(-2 * time.Hour).Unix()
+	userSession.SecondFactorAuthnTimestamp = s.mock.Clock.Now().Add(-time.Hour).Unix()
+	userSession.BindingUserAgent = "fingerprint"
+	userSession.KeepMeLoggedIn = true
+	userSession.Generation = 1
+	s.Require().NoError(s.mock.Ctx.SaveSession(userSession))
+
+	s.mock.UserProviderMock.
+		EXPECT().
+		CheckUserPassword(gomock.Eq("test"), gomock.Eq("hello")).
+		Return(true, nil)
+
+	s.mock.UserProviderMock.
+		EXPECT().
+		GetDetails(gomock.Eq("test")).
+		Return(&authentication.UserDetails{
+			Username: "test",
+			Emails:   []string{"test@example.com"},
+			Groups:   []string{"dev"},
+		}, nil)
+
+	s.mock.StorageProviderMock.
+		EXPECT().
+		AppendAuthenticationLog(gomock.Any()).
+		Return(nil)
+
+	s.mock.StorageProviderMock.
+		EXPECT().
+		LoadSessionGeneration(gomock.Eq("test")).
+		Return(1, nil)
+
+	s.mock.Ctx.Request.SetBodyString(`{
+		"username": "test",
+		"password": "hello"
+	}`)
+	FirstFactorPost(0, false)(s.mock.Ctx)
+
+	assert.Equal(s.T(), 200, s.mock.Ctx.Response.StatusCode())
+
+	// The second factor and the binding of the session are kept.
+	userSession = s.mock.Ctx.GetSession()
+	assert.Equal(s.T(), authentication.TwoFactor, userSession.AuthenticationLevel)
+	assert.Equal(s.T(), []string{authentication.TOTP}, userSession.AuthenticationMethods)
+	assert.Equal(s.T(), s.mock.Clock.Now().Add(-time.Hour).Unix(), userSession.SecondFactorAuthnTimestamp)
+	assert.Equal(s.T(), s.mock.Clock.Now().Unix(), userSession.FirstFactorAuthnTimestamp)
+	assert.Equal(s.T(), "fingerprint", userSession.BindingUserAgent)
+	assert.Equal(s.T(), []string{"dev"}, userSession.Groups)
+}
+
+func (s *FirstFactorSuite) TestShouldResetInvalidatedSessionOnFirstFactor() {
+	userSession := s.mock.Ctx.GetSession()
+	userSession.Username = "test"
+	userSession.AuthenticationLevel = authentication.TwoFactor
+	userSession.AuthenticationMethods = []string{authentication.TOTP}
+	userSession.KeepMeLoggedIn = true
+	s.Require().NoError(s.mock.Ctx.SaveSession(userSession))
+
+	s.mock.UserProviderMock.
+		EXPECT().
+		CheckUserPassword(gomock.Eq("test"), gomock.Eq("hello")).
+		Return(true, nil)
+
+	s.mock.UserProviderMock.
+		EXPECT().
+		GetDetails(gomock.Eq("test")).
+		Return(&authentication.UserDetails{Username: "test"}, nil).
+		Times(2)
+
+	s.mock.StorageProviderMock.
+		EXPECT().
+		AppendAuthenticationLog(gomock.Any()).
+		Return(nil)
+
+	// The sessions of the user have been invalidated since this one was created.
+	s.mock.StorageProviderMock.
+		EXPECT().
+		LoadSessionGeneration(gomock.Eq("test")).
+		Return(1, nil).
+		Times(2)
+
+	s.mock.Ctx.Request.SetBodyString(`{
+		"username": "test",
+		"password": "hello"
+	}`)
+	FirstFactorPost(0, false)(s.mock.Ctx)
+
+	assert.Equal(s.T(), 200, s.mock.Ctx.Response.StatusCode())
+
+	userSession = s.mock.Ctx.GetSession()
+	assert.Equal(s.T(), authentication.OneFactor, userSession.AuthenticationLevel)
+	assert.Empty(s.T(), userSession.AuthenticationMethods)
+	assert.Equal(s.T(), 1, userSession.Generation)
+}
+
 type FirstFactorRedirectionSuite struct {
 	suite.Suite
 
@@ -287,7 +403,7 @@ func (s *FirstFactorRedirectionSuite) SetupTest() {
 		},
 	}
 	s.mock.Ctx.Providers.Authorizer = authorization.NewAuthorizer(
-		s.mock.Ctx.Configuration.AccessControl)
+		s.mock.Ctx.Configuration.AccessControl, &s.mock.Clock)
 
 	s.mock.UserProviderMock.
 		EXPECT().
@@ -307,6 +423,11 @@ func (s *FirstFactorRedirectionSuite) SetupTest() {
 		EXPECT().
 		AppendAuthenticationLog(gomock.Any()).
 		Return(nil)
+
+	s.mock.StorageProviderMock.
+		EXPECT().
+		LoadSessionGeneration(gomock.Eq("test")).
+		Return(0, nil)
 }
 
 func (s *FirstFactorRedirectionSuite) TearDownTest() {
@@ -358,7 +479,7 @@ func (s *FirstFactorRedirectionSuite) TestShouldRedirectToDefaultURLWhenURLIsUns
 func (s *FirstFactorRedirectionSuite) TestShouldReply200WhenNoTargetURLProvidedAndTwoFactorEnabled() {
 	s.mock.Ctx.Providers.Authorizer = authorization.NewAuthorizer(schema.AccessControlConfiguration{
 		DefaultPolicy: "two_factor",
-	})
+	}, &s.mock.Clock)
 	s.mock.Ctx.Request.SetBodyString(`{
 		"username": "test",
 		"password": "hello",
@@ -388,7 +509,7 @@ func (s *FirstFactorRedirectionSuite) TestShouldReply200WhenUnsafeTargetURLProvi
 				Policy:  "two_factor",
 			},
 		},
-	})
+	}, &s.mock.Clock)
 	s.mock.Ctx.Request.SetBodyString(`{
 		"username": "test",
 		"password": "hello",
@@ -55,6 +55,21 @@ func secondFactorTOTPIdentityFinish(ctx *middlewares.AutheliaCtx, username strin
 		return
 	}
 
+	// The other sessions of the user must not stay valid after a device change.
+	userSession := ctx.GetSession()
+
+	err = renewSessionGeneration(ctx, username, &userSession)
+	if err != nil {
+		ctx.Error(fmt.Errorf("Unable to invalidate the sessions of user %s: %s", username, err), unableToRegisterOneTimePasswordMessage)
+		return
+	}
+
+	err = ctx.SaveSession(userSession)
+	if err != nil {
+		ctx.Error(fmt.Errorf("Unable to save the session of user %s: %s", username, err), unableToRegisterOneTimePasswordMessage)
+		return
+	}
+
 	response := TOTPKeyResponse{
 		OTPAuthURL:   key.URL(),
 		Base32Secret: key.Secret(),
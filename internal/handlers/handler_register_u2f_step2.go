@@ -52,5 +52,14 @@ func SecondFactorU2FRegister(ctx *middlewares.AutheliaCtx) {
 		return
 	}
 
+	// The other sessions of the user must not stay valid after a device change, this one is saved by the deferred
+	// function with the new generation.
+	err = renewSessionGeneration(ctx, userSession.Username, &userSession)
+
+	if err != nil {
+		ctx.Error(fmt.Errorf("Unable to invalidate the sessions of user %s: %v", userSession.Username, err), unableToRegisterSecurityKeyMessage)
+		return
+	}
+
 	ctx.ReplyOK()
 }
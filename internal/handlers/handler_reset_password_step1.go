@@ -26,8 +26,9 @@ func identityRetrieverFromStorage(ctx *middlewares.AutheliaCtx) (*session.Identi
 		return nil, fmt.Errorf("User %s has no email address configured", requestBody.Username)
 	}
 
+	// The username of the backend is kept since the password reset invalidates the sessions of the user.
 	return &session.Identity{
-		Username: requestBody.Username,
+		Username: details.Username,
 		Email:    details.Emails[0],
 	}, nil
 }
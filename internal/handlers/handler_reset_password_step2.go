@@ -44,6 +44,14 @@ func ResetPasswordPost(ctx *middlewares.AutheliaCtx) {
 
 	ctx.Logger.Debugf("Password of user %s has been reset", *userSession.PasswordResetUsername)
 
+	// The sessions opened with the previous password must not stay valid.
+	err = renewSessionGeneration(ctx, *userSession.PasswordResetUsername, &userSession)
+
+	if err != nil {
+		ctx.Error(fmt.Errorf("Unable to invalidate the sessions of user %s: %s", *userSession.PasswordResetUsername, err), operationFailedMessage)
+		return
+	}
+
 	// Reset the request.
 	userSession.PasswordResetUsername = nil
 	err = ctx.SaveSession(userSession)
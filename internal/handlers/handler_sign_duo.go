@@ -66,6 +66,8 @@ func SecondFactorDuoPost(duoAPI duo.API) middlewares.RequestHandler {
 		}
 
 		userSession.AuthenticationLevel = authentication.TwoFactor
+		userSession.AddAuthenticationMethod(authentication.Push)
+		userSession.SecondFactorAuthnTimestamp = ctx.Clock.Now().Unix()
 		err = ctx.SaveSession(userSession)
 
 		if err != nil {
@@ -45,6 +45,8 @@ func SecondFactorTOTPPost(totpVerifier TOTPVerifier) middlewares.RequestHandler
 		}
 
 		userSession.AuthenticationLevel = authentication.TwoFactor
+		userSession.AddAuthenticationMethod(authentication.TOTP)
+		userSession.SecondFactorAuthnTimestamp = ctx.Clock.Now().Unix()
 		err = ctx.SaveSession(userSession)
 
 		if err != nil {
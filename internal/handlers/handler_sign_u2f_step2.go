@@ -48,6 +48,8 @@ func SecondFactorU2FSignPost(u2fVerifier U2FVerifier) middlewares.RequestHandler
 		}
 
 		userSession.AuthenticationLevel = authentication.TwoFactor
+		userSession.AddAuthenticationMethod(authentication.U2F)
+		userSession.SecondFactorAuthnTimestamp = ctx.Clock.Now().Unix()
 		err = ctx.SaveSession(userSession)
 
 		if err != nil {
@@ -1,16 +1,46 @@
 package handlers
 
 import (
+	"net/url"
+
+	"github.com/authelia/authelia/internal/authorization"
 	"github.com/authelia/authelia/internal/middlewares"
 )
 
-// StateGet is the handler serving the user state.
+// StateGet is the handler serving the user state. When the portal provides the target URL of the user with the rd
+// parameter, the authentication level is the one of the session for this URL, which is lower when the user must step up
+// to access it, and the second factor methods allowed to access it are provided.
 func StateGet(ctx *middlewares.AutheliaCtx) {
 	userSession := ctx.GetSession()
+	authLevel := userSession.AuthenticationLevel
+
+	var requiredMethods []string
+
+	if rd := ctx.QueryArgs().Peek("rd"); len(rd) != 0 && userSession.Username != "" {
+		targetURL, err := url.ParseRequestURI(string(rd))
+		if err == nil && getProtectedDomain(ctx.Configuration, targetURL) != nil {
+			// The headers of the portal request stand for the ones of the target URL, see Handle1FAResponse.
+			object := authorization.NewObject(*targetURL, ctx.RequestHeaders())
+
+			decision := ctx.Providers.Authorizer.GetDecision(authorization.Subject{
+				Username:   userSession.Username,
+				Groups:     userSession.Groups,
+				Emails:     userSession.Emails,
+				Attributes: userSession.Attributes,
+				IP:         ctx.RemoteIP(),
+				Country:    ctx.RemoteCountry(),
+			}, object)
+
+			authLevel, _ = verifySessionStepUp(ctx, object, decision, &userSession, authLevel)
+			requiredMethods = decision.RequiredMethods()
+		}
+	}
+
 	stateResponse := StateResponse{
 		Username:              userSession.Username,
-		AuthenticationLevel:   userSession.AuthenticationLevel,
+		AuthenticationLevel:   authLevel,
 		DefaultRedirectionURL: ctx.Configuration.DefaultRedirectionURL,
+		RequiredMethods:       requiredMethods,
 	}
 
 	err := ctx.SetJSONBody(stateResponse)
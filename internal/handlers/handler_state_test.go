@@ -3,12 +3,15 @@ package handlers
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/authorization"
+	"github.com/authelia/authelia/internal/configuration/schema"
 	"github.com/authelia/authelia/internal/mocks"
 )
 
@@ -86,6 +89,95 @@ func (s *StateGetSuite) TestShouldReturnAuthenticationLevelFromSession() {
 	assert.Equal(s.T(), expectedBody, actualBody)
 }
 
+func (s *StateGetSuite) TestShouldReturnAuthenticationLevelForTargetURL() {
+	s.mock.Ctx.Configuration.Session.Domain = "example.com"
+	s.mock.Ctx.Providers.Authorizer = authorization.NewAuthorizer(schema.AccessControlConfiguration{
+		DefaultPolicy: "two_factor",
+		Rules: []schema.ACLRule{{
+			Domains:         []string{"admin.example.com"},
+			Policy:          "two_factor",
+			RequiredMethods: []string{"u2f"},
+		}},
+	}, &s.mock.Clock)
+
+	userSession := s.mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	userSession.AuthenticationLevel = authentication.TwoFactor
+	userSession.AuthenticationMethods = []string{authentication.TOTP}
+	err := s.mock.Ctx.SaveSession(userSession)
+	require.NoError(s.T(), err)
+
+	type Response struct {
+		Status string
+		Data   StateResponse
+	}
+
+	getState := func(rd string) StateResponse {
+		s.mock.Ctx.QueryArgs().Set("rd", rd)
+		StateGet(s.mock.Ctx)
+
+		actualBody := Response{}
+
+		err := json.Unmarshal(s.mock.Ctx.Response.Body(), &actualBody)
+		require.NoError(s.T(), err)
+
+		return actualBody.Data
+	}
+
+	// The user must step up with a security key to access the admin console.
+	state := getState("https://admin.example.com")
+	assert.Equal(s.T(), authentication.OneFactor, state.AuthenticationLevel)
+	assert.Equal(s.T(), []string{authentication.U2F}, state.RequiredMethods)
+
+	state = getState("https://public.example.com")
+	assert.Equal(s.T(), authentication.TwoFactor, state.AuthenticationLevel)
+	assert.Nil(s.T(), state.RequiredMethods)
+
+	state = getState("https://example.org")
+	assert.Equal(s.T(), authentication.TwoFactor, state.AuthenticationLevel)
+	assert.Nil(s.T(), state.RequiredMethods)
+
+	assert.Equal(s.T(), authentication.TwoFactor, s.mock.Ctx.GetSession().AuthenticationLevel)
+}
+
+func (s *StateGetSuite) TestShouldReturnAuthenticationLevelForTargetURLRequiringFreshAuthentication() {
+	s.mock.Ctx.Clock = &s.mock.Clock
+	s.mock.Ctx.Configuration.Session.Domain = "example.com"
+	s.mock.Ctx.Providers.Authorizer = authorization.NewAuthorizer(schema.AccessControlConfiguration{
+		DefaultPolicy: "two_factor",
+		Rules: []schema.ACLRule{{
+			Domains:              []string{"payroll.example.com"},
+			Policy:               "two_factor",
+			MaxAuthenticationAge: "10m",
+		}},
+	}, &s.mock.Clock)
+
+	userSession := s.mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	userSession.AuthenticationLevel = authentication.TwoFactor
+	userSession.FirstFactorAuthnTimestamp = s.mock.Clock.Now().Add(-time.Minute).Unix()
+	userSession.SecondFactorAuthnTimestamp = s.mock.Clock.Now().Add(-time.Hour).Unix()
+	err := s.mock.Ctx.SaveSession(userSession)
+	require.NoError(s.T(), err)
+
+	s.mock.Ctx.QueryArgs().Set("rd", "https://payroll.example.com")
+	StateGet(s.mock.Ctx)
+
+	type Response struct {
+		Status string
+		Data   StateResponse
+	}
+
+	actualBody := Response{}
+
+	err = json.Unmarshal(s.mock.Ctx.Response.Body(), &actualBody)
+	require.NoError(s.T(), err)
+
+	// The user must complete the second factor again to access the payroll.
+	assert.Equal(s.T(), authentication.OneFactor, actualBody.Data.AuthenticationLevel)
+	assert.Equal(s.T(), authentication.TwoFactor, s.mock.Ctx.GetSession().AuthenticationLevel)
+}
+
 func TestRunStateGetSuite(t *testing.T) {
 	s := new(StateGetSuite)
 	suite.Run(t, s)
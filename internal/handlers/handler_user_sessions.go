@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/authelia/authelia/internal/middlewares"
+)
+
+// UserSessionsGet lists the active sessions of the user identified by the session.
+func UserSessionsGet(ctx *middlewares.AutheliaCtx) {
+	userSession := ctx.GetSession()
+
+	sessions, err := ctx.Providers.SessionProvider.GetActiveSessions(userSession.Username)
+	if err != nil {
+		ctx.Error(fmt.Errorf("Unable to retrieve the active sessions of user %s: %s", userSession.Username, err), operationFailedMessage)
+		return
+	}
+
+	currentID, err := ctx.Providers.SessionProvider.GetActiveSessionID(ctx.RequestCtx)
+	if err != nil {
+		ctx.Error(fmt.Errorf("Unable to retrieve the current session of user %s: %s", userSession.Username, err), operationFailedMessage)
+		return
+	}
+
+	response := make([]ActiveSessionResponse, 0, len(sessions))
+
+	for _, s := range sessions {
+		response = append(response, ActiveSessionResponse{ActiveSession: s, Current: s.ID == currentID})
+	}
+
+	err = ctx.SetJSONBody(response)
+	if err != nil {
+		ctx.Logger.Errorf("Unable to set active sessions response in body: %s", err)
+	}
+}
+
+// UserSessionDelete revokes one of the active sessions of the user identified by the session.
+func UserSessionDelete(ctx *middlewares.AutheliaCtx) {
+	userSession := ctx.GetSession()
+	id, _ := ctx.UserValue("id").(string)
+
+	revoked, err := ctx.Providers.SessionProvider.RevokeActiveSession(userSession.Username, id)
+	if err != nil {
+		ctx.Error(fmt.Errorf("Unable to revoke session %s of user %s: %s", id, userSession.Username, err), operationFailedMessage)
+		return
+	}
+
+	if !revoked {
+		ctx.Error(fmt.Errorf("User %s has no active session %s", userSession.Username, id), operationFailedMessage)
+		ctx.SetStatusCode(fasthttp.StatusNotFound)
+
+		return
+	}
+
+	ctx.Logger.Debugf("Session %s of user %s has been revoked", id, userSession.Username)
+	ctx.ReplyOK()
+}
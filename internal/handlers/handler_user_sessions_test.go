@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"github.com/valyala/fasthttp"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/mocks"
+)
+
+type UserSessionsSuite struct {
+	suite.Suite
+	mock *mocks.MockAutheliaCtx
+
+	// The public identifier of another session of the user.
+	otherID string
+}
+
+func (s *UserSessionsSuite) SetupTest() {
+	s.mock = mocks.NewMockAutheliaCtx(s.T())
+
+	userSession := s.mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	userSession.AuthenticationLevel = authentication.OneFactor
+	err := s.mock.Ctx.SaveSession(userSession)
+	s.Require().NoError(err)
+
+	// The user is also logged in from another browser.
+	provider := s.mock.Ctx.Providers.SessionProvider
+	otherCtx := &fasthttp.RequestCtx{}
+	otherSession, err := provider.GetSession(otherCtx)
+	s.Require().NoError(err)
+
+	otherSession.Username = testUsername
+	otherSession.AuthenticationLevel = authentication.TwoFactor
+	s.Require().NoError(provider.SaveSession(otherCtx, otherSession))
+	s.Require().NoError(provider.UpdateActiveSession(otherCtx, otherSession, "192.168.0.1", time.Now()))
+
+	s.otherID, err = provider.GetActiveSessionID(otherCtx)
+	s.Require().NoError(err)
+}
+
+func (s *UserSessionsSuite) TearDownTest() {
+	s.mock.Close()
+}
+
+func (s *UserSessionsSuite) getActiveSessions() []ActiveSessionResponse {
+	s.mock.Ctx.Response.Reset()
+	UserSessionsGet(s.mock.Ctx)
+	s.Require().Equal(200, s.mock.Ctx.Response.StatusCode())
+
+	var response struct {
+		Status string                  `json:"status"`
+		Data   []ActiveSessionResponse `json:"data"`
+	}
+
+	s.Require().NoError(json.Unmarshal(s.mock.Ctx.Response.Body(), &response))
+	s.Assert().Equal("OK", response.Status)
+
+	return response.Data
+}
+
+func (s *UserSessionsSuite) TestShouldListActiveSessionsOfUser() {
+	sessions := s.getActiveSessions()
+	s.Require().Len(sessions, 2)
+
+	for _, session := range sessions {
+		if session.ID == s.otherID {
+			s.Assert().False(session.Current)
+			s.Assert().Equal("192.168.0.1", session.IP)
+			s.Assert().Equal(authentication.TwoFactor, session.AuthenticationLevel)
+		} else {
+			s.Assert().True(session.Current)
+			s.Assert().Equal(authentication.OneFactor, session.AuthenticationLevel)
+		}
+	}
+}
+
+func (s *UserSessionsSuite) TestShouldRevokeActiveSessionOfUser() {
+	s.mock.Ctx.SetUserValue("id", s.otherID)
+	UserSessionDelete(s.mock.Ctx)
+
+	s.Assert().Equal(200, s.mock.Ctx.Response.StatusCode())
+	s.Assert().Equal("{\"status\":\"OK\"}", string(s.mock.Ctx.Response.Body()))
+
+	sessions := s.getActiveSessions()
+	s.Require().Len(sessions, 1)
+	s.Assert().True(sessions[0].Current)
+}
+
+func (s *UserSessionsSuite) TestShouldNotRevokeUnknownSession() {
+	s.mock.Ctx.SetUserValue("id", "unknown")
+	UserSessionDelete(s.mock.Ctx)
+
+	s.Assert().Equal(404, s.mock.Ctx.Response.StatusCode())
+	s.Assert().Equal("{\"status\":\"KO\",\"message\":\"Operation failed.\"}", string(s.mock.Ctx.Response.Body()))
+	assert.Equal(s.T(), "User john has no active session unknown", s.mock.Hook.LastEntry().Message)
+
+	s.Assert().Len(s.getActiveSessions(), 2)
+}
+
+func TestRunUserSessionsSuite(t *testing.T) {
+	s := new(UserSessionsSuite)
+	suite.Run(t, s)
+}
+
+func TestShouldNotRevokeActiveSessionOfOtherUsers(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	userSession := mock.Ctx.GetSession()
+	userSession.Username = "harry"
+	userSession.AuthenticationLevel = authentication.OneFactor
+	require.NoError(t, mock.Ctx.SaveSession(userSession))
+
+	otherCtx := &fasthttp.RequestCtx{}
+	otherSession, err := mock.Ctx.Providers.SessionProvider.GetSession(otherCtx)
+	require.NoError(t, err)
+
+	otherSession.Username = testUsername
+	require.NoError(t, mock.Ctx.Providers.SessionProvider.SaveSession(otherCtx, otherSession))
+	require.NoError(t, mock.Ctx.Providers.SessionProvider.UpdateActiveSession(otherCtx, otherSession, "", time.Now()))
+
+	otherID, err := mock.Ctx.Providers.SessionProvider.GetActiveSessionID(otherCtx)
+	require.NoError(t, err)
+
+	mock.Ctx.SetUserValue("id", otherID)
+	UserSessionDelete(mock.Ctx)
+
+	assert.Equal(t, 404, mock.Ctx.Response.StatusCode())
+
+	sessions, err := mock.Ctx.Providers.SessionProvider.GetActiveSessions(testUsername)
+	require.NoError(t, err)
+	assert.Len(t, sessions, 1)
+}
@@ -2,12 +2,14 @@ package handlers
 
 import (
 	"encoding/base64"
+	"errors"
 	"fmt"
-	"net"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/sirupsen/logrus"
 	"github.com/valyala/fasthttp"
 
 	"github.com/authelia/authelia/internal/authentication"
@@ -22,6 +24,43 @@ func isURLUnderProtectedDomain(url *url.URL, domain string) bool {
 	return strings.HasSuffix(url.Hostname(), domain)
 }
 
+// getProtectedDomain retrieve the configuration of the most specific protected root domain the URL is under, nil if
+// it is not under any of them. The domain of the session is the only protected domain if no domains are configured.
+func getProtectedDomain(configuration schema.Configuration, url *url.URL) *schema.DomainConfiguration {
+	if url == nil {
+		return nil
+	}
+
+	if len(configuration.Domains) == 0 {
+		if isURLUnderProtectedDomain(url, configuration.Session.Domain) {
+			return &schema.DomainConfiguration{Domain: configuration.Session.Domain}
+		}
+
+		return nil
+	}
+
+	names := make([]string, 0, len(configuration.Domains))
+	for _, domain := range configuration.Domains {
+		names = append(names, domain.Domain)
+	}
+
+	if i := utils.GetMostSpecificDomain(url.Hostname(), names); i != -1 {
+		return &configuration.Domains[i]
+	}
+
+	return nil
+}
+
+// getPortalURL retrieve the URL of the login portal of the protected domain of the target URL, or the one provided by
+// the proxy with the rd parameter if the domain has none.
+func getPortalURL(ctx *middlewares.AutheliaCtx, targetURL *url.URL) string {
+	if domain := getProtectedDomain(ctx.Configuration, targetURL); domain != nil && domain.PortalURL != "" {
+		return domain.PortalURL
+	}
+
+	return string(ctx.QueryArgs().Peek("rd"))
+}
+
 func isSchemeHTTPS(url *url.URL) bool {
 	return url.Scheme == "https"
 }
@@ -95,26 +134,32 @@ func parseBasicAuth(auth string) (username, password string, err error) {
 	return cs[:s], cs[s+1:], nil
 }
 
-// isTargetURLAuthorized check whether the given user is authorized to access the resource.
-func isTargetURLAuthorized(authorizer *authorization.Authorizer, targetURL url.URL,
-	username string, userGroups []string, clientIP net.IP, authLevel authentication.Level) authorizationMatching {
-	level := authorizer.GetRequiredLevel(authorization.Subject{
-		Username: username,
-		Groups:   userGroups,
-		IP:       clientIP,
-	}, targetURL)
+// isTargetURLAuthorized check whether the given user is authorized to access the resource given the decision of the
+// access control.
+func isTargetURLAuthorized(decision authorization.Decision, username string,
+	authLevel authentication.Level) authorizationMatching {
+	level := decision.Level
 
 	switch {
 	case level == authorization.Bypass:
 		return Authorized
-	case level == authorization.Denied && username != "":
+	case level == authorization.Denied:
+		switch decision.DenyBehavior() {
+		case schema.DenyBehaviorForbidden:
+			return Forbidden
+		case schema.DenyBehaviorAccessDenied:
+			return AccessDenied
+		}
+
 		// If the user is not anonymous, it means that we went through
 		// all the rules related to that user and knowing who he is we can
 		// deduce the access is forbidden
 		// For anonymous users though, we cannot be sure that she
 		// could not be granted the rights to access the resource. Consequently
 		// for anonymous users we send Unauthorized instead of Forbidden
-		return Forbidden
+		if username != "" {
+			return Forbidden
+		}
 	case level == authorization.OneFactor && authLevel >= authentication.OneFactor,
 		level == authorization.TwoFactor && authLevel >= authentication.TwoFactor:
 		return Authorized
@@ -125,32 +170,41 @@ func isTargetURLAuthorized(authorizer *authorization.Authorizer, targetURL url.U
 
 // verifyBasicAuth verify that the provided username and password are correct and
 // that the user is authorized to target the resource.
-func verifyBasicAuth(auth []byte, targetURL url.URL, ctx *middlewares.AutheliaCtx) (username, name string, groups, emails []string, authLevel authentication.Level, err error) { //nolint:unparam
+func verifyBasicAuth(auth []byte, targetURL url.URL, ctx *middlewares.AutheliaCtx) (user verifiedUser, err error) { //nolint:unparam
 	username, password, err := parseBasicAuth(string(auth))
 
 	if err != nil {
-		return "", "", nil, nil, authentication.NotAuthenticated, fmt.Errorf("Unable to parse content of %s header: %s", AuthorizationHeader, err)
+		return verifiedUser{}, fmt.Errorf("Unable to parse content of %s header: %s", AuthorizationHeader, err)
 	}
 
 	authenticated, err := ctx.Providers.UserProvider.CheckUserPassword(username, password)
 
 	if err != nil {
-		return "", "", nil, nil, authentication.NotAuthenticated, fmt.Errorf("Unable to check credentials extracted from %s header: %s", AuthorizationHeader, err)
+		return verifiedUser{}, fmt.Errorf("Unable to check credentials extracted from %s header: %s", AuthorizationHeader, err)
 	}
 
 	// If the user is not correctly authenticated, send a 401.
 	if !authenticated {
 		// Request Basic Authentication otherwise
-		return "", "", nil, nil, authentication.NotAuthenticated, fmt.Errorf("User %s is not authenticated", username)
+		return verifiedUser{}, fmt.Errorf("User %s is not authenticated", username)
 	}
 
 	details, err := ctx.Providers.UserProvider.GetDetails(username)
 
 	if err != nil {
-		return "", "", nil, nil, authentication.NotAuthenticated, fmt.Errorf("Unable to retrieve details of user %s: %s", username, err)
+		return verifiedUser{}, fmt.Errorf("Unable to retrieve details of user %s: %s", username, err)
 	}
 
-	return username, details.DisplayName, details.Groups, details.Emails, authentication.OneFactor, nil
+	return verifiedUser{
+		UserDetails: authentication.UserDetails{
+			Username:    username,
+			DisplayName: details.DisplayName,
+			Emails:      details.Emails,
+			Groups:      details.Groups,
+			Attributes:  details.Attributes,
+		},
+		AuthenticationLevel: authentication.OneFactor,
+	}, nil
 }
 
 // setForwardedHeaders set the forwarded User, Groups, Name and Email headers.
@@ -168,9 +222,17 @@ func setForwardedHeaders(headers *fasthttp.ResponseHeader, username, name string
 	}
 }
 
-// hasUserBeenInactiveTooLong checks whether the user has been inactive for too long.
-func hasUserBeenInactiveTooLong(ctx *middlewares.AutheliaCtx) (bool, error) { //nolint:unparam
+// hasUserBeenInactiveTooLong checks whether the user has been inactive for too long, the inactivity of the protected
+// domain of the target URL applies if configured.
+func hasUserBeenInactiveTooLong(ctx *middlewares.AutheliaCtx, targetURL *url.URL) (bool, error) { //nolint:unparam
 	maxInactivityPeriod := int64(ctx.Providers.SessionProvider.Inactivity.Seconds())
+
+	if domain := getProtectedDomain(ctx.Configuration, targetURL); domain != nil && domain.Inactivity != "" {
+		// Skip Error Check since validator checks it.
+		inactivity, _ := utils.ParseDurationString(domain.Inactivity)
+		maxInactivityPeriod = int64(inactivity.Seconds())
+	}
+
 	if maxInactivityPeriod == 0 {
 		return false, nil
 	}
@@ -190,28 +252,50 @@ func hasUserBeenInactiveTooLong(ctx *middlewares.AutheliaCtx) (bool, error) { //
 
 // verifySessionCookie verifies if a user is identified by a cookie.
 func verifySessionCookie(ctx *middlewares.AutheliaCtx, targetURL *url.URL, userSession *session.UserSession, refreshProfile bool,
-	refreshProfileInterval time.Duration) (username, name string, groups, emails []string, authLevel authentication.Level, err error) {
+	refreshProfileInterval time.Duration) (user verifiedUser, err error) {
+	// The session has been degraded or destroyed when it was loaded for another client than the one it is bound to.
+	if ctx.IsSessionBindingMismatched() {
+		return newVerifiedUser(userSession, authentication.NotAuthenticated), fmt.Errorf("User %s %w", userSession.Username, errSessionBindingMismatch)
+	}
+
 	// No username in the session means the user is anonymous.
 	isUserAnonymous := userSession.Username == ""
 
 	if isUserAnonymous && userSession.AuthenticationLevel != authentication.NotAuthenticated {
-		return "", "", nil, nil, authentication.NotAuthenticated, fmt.Errorf("An anonymous user cannot be authenticated. That might be the sign of a compromise")
+		return verifiedUser{}, fmt.Errorf("An anonymous user cannot be authenticated. That might be the sign of a compromise")
 	}
 
 	if !userSession.KeepMeLoggedIn && !isUserAnonymous {
-		inactiveLongEnough, err := hasUserBeenInactiveTooLong(ctx)
+		inactiveLongEnough, err := hasUserBeenInactiveTooLong(ctx, targetURL)
 		if err != nil {
-			return "", "", nil, nil, authentication.NotAuthenticated, fmt.Errorf("Unable to check if user has been inactive for a long time: %s", err)
+			return verifiedUser{}, fmt.Errorf("Unable to check if user has been inactive for a long time: %s", err)
 		}
 
 		if inactiveLongEnough {
 			// Destroy the session a new one will be regenerated on next request.
 			err := ctx.Providers.SessionProvider.DestroySession(ctx.RequestCtx)
 			if err != nil {
-				return "", "", nil, nil, authentication.NotAuthenticated, fmt.Errorf("Unable to destroy user session after long inactivity: %s", err)
+				return verifiedUser{}, fmt.Errorf("Unable to destroy user session after long inactivity: %s", err)
 			}
 
-			return userSession.Username, userSession.DisplayName, userSession.Groups, userSession.Emails, authentication.NotAuthenticated, fmt.Errorf("User %s has been inactive for too long", userSession.Username)
+			return newVerifiedUser(userSession, authentication.NotAuthenticated), fmt.Errorf("User %s %w", userSession.Username, errSessionInactive)
+		}
+	}
+
+	if !isUserAnonymous {
+		generation, err := ctx.Providers.StorageProvider.LoadSessionGeneration(userSession.Username)
+		if err != nil {
+			return verifiedUser{}, fmt.Errorf("Unable to load the session generation of user %s: %s", userSession.Username, err)
+		}
+
+		if userSession.Generation < generation {
+			// The sessions of the user have been invalidated since this one was created.
+			err := ctx.Providers.SessionProvider.DestroySession(ctx.RequestCtx)
+			if err != nil {
+				return verifiedUser{}, fmt.Errorf("Unable to destroy user session of a previous generation: %s", err)
+			}
+
+			return newVerifiedUser(userSession, authentication.NotAuthenticated), fmt.Errorf("User %s %w", userSession.Username, errSessionStale)
 		}
 	}
 
@@ -223,20 +307,121 @@ func verifySessionCookie(ctx *middlewares.AutheliaCtx, targetURL *url.URL, userS
 				ctx.Logger.Error(fmt.Errorf("Unable to destroy user session after provider refresh didn't find the user: %s", err))
 			}
 
-			return userSession.Username, userSession.DisplayName, userSession.Groups, userSession.Emails, authentication.NotAuthenticated, err
+			return newVerifiedUser(userSession, authentication.NotAuthenticated), err
 		}
 
 		ctx.Logger.Warnf("Error occurred while attempting to update user details from LDAP: %s", err)
 	}
 
-	return userSession.Username, userSession.DisplayName, userSession.Groups, userSession.Emails, userSession.AuthenticationLevel, nil
+	return newVerifiedUser(userSession, userSession.AuthenticationLevel), nil
 }
 
-func handleUnauthorized(ctx *middlewares.AutheliaCtx, targetURL fmt.Stringer, username string) {
+// newVerifiedUser returns the user identified by a session with the given authentication level.
+func newVerifiedUser(userSession *session.UserSession, authLevel authentication.Level) verifiedUser {
+	return verifiedUser{
+		UserDetails: authentication.UserDetails{
+			Username:    userSession.Username,
+			DisplayName: userSession.DisplayName,
+			Emails:      userSession.Emails,
+			Groups:      userSession.Groups,
+			Attributes:  userSession.Attributes,
+		},
+		AuthenticationLevel: authLevel,
+	}
+}
+
+// verifySessionStepUp returns the authentication level of the session for the target URL once the required methods
+// and the max authentication age of the rule applied are checked, with the reason of the step up when it is lowered.
+func verifySessionStepUp(ctx *middlewares.AutheliaCtx, object authorization.Object, decision authorization.Decision,
+	userSession *session.UserSession, authLevel authentication.Level) (authentication.Level, string) {
+	reason := ""
+
+	level := verifySessionRequiredMethods(ctx, object, decision, userSession, authLevel)
+	if level != authLevel {
+		reason = reasonNeedsRequiredMethod
+	}
+
+	if freshLevel := verifySessionAuthenticationAge(ctx, object, decision, userSession, level); freshLevel != level {
+		level, reason = freshLevel, reasonAuthenticationTooOld
+	}
+
+	return level, reason
+}
+
+// verifySessionRequiredMethods returns the authentication level of the session for the target URL, lowered to one
+// factor when the rule matching it requires a second factor method the user has not used yet so that the user is asked
+// to step up. The session itself is left unchanged since the other resources don't require the same methods.
+func verifySessionRequiredMethods(ctx *middlewares.AutheliaCtx, object authorization.Object,
+	decision authorization.Decision, userSession *session.UserSession,
+	authLevel authentication.Level) authentication.Level {
+	if authLevel != authentication.TwoFactor {
+		return authLevel
+	}
+
+	requiredMethods := decision.RequiredMethods()
+
+	if len(requiredMethods) == 0 {
+		return authLevel
+	}
+
+	for _, method := range requiredMethods {
+		if utils.IsStringInSlice(method, userSession.AuthenticationMethods) {
+			return authLevel
+		}
+	}
+
+	ctx.Logger.Debugf("User %s must authenticate with one of the methods %s to access %s",
+		userSession.Username, strings.Join(requiredMethods, ", "), object.String())
+
+	return authentication.OneFactor
+}
+
+// verifySessionAuthenticationAge returns the authentication level of the session for the target URL, which is lower
+// when the rule matching it requires a fresher authentication than the last one completed by the user, so that the
+// user is asked to authenticate again. The session is left unchanged since the other resources don't require it.
+func verifySessionAuthenticationAge(ctx *middlewares.AutheliaCtx, object authorization.Object,
+	decision authorization.Decision, userSession *session.UserSession,
+	authLevel authentication.Level) authentication.Level {
+	if authLevel == authentication.NotAuthenticated {
+		return authLevel
+	}
+
+	maxAge := decision.MaxAuthenticationAge()
+	if maxAge == 0 {
+		return authLevel
+	}
+
+	now := ctx.Clock.Now().Unix()
+	maxAgeSeconds := int64(maxAge.Seconds())
+	effectiveLevel := authLevel
+
+	lastAuthnTimestamp := userSession.FirstFactorAuthnTimestamp
+	if userSession.SecondFactorAuthnTimestamp > lastAuthnTimestamp {
+		lastAuthnTimestamp = userSession.SecondFactorAuthnTimestamp
+	}
+
+	switch {
+	case now-lastAuthnTimestamp > maxAgeSeconds:
+		effectiveLevel = authentication.NotAuthenticated
+	case authLevel == authentication.TwoFactor && now-userSession.SecondFactorAuthnTimestamp > maxAgeSeconds &&
+		decision.Level == authorization.TwoFactor:
+		effectiveLevel = authentication.OneFactor
+	}
+
+	if effectiveLevel != authLevel {
+		ctx.Logger.Debugf("Last authentication of user %s is older than %s required to access %s",
+			userSession.Username, maxAge, object.String())
+	}
+
+	return effectiveLevel
+}
+
+func handleUnauthorized(ctx *middlewares.AutheliaCtx, targetURL *url.URL, username string) {
 	// Kubernetes ingress controller and Traefik use the rd parameter of the verify
-	// endpoint to provide the URL of the login portal. The target URL of the user
-	// is computed from X-Forwarded-* headers or X-Original-URL.
-	rd := string(ctx.QueryArgs().Peek("rd"))
+	// endpoint to provide the URL of the login portal unless the protected domain
+	// has its own. The target URL of the user is computed from X-Forwarded-* headers
+	// or X-Original-URL.
+	rd := getPortalURL(ctx, targetURL)
 	if rd != "" {
 		redirectionURL := fmt.Sprintf("%s?rd=%s", rd, url.QueryEscape(targetURL.String()))
 		if strings.Contains(redirectionURL, "/%23/") {
@@ -252,6 +437,92 @@ func handleUnauthorized(ctx *middlewares.AutheliaCtx, targetURL fmt.Stringer, us
 	}
 }
 
+// handleAccessDenied redirects the user to the access denied page of the login portal, the access is simply forbidden
+// if there is no login portal to redirect to.
+func handleAccessDenied(ctx *middlewares.AutheliaCtx, targetURL *url.URL, username string) {
+	rd := getPortalURL(ctx, targetURL)
+	if rd == "" {
+		ctx.Logger.Infof("Access to %s is forbidden to user %s", targetURL.String(), username)
+		ctx.ReplyForbidden()
+
+		return
+	}
+
+	redirectionURL := fmt.Sprintf("%s%s?rd=%s", strings.TrimSuffix(rd, "/"), accessDeniedPath,
+		url.QueryEscape(targetURL.String()))
+
+	ctx.Logger.Infof("Access to %s is forbidden to user %s, redirecting to %s", targetURL.String(), username, redirectionURL)
+	ctx.Redirect(redirectionURL, 302)
+	ctx.SetBodyString(fmt.Sprintf("Found. Redirecting to %s", redirectionURL))
+}
+
+// getVerifyErrorReason returns the reason explaining a failed verification of the user.
+func getVerifyErrorReason(err error) string {
+	switch {
+	case errors.Is(err, errSessionInactive):
+		return reasonInactive
+	case errors.Is(err, errSessionStale):
+		return reasonRevoked
+	case errors.Is(err, errSessionBindingMismatch):
+		return reasonBindingMismatch
+	}
+
+	return reasonUnauthorized
+}
+
+// explainVerifyDecision logs the rule, the levels and the reason of a decision of the verify endpoint and adds them
+// to the response headers when the client belongs to the explain networks.
+func explainVerifyDecision(ctx *middlewares.AutheliaCtx, object authorization.Object, subject authorization.Subject,
+	decision *authorization.Decision, authLevel authentication.Level, reason string) {
+	explain := ctx.IsRemoteIPInExplainNetworks()
+	if !explain && !ctx.Logger.Logger.IsLevelEnabled(logrus.DebugLevel) {
+		return
+	}
+
+	// The decision is only looked up here when the verification failed before the access control was checked.
+	if decision == nil {
+		lookedUp := ctx.Providers.Authorizer.GetDecision(subject, object)
+		decision = &lookedUp
+	}
+
+	ruleName := defaultPolicyRule
+
+	if decision.Rule != nil {
+		ruleName = decision.Rule.Name
+		if ruleName == "" {
+			ruleName = strconv.Itoa(decision.Index)
+		}
+	}
+
+	requiredLevel := authorization.LevelToPolicy(decision.Level)
+	currentLevel := authenticationLevelToString(authLevel)
+
+	ctx.Logger.WithFields(logrus.Fields{
+		"rule":           ruleName,
+		"required_level": requiredLevel,
+		"current_level":  currentLevel,
+		"reason":         reason,
+	}).Debugf("Access to %s has been decided for user %s", object.String(), subject.Username)
+
+	if explain {
+		ctx.Response.Header.Set(explainRuleHeader, ruleName)
+		ctx.Response.Header.Set(explainRequiredLevelHeader, requiredLevel)
+		ctx.Response.Header.Set(explainCurrentLevelHeader, currentLevel)
+		ctx.Response.Header.Set(explainReasonHeader, reason)
+	}
+}
+
+func authenticationLevelToString(level authentication.Level) string {
+	switch level {
+	case authentication.OneFactor:
+		return "one_factor"
+	case authentication.TwoFactor:
+		return "two_factor"
+	}
+
+	return "not_authenticated"
+}
+
 func updateActivityTimestamp(ctx *middlewares.AutheliaCtx, isBasicAuth bool, username string) error {
 	if isBasicAuth || username == "" {
 		return nil
@@ -309,6 +580,13 @@ func generateVerifySessionHasUpToDateProfileTraceLogs(ctx *middlewares.AutheliaC
 		ctx.Logger.Tracef("No updated emails detected for %s", userSession.Username)
 	}
 
+	// Check Attributes.
+	if utils.IsStringSliceMapsDifferent(userSession.Attributes, details.Attributes) {
+		ctx.Logger.Tracef("Updated attributes detected for %s. Added or updated: %v.", userSession.Username, details.Attributes)
+	} else {
+		ctx.Logger.Tracef("No updated attributes detected for %s", userSession.Username)
+	}
+
 	// Check Name.
 	if nameDelta {
 		ctx.Logger.Tracef("Updated display name detected for %s. Added: %s. Removed: %s.", userSession.Username, details.DisplayName, userSession.DisplayName)
@@ -324,7 +602,7 @@ func verifySessionHasUpToDateProfile(ctx *middlewares.AutheliaCtx, targetURL *ur
 	ctx.Logger.Tracef("Checking if we need check the authentication backend for an updated profile for %s.", userSession.Username)
 
 	if refreshProfile && userSession.Username != "" && targetURL != nil &&
-		ctx.Providers.Authorizer.IsURLMatchingRuleWithGroupSubjects(*targetURL) &&
+		ctx.Providers.Authorizer.IsURLMatchingRuleWithProfileSubjects(*targetURL) &&
 		(refreshProfileInterval == schema.RefreshIntervalAlways || userSession.RefreshTTL.Before(ctx.Clock.Now())) {
 		ctx.Logger.Debugf("Checking the authentication backend for an updated profile for user %s", userSession.Username)
 		details, err := ctx.Providers.UserProvider.GetDetails(userSession.Username)
@@ -335,9 +613,10 @@ func verifySessionHasUpToDateProfile(ctx *middlewares.AutheliaCtx, targetURL *ur
 
 		emailsDiff := utils.IsStringSlicesDifferent(userSession.Emails, details.Emails)
 		groupsDiff := utils.IsStringSlicesDifferent(userSession.Groups, details.Groups)
+		attributesDiff := utils.IsStringSliceMapsDifferent(userSession.Attributes, details.Attributes)
 		nameDiff := userSession.DisplayName != details.DisplayName
 
-		if !groupsDiff && !emailsDiff && !nameDiff {
+		if !groupsDiff && !emailsDiff && !attributesDiff && !nameDiff {
 			ctx.Logger.Tracef("Updated profile not detected for %s.", userSession.Username)
 			// Only update TTL if the user has a interval set.
 			// We get to this check when there were no changes.
@@ -355,6 +634,7 @@ func verifySessionHasUpToDateProfile(ctx *middlewares.AutheliaCtx, targetURL *ur
 			}
 			userSession.Emails = details.Emails
 			userSession.Groups = details.Groups
+			userSession.Attributes = details.Attributes
 			userSession.DisplayName = details.DisplayName
 
 			// Only update TTL if the user has a interval set.
@@ -408,35 +688,33 @@ func VerifyGet(cfg schema.AuthenticationBackendConfiguration) middlewares.Reques
 			return
 		}
 
-		if !isURLUnderProtectedDomain(targetURL, ctx.Configuration.Session.Domain) {
-			ctx.Logger.Error(fmt.Errorf("The target URL %s is not under the protected domain %s",
-				targetURL.String(), ctx.Configuration.Session.Domain))
+		if getProtectedDomain(ctx.Configuration, targetURL) == nil {
+			ctx.Logger.Error(fmt.Errorf("The target URL %s is not under any protected domain", targetURL.String()))
 			ctx.ReplyUnauthorized()
 
 			return
 		}
 
-		var username, name string
-
-		var groups, emails []string
+		// The headers of the request are only collected once since every rule lookup needs them.
+		object := authorization.NewObject(*targetURL, ctx.RequestHeaders())
 
-		var authLevel authentication.Level
+		var user verifiedUser
 
 		proxyAuthorization := ctx.Request.Header.Peek(AuthorizationHeader)
 		isBasicAuth := proxyAuthorization != nil
 		userSession := ctx.GetSession()
 
 		if isBasicAuth {
-			username, name, groups, emails, authLevel, err = verifyBasicAuth(proxyAuthorization, *targetURL, ctx)
+			user, err = verifyBasicAuth(proxyAuthorization, *targetURL, ctx)
 		} else {
-			username, name, groups, emails, authLevel, err = verifySessionCookie(ctx, targetURL, &userSession,
+			user, err = verifySessionCookie(ctx, targetURL, &userSession,
 				refreshProfile, refreshProfileInterval)
 
 			sessionUsername := ctx.Request.Header.Peek(SessionUsernameHeader)
-			if sessionUsername != nil && !strings.EqualFold(string(sessionUsername), username) {
+			if sessionUsername != nil && !strings.EqualFold(string(sessionUsername), user.Username) {
 				ctx.Logger.Warnf(
 					"Could not match user %s to their %s header with a value of %s when visiting %s, possible cookie hijack or attempt to bypass security detected destroying the session and sending 401 response",
-					username, SessionUsernameHeader, sessionUsername, targetURL.String())
+					user.Username, SessionUsernameHeader, sessionUsername, targetURL.String())
 
 				err := ctx.Providers.SessionProvider.DestroySession(ctx.RequestCtx)
 				if err != nil {
@@ -451,32 +729,75 @@ func VerifyGet(cfg schema.AuthenticationBackendConfiguration) middlewares.Reques
 			}
 		}
 
+		username := user.Username
+
+		subject := authorization.Subject{
+			Username:   username,
+			Groups:     user.Groups,
+			Emails:     user.Emails,
+			Attributes: user.Attributes,
+			IP:         ctx.RemoteIP(),
+			Country:    ctx.RemoteCountry(),
+		}
+
 		if err != nil {
 			ctx.Logger.Error(fmt.Sprintf("Error caught when verifying user authorization: %s", err))
 
-			if err := updateActivityTimestamp(ctx, isBasicAuth, username); err != nil {
-				ctx.Error(fmt.Errorf("Unable to update last activity: %s", err), operationFailedMessage)
-				return
+			// The activity of another client than the one the session is bound to must not keep it alive.
+			if !errors.Is(err, errSessionBindingMismatch) {
+				if err := updateActivityTimestamp(ctx, isBasicAuth, username); err != nil {
+					ctx.Error(fmt.Errorf("Unable to update last activity: %s", err), operationFailedMessage)
+					return
+				}
 			}
 
 			handleUnauthorized(ctx, targetURL, username)
+			explainVerifyDecision(ctx, object, subject, nil, authentication.NotAuthenticated, getVerifyErrorReason(err))
 
 			return
 		}
 
-		authorization := isTargetURLAuthorized(ctx.Providers.Authorizer, *targetURL, username,
-			groups, ctx.RemoteIP(), authLevel)
+		decision := ctx.Providers.Authorizer.GetDecision(subject, object)
+		authLevel, stepUpReason := user.AuthenticationLevel, ""
+
+		if !isBasicAuth {
+			authLevel, stepUpReason = verifySessionStepUp(ctx, object, decision, &userSession, authLevel)
+		}
+
+		authorization := isTargetURLAuthorized(decision, username, authLevel)
+
+		var reason string
 
 		switch authorization {
 		case Forbidden:
 			ctx.Logger.Infof("Access to %s is forbidden to user %s", targetURL.String(), username)
 			ctx.ReplyForbidden()
+
+			reason = reasonForbidden
+		case AccessDenied:
+			handleAccessDenied(ctx, targetURL, username)
+
+			reason = reasonForbidden
 		case NotAuthorized:
 			handleUnauthorized(ctx, targetURL, username)
+
+			switch {
+			case stepUpReason != "":
+				reason = stepUpReason
+			case authLevel == authentication.NotAuthenticated:
+				reason = reasonNeedsAuthentication
+			default:
+				reason = reasonNeedsTwoFactor
+			}
 		case Authorized:
-			setForwardedHeaders(&ctx.Response.Header, username, name, groups, emails)
+			setForwardedHeaders(&ctx.Response.Header, username, user.DisplayName, user.Groups, user.Emails)
+
+			reason = reasonAuthorized
 		}
 
+		// The explanation is given once the response is built since replying an error resets the headers.
+		explainVerifyDecision(ctx, object, subject, &decision, authLevel, reason)
+
 		if err := updateActivityTimestamp(ctx, isBasicAuth, username); err != nil {
 			ctx.Error(fmt.Errorf("Unable to update last activity: %s", err), operationFailedMessage)
 		}
@@ -15,6 +15,7 @@ import (
 	"github.com/authelia/authelia/internal/authentication"
 	"github.com/authelia/authelia/internal/authorization"
 	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/middlewares"
 	"github.com/authelia/authelia/internal/mocks"
 	"github.com/authelia/authelia/internal/session"
 	"github.com/authelia/authelia/internal/utils"
@@ -181,19 +182,62 @@ func TestShouldCheckAuthorizationMatching(t *testing.T) {
 				Domains: []string{"test.example.com"},
 				Policy:  rule.Policy,
 			}},
-		})
+		}, utils.RealClock{})
 
 		username := ""
 		if rule.AuthLevel > authentication.NotAuthenticated {
 			username = testUsername
 		}
 
-		matching := isTargetURLAuthorized(authorizer, *url, username, []string{}, net.ParseIP("127.0.0.1"), rule.AuthLevel)
+		matching := isTargetURLAuthorized(authorizer.GetDecision(authorization.Subject{
+			Username: username,
+			Groups:   []string{},
+			IP:       net.ParseIP("127.0.0.1"),
+		}, authorization.NewObject(*url, nil)), username, rule.AuthLevel)
 		assert.Equal(t, rule.ExpectedMatching, matching, "policy=%s, authLevel=%v, expected=%v, actual=%v",
 			rule.Policy, rule.AuthLevel, rule.ExpectedMatching, matching)
 	}
 }
 
+func TestShouldCheckAuthorizationMatchingWithDenyBehavior(t *testing.T) {
+	testCases := []struct {
+		denyBehavior     string
+		authLevel        authentication.Level
+		expectedMatching authorizationMatching
+	}{
+		{"redirect", authentication.NotAuthenticated, NotAuthorized},
+		{"redirect", authentication.OneFactor, Forbidden},
+		{"forbidden", authentication.NotAuthenticated, Forbidden},
+		{"forbidden", authentication.TwoFactor, Forbidden},
+		{"access_denied", authentication.NotAuthenticated, AccessDenied},
+		{"access_denied", authentication.OneFactor, AccessDenied},
+	}
+
+	url, _ := url.ParseRequestURI("https://test.example.com")
+
+	for _, tc := range testCases {
+		authorizer := authorization.NewAuthorizer(schema.AccessControlConfiguration{
+			DefaultPolicy: "deny",
+			Rules: []schema.ACLRule{{
+				Domains:      []string{"test.example.com"},
+				Policy:       "deny",
+				DenyBehavior: tc.denyBehavior,
+			}},
+		}, utils.RealClock{})
+
+		username := ""
+		if tc.authLevel > authentication.NotAuthenticated {
+			username = testUsername
+		}
+
+		matching := isTargetURLAuthorized(authorizer.GetDecision(authorization.Subject{
+			Username: username,
+			IP:       net.ParseIP("127.0.0.1"),
+		}, authorization.NewObject(*url, nil)), username, tc.authLevel)
+		assert.Equal(t, tc.expectedMatching, matching, "denyBehavior=%s, authLevel=%v", tc.denyBehavior, tc.authLevel)
+	}
+}
+
 // Test verifyBasicAuth.
 func TestShouldVerifyWrongCredentials(t *testing.T) {
 	mock := mocks.NewMockAutheliaCtx(t)
@@ -204,7 +248,7 @@ func TestShouldVerifyWrongCredentials(t *testing.T) {
 		Return(false, nil)
 
 	url, _ := url.ParseRequestURI("https://test.example.com")
-	_, _, _, _, _, err := verifyBasicAuth([]byte("Basic am9objpwYXNzd29yZA=="), *url, mock.Ctx)
+	_, err := verifyBasicAuth([]byte("Basic am9objpwYXNzd29yZA=="), *url, mock.Ctx)
 
 	assert.Error(t, err)
 }
@@ -425,6 +469,8 @@ func TestShouldNotCrashOnEmptyEmail(t *testing.T) {
 	err := mock.Ctx.SaveSession(userSession)
 	require.NoError(t, err)
 
+	mock.StorageProviderMock.EXPECT().LoadSessionGeneration(testUsername).Return(0, nil)
+
 	mock.Ctx.Request.Header.Set("X-Original-URL", "https://bypass.example.com")
 
 	VerifyGet(verifyGetCfg)(mock.Ctx)
@@ -480,6 +526,10 @@ func TestShouldVerifyAuthorizationsUsingSessionCookie(t *testing.T) {
 			userSession.Emails = testCase.Emails
 			userSession.AuthenticationLevel = testCase.AuthenticationLevel
 
+			if testCase.Username != "" {
+				mock.StorageProviderMock.EXPECT().LoadSessionGeneration(testCase.Username).Return(0, nil)
+			}
+
 			err := mock.Ctx.SaveSession(userSession)
 			require.NoError(t, err)
 
@@ -511,7 +561,7 @@ func TestShouldDestroySessionWhenInactiveForTooLong(t *testing.T) {
 
 	mock.Ctx.Configuration.Session.Inactivity = testInactivity
 	// Reload the session provider since the configuration is indirect.
-	mock.Ctx.Providers.SessionProvider = session.NewProvider(mock.Ctx.Configuration.Session)
+	mock.Ctx.Providers.SessionProvider = session.NewProvider(mock.Ctx.Configuration.Session, mock.Ctx.Configuration.Domains, nil, nil)
 	assert.Equal(t, time.Second*10, mock.Ctx.Providers.SessionProvider.Inactivity)
 
 	userSession := mock.Ctx.GetSession()
@@ -544,7 +594,7 @@ func TestShouldDestroySessionWhenInactiveForTooLongUsingDurationNotation(t *test
 
 	mock.Ctx.Configuration.Session.Inactivity = "10s"
 	// Reload the session provider since the configuration is indirect.
-	mock.Ctx.Providers.SessionProvider = session.NewProvider(mock.Ctx.Configuration.Session)
+	mock.Ctx.Providers.SessionProvider = session.NewProvider(mock.Ctx.Configuration.Session, mock.Ctx.Configuration.Domains, nil, nil)
 	assert.Equal(t, time.Second*10, mock.Ctx.Providers.SessionProvider.Inactivity)
 
 	userSession := mock.Ctx.GetSession()
@@ -565,10 +615,65 @@ func TestShouldDestroySessionWhenInactiveForTooLongUsingDurationNotation(t *test
 	assert.Equal(t, authentication.NotAuthenticated, newUserSession.AuthenticationLevel)
 }
 
+func TestShouldDestroySessionOfPreviousGeneration(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	// The password of the user has been reset since the session was opened.
+	mock.StorageProviderMock.EXPECT().LoadSessionGeneration(testUsername).Return(1, nil)
+
+	userSession := mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	userSession.AuthenticationLevel = authentication.TwoFactor
+	userSession.Generation = 0
+
+	err := mock.Ctx.SaveSession(userSession)
+	require.NoError(t, err)
+
+	mock.Ctx.Configuration.Server.ExplainNetworks = []string{"10.0.0.0/8"}
+	mock.Ctx.Networks = middlewares.NewNetworks(mock.Ctx.Configuration.Server)
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://two-factor.example.com")
+	mock.Ctx.Request.Header.Set("X-Forwarded-For", "10.0.0.5")
+
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 401, mock.Ctx.Response.StatusCode())
+	assert.Equal(t, reasonRevoked, string(mock.Ctx.Response.Header.Peek(explainReasonHeader)))
+
+	// The session has been destroyed.
+	newUserSession := mock.Ctx.GetSession()
+	assert.Equal(t, "", newUserSession.Username)
+	assert.Equal(t, authentication.NotAuthenticated, newUserSession.AuthenticationLevel)
+}
+
+func TestShouldKeepSessionOfCurrentGeneration(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.StorageProviderMock.EXPECT().LoadSessionGeneration(testUsername).Return(1, nil)
+
+	userSession := mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	userSession.AuthenticationLevel = authentication.TwoFactor
+	userSession.Generation = 1
+
+	err := mock.Ctx.SaveSession(userSession)
+	require.NoError(t, err)
+
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://two-factor.example.com")
+
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 200, mock.Ctx.Response.StatusCode())
+	assert.Equal(t, testUsername, mock.Ctx.GetSession().Username)
+}
+
 func TestShouldKeepSessionWhenUserCheckedRememberMeAndIsInactiveForTooLong(t *testing.T) {
 	mock := mocks.NewMockAutheliaCtx(t)
 	defer mock.Close()
 
+	mock.StorageProviderMock.EXPECT().LoadSessionGeneration(testUsername).Return(0, nil)
+
 	clock := mocks.TestingClock{}
 	clock.Set(time.Now())
 
@@ -601,6 +706,8 @@ func TestShouldKeepSessionWhenInactivityTimeoutHasNotBeenExceeded(t *testing.T)
 	mock := mocks.NewMockAutheliaCtx(t)
 	defer mock.Close()
 
+	mock.StorageProviderMock.EXPECT().LoadSessionGeneration(testUsername).Return(0, nil)
+
 	clock := mocks.TestingClock{}
 	clock.Set(time.Now())
 
@@ -641,7 +748,7 @@ func TestShouldRedirectWhenSessionInactiveForTooLongAndRDParamProvided(t *testin
 
 	mock.Ctx.Configuration.Session.Inactivity = testInactivity
 	// Reload the session provider since the configuration is indirect.
-	mock.Ctx.Providers.SessionProvider = session.NewProvider(mock.Ctx.Configuration.Session)
+	mock.Ctx.Providers.SessionProvider = session.NewProvider(mock.Ctx.Configuration.Session, mock.Ctx.Configuration.Domains, nil, nil)
 	assert.Equal(t, time.Second*10, mock.Ctx.Providers.SessionProvider.Inactivity)
 
 	past := clock.Now().Add(-1 * time.Hour)
@@ -672,6 +779,8 @@ func TestShouldUpdateInactivityTimestampEvenWhenHittingForbiddenResources(t *tes
 	mock := mocks.NewMockAutheliaCtx(t)
 	defer mock.Close()
 
+	mock.StorageProviderMock.EXPECT().LoadSessionGeneration(testUsername).Return(0, nil)
+
 	clock := mocks.TestingClock{}
 	clock.Set(time.Now())
 
@@ -703,6 +812,8 @@ func TestShouldURLEncodeRedirectionURLParameter(t *testing.T) {
 	mock := mocks.NewMockAutheliaCtx(t)
 	defer mock.Close()
 
+	mock.StorageProviderMock.EXPECT().LoadSessionGeneration(testUsername).Return(0, nil)
+
 	userSession := mock.Ctx.GetSession()
 	userSession.Username = testUsername
 	userSession.AuthenticationLevel = authentication.NotAuthenticated
@@ -783,6 +894,8 @@ func TestShouldNotRefreshUserGroupsFromBackend(t *testing.T) {
 	mock := mocks.NewMockAutheliaCtx(t)
 	defer mock.Close()
 
+	mock.StorageProviderMock.EXPECT().LoadSessionGeneration(testUsername).Return(0, nil).Times(3)
+
 	// Setup pointer to john so we can adjust it during the test.
 	user := &authentication.UserDetails{
 		Username: "john",
@@ -847,6 +960,8 @@ func TestShouldNotRefreshUserGroupsFromBackendWhenNoGroupSubject(t *testing.T) {
 	mock := mocks.NewMockAutheliaCtx(t)
 	defer mock.Close()
 
+	mock.StorageProviderMock.EXPECT().LoadSessionGeneration(testUsername).Return(0, nil)
+
 	// Setup user john.
 	user := &authentication.UserDetails{
 		Username: "john",
@@ -889,6 +1004,8 @@ func TestShouldGetRemovedUserGroupsFromBackend(t *testing.T) {
 	mock := mocks.NewMockAutheliaCtx(t)
 	defer mock.Close()
 
+	mock.StorageProviderMock.EXPECT().LoadSessionGeneration(testUsername).Return(0, nil).Times(3)
+
 	// Setup pointer to john so we can adjust it during the test.
 	user := &authentication.UserDetails{
 		Username: "john",
@@ -958,6 +1075,8 @@ func TestShouldGetRemovedUserGroupsFromBackend(t *testing.T) {
 func TestShouldGetAddedUserGroupsFromBackend(t *testing.T) {
 	mock := mocks.NewMockAutheliaCtx(t)
 
+	mock.StorageProviderMock.EXPECT().LoadSessionGeneration(testUsername).Return(0, nil).Times(2)
+
 	// Setup pointer to john so we can adjust it during the test.
 	user := &authentication.UserDetails{
 		Username: "john",
@@ -1022,6 +1141,8 @@ func TestShouldGetAddedUserGroupsFromBackend(t *testing.T) {
 	err = mock.Ctx.SaveSession(userSession)
 	assert.NoError(t, err)
 
+	mock.StorageProviderMock.EXPECT().LoadSessionGeneration(testUsername).Return(0, nil)
+
 	gomock.InOrder(
 		mock.UserProviderMock.EXPECT().GetDetails("john").Return(user, nil).Times(1),
 	)
@@ -1045,6 +1166,8 @@ func TestShouldCheckValidSessionUsernameHeaderAndReturn200(t *testing.T) {
 	mock := mocks.NewMockAutheliaCtx(t)
 	defer mock.Close()
 
+	mock.StorageProviderMock.EXPECT().LoadSessionGeneration(testUsername).Return(0, nil)
+
 	expectedStatusCode := 200
 
 	userSession := mock.Ctx.GetSession()
@@ -1066,6 +1189,8 @@ func TestShouldCheckInvalidSessionUsernameHeaderAndReturn401(t *testing.T) {
 	mock := mocks.NewMockAutheliaCtx(t)
 	defer mock.Close()
 
+	mock.StorageProviderMock.EXPECT().LoadSessionGeneration(testUsername).Return(0, nil)
+
 	expectedStatusCode := 401
 
 	userSession := mock.Ctx.GetSession()
@@ -1082,3 +1207,600 @@ func TestShouldCheckInvalidSessionUsernameHeaderAndReturn401(t *testing.T) {
 	assert.Equal(t, expectedStatusCode, mock.Ctx.Response.StatusCode())
 	assert.Equal(t, "Unauthorized", string(mock.Ctx.Response.Body()))
 }
+
+func TestShouldAskToStepUpWhenRequiredMethodHasNotBeenUsed(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.StorageProviderMock.EXPECT().LoadSessionGeneration(testUsername).Return(0, nil)
+
+	mock.Ctx.Providers.Authorizer = authorization.NewAuthorizer(schema.AccessControlConfiguration{
+		DefaultPolicy: "deny",
+		Rules: []schema.ACLRule{{
+			Domains:         []string{"two-factor.example.com"},
+			Policy:          "two_factor",
+			RequiredMethods: []string{"u2f"},
+		}},
+	}, &mock.Clock)
+
+	userSession := mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	userSession.AuthenticationLevel = authentication.TwoFactor
+	userSession.AuthenticationMethods = []string{authentication.TOTP}
+
+	err := mock.Ctx.SaveSession(userSession)
+	require.NoError(t, err)
+
+	mock.Ctx.QueryArgs().Add("rd", "https://login.example.com")
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://two-factor.example.com")
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, "Found. Redirecting to https://login.example.com?rd=https%3A%2F%2Ftwo-factor.example.com",
+		string(mock.Ctx.Response.Body()))
+	assert.Equal(t, 302, mock.Ctx.Response.StatusCode())
+
+	// The session is left unchanged for the resources which don't require the method.
+	userSession = mock.Ctx.GetSession()
+	assert.Equal(t, authentication.TwoFactor, userSession.AuthenticationLevel)
+}
+
+func TestShouldAuthorizeWhenRequiredMethodHasBeenUsed(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.StorageProviderMock.EXPECT().LoadSessionGeneration(testUsername).Return(0, nil)
+
+	mock.Ctx.Providers.Authorizer = authorization.NewAuthorizer(schema.AccessControlConfiguration{
+		DefaultPolicy: "deny",
+		Rules: []schema.ACLRule{{
+			Domains:         []string{"two-factor.example.com"},
+			Policy:          "two_factor",
+			RequiredMethods: []string{"u2f", "mobile_push"},
+		}},
+	}, &mock.Clock)
+
+	userSession := mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	userSession.AuthenticationLevel = authentication.TwoFactor
+	userSession.AuthenticationMethods = []string{authentication.TOTP, authentication.Push}
+
+	err := mock.Ctx.SaveSession(userSession)
+	require.NoError(t, err)
+
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://two-factor.example.com")
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 200, mock.Ctx.Response.StatusCode())
+
+	userSession = mock.Ctx.GetSession()
+	assert.Equal(t, authentication.TwoFactor, userSession.AuthenticationLevel)
+}
+
+func TestShouldAskToStepUpWhenSecondFactorIsTooOld(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.StorageProviderMock.EXPECT().LoadSessionGeneration(testUsername).Return(0, nil)
+
+	mock.Ctx.Providers.Authorizer = authorization.NewAuthorizer(schema.AccessControlConfiguration{
+		DefaultPolicy: "deny",
+		Rules: []schema.ACLRule{{
+			Domains:              []string{"payroll.example.com"},
+			Policy:               "two_factor",
+			MaxAuthenticationAge: "10m",
+		}},
+	}, &mock.Clock)
+
+	userSession := mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	userSession.AuthenticationLevel = authentication.TwoFactor
+	userSession.FirstFactorAuthnTimestamp = mock.Ctx.Clock.Now().Add(-time.Minute).Unix()
+	userSession.SecondFactorAuthnTimestamp = mock.Ctx.Clock.Now().Add(-time.Hour).Unix()
+	userSession.LastActivity = mock.Ctx.Clock.Now().Unix()
+
+	err := mock.Ctx.SaveSession(userSession)
+	require.NoError(t, err)
+
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://payroll.example.com")
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 401, mock.Ctx.Response.StatusCode())
+
+	userSession = mock.Ctx.GetSession()
+	assert.Equal(t, testUsername, userSession.Username)
+	assert.Equal(t, authentication.TwoFactor, userSession.AuthenticationLevel)
+}
+
+func TestShouldAskToAuthenticateWhenLastAuthenticationIsTooOld(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.StorageProviderMock.EXPECT().LoadSessionGeneration(testUsername).Return(0, nil)
+
+	mock.Ctx.Providers.Authorizer = authorization.NewAuthorizer(schema.AccessControlConfiguration{
+		DefaultPolicy: "deny",
+		Rules: []schema.ACLRule{{
+			Domains:              []string{"payroll.example.com"},
+			Policy:               "one_factor",
+			MaxAuthenticationAge: "10m",
+		}},
+	}, &mock.Clock)
+
+	userSession := mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	userSession.AuthenticationLevel = authentication.OneFactor
+	userSession.FirstFactorAuthnTimestamp = mock.Ctx.Clock.Now().Add(-time.Hour).Unix()
+	userSession.LastActivity = mock.Ctx.Clock.Now().Unix()
+
+	err := mock.Ctx.SaveSession(userSession)
+	require.NoError(t, err)
+
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://payroll.example.com")
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 401, mock.Ctx.Response.StatusCode())
+
+	userSession = mock.Ctx.GetSession()
+	assert.Equal(t, testUsername, userSession.Username)
+	assert.Equal(t, authentication.OneFactor, userSession.AuthenticationLevel)
+}
+
+func TestShouldAuthorizeWhenLastAuthenticationIsFreshEnough(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.StorageProviderMock.EXPECT().LoadSessionGeneration(testUsername).Return(0, nil)
+
+	mock.Ctx.Providers.Authorizer = authorization.NewAuthorizer(schema.AccessControlConfiguration{
+		DefaultPolicy: "deny",
+		Rules: []schema.ACLRule{{
+			Domains:              []string{"payroll.example.com"},
+			Policy:               "two_factor",
+			MaxAuthenticationAge: "10m",
+		}},
+	}, &mock.Clock)
+
+	userSession := mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	userSession.AuthenticationLevel = authentication.TwoFactor
+	userSession.FirstFactorAuthnTimestamp = mock.Ctx.Clock.Now().Add(-time.Hour).Unix()
+	userSession.SecondFactorAuthnTimestamp = mock.Ctx.Clock.Now().Add(-5 * time.Minute).Unix()
+	userSession.LastActivity = mock.Ctx.Clock.Now().Unix()
+
+	err := mock.Ctx.SaveSession(userSession)
+	require.NoError(t, err)
+
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://payroll.example.com")
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 200, mock.Ctx.Response.StatusCode())
+
+	userSession = mock.Ctx.GetSession()
+	assert.Equal(t, authentication.TwoFactor, userSession.AuthenticationLevel)
+}
+
+func TestShouldMatchRulesOnForwardedHeadersAndQuery(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.Ctx.Providers.Authorizer = authorization.NewAuthorizer(schema.AccessControlConfiguration{
+		DefaultPolicy: "deny",
+		Rules: []schema.ACLRule{
+			{
+				Domains: []string{"api.example.com"},
+				Policy:  "bypass",
+				Headers: []schema.ACLRuleMatcher{{Name: "Accept", Value: "application/json"}},
+				Query:   []schema.ACLRuleMatcher{{Name: "api_key"}},
+			},
+		},
+	}, &mock.Clock)
+
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://api.example.com/users?api_key=abc")
+	mock.Ctx.Request.Header.Set("Accept", "application/json")
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 200, mock.Ctx.Response.StatusCode())
+
+	mock.Ctx.Response.Reset()
+	mock.Ctx.Request.Header.Set("Accept", "text/html")
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 401, mock.Ctx.Response.StatusCode())
+}
+
+func TestShouldExplainVerifyDecisionToExplainNetworks(t *testing.T) {
+	testCases := []struct {
+		description   string
+		targetURL     string
+		authLevel     authentication.Level
+		lastActivity  time.Duration
+		expectedRule  string
+		expectedLevel string
+		expectedAuthn string
+		expectedWhy   string
+	}{
+		{"named rule forbidding access", "https://admin.example.com", authentication.TwoFactor, 0,
+			"admin", "deny", "two_factor", "forbidden"},
+		{"unnamed rule requiring second factor", "https://two-factor.example.com", authentication.OneFactor, 0,
+			"1", "two_factor", "one_factor", "needs_two_factor"},
+		{"default policy requiring authentication", "https://other.example.com", authentication.NotAuthenticated, 0,
+			"default_policy", "one_factor", "not_authenticated", "needs_authentication"},
+		{"inactive session", "https://two-factor.example.com", authentication.TwoFactor, -time.Hour,
+			"1", "two_factor", "not_authenticated", "inactive"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			mock := mocks.NewMockAutheliaCtx(t)
+			defer mock.Close()
+
+			mock.Ctx.Configuration.Server.ExplainNetworks = []string{"10.0.0.0/8"}
+			mock.Ctx.Networks = middlewares.NewNetworks(mock.Ctx.Configuration.Server)
+			mock.Ctx.Configuration.Session.Inactivity = testInactivity
+			mock.Ctx.Providers.SessionProvider = session.NewProvider(mock.Ctx.Configuration.Session, mock.Ctx.Configuration.Domains, nil, nil)
+			mock.Ctx.Providers.Authorizer = authorization.NewAuthorizer(schema.AccessControlConfiguration{
+				DefaultPolicy: "one_factor",
+				Rules: []schema.ACLRule{
+					{Name: "admin", Domains: []string{"admin.example.com"}, Policy: "deny"},
+					{Domains: []string{"two-factor.example.com"}, Policy: "two_factor"},
+				},
+			}, &mock.Clock)
+
+			if tc.authLevel > authentication.NotAuthenticated {
+				userSession := mock.Ctx.GetSession()
+				userSession.Username = testUsername
+				userSession.AuthenticationLevel = tc.authLevel
+				userSession.LastActivity = time.Now().Add(tc.lastActivity).Unix()
+
+				err := mock.Ctx.SaveSession(userSession)
+				require.NoError(t, err)
+
+				// The generation of an inactive session is not checked since it is already destroyed.
+				if tc.lastActivity == 0 {
+					mock.StorageProviderMock.EXPECT().LoadSessionGeneration(testUsername).Return(0, nil)
+				}
+			}
+
+			mock.Ctx.Request.Header.Set("X-Original-URL", tc.targetURL)
+			mock.Ctx.Request.Header.Set("X-Forwarded-For", "10.0.0.5")
+			VerifyGet(verifyGetCfg)(mock.Ctx)
+
+			assert.Equal(t, tc.expectedRule, string(mock.Ctx.Response.Header.Peek(explainRuleHeader)))
+			assert.Equal(t, tc.expectedLevel, string(mock.Ctx.Response.Header.Peek(explainRequiredLevelHeader)))
+			assert.Equal(t, tc.expectedAuthn, string(mock.Ctx.Response.Header.Peek(explainCurrentLevelHeader)))
+			assert.Equal(t, tc.expectedWhy, string(mock.Ctx.Response.Header.Peek(explainReasonHeader)))
+		})
+	}
+}
+
+func TestShouldExplainVerifyDecisionRequiringStepUp(t *testing.T) {
+	testCases := []struct {
+		description   string
+		targetURL     string
+		expectedRule  string
+		expectedAuthn string
+		expectedWhy   string
+	}{
+		{"rule requiring another second factor method", "https://vault.example.com", "vault", "one_factor",
+			"needs_required_method"},
+		{"rule requiring a fresher authentication", "https://payroll.example.com", "payroll", "not_authenticated",
+			"authentication_too_old"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			mock := mocks.NewMockAutheliaCtx(t)
+			defer mock.Close()
+
+			mock.Ctx.Configuration.Server.ExplainNetworks = []string{"10.0.0.0/8"}
+			mock.Ctx.Networks = middlewares.NewNetworks(mock.Ctx.Configuration.Server)
+			mock.Ctx.Providers.Authorizer = authorization.NewAuthorizer(schema.AccessControlConfiguration{
+				DefaultPolicy: "deny",
+				Rules: []schema.ACLRule{
+					{Name: "vault", Domains: []string{"vault.example.com"}, Policy: "two_factor",
+						RequiredMethods: []string{"u2f"}},
+					{Name: "payroll", Domains: []string{"payroll.example.com"}, Policy: "two_factor",
+						MaxAuthenticationAge: "10m"},
+				},
+			}, &mock.Clock)
+
+			mock.StorageProviderMock.EXPECT().LoadSessionGeneration(testUsername).Return(0, nil)
+
+			userSession := mock.Ctx.GetSession()
+			userSession.Username = testUsername
+			userSession.AuthenticationLevel = authentication.TwoFactor
+			userSession.AuthenticationMethods = []string{"totp"}
+			userSession.FirstFactorAuthnTimestamp = mock.Ctx.Clock.Now().Add(-time.Hour).Unix()
+			userSession.SecondFactorAuthnTimestamp = mock.Ctx.Clock.Now().Add(-time.Hour).Unix()
+			userSession.LastActivity = mock.Ctx.Clock.Now().Unix()
+
+			err := mock.Ctx.SaveSession(userSession)
+			require.NoError(t, err)
+
+			mock.Ctx.Request.Header.Set("X-Original-URL", tc.targetURL)
+			mock.Ctx.Request.Header.Set("X-Forwarded-For", "10.0.0.5")
+			VerifyGet(verifyGetCfg)(mock.Ctx)
+
+			assert.Equal(t, 401, mock.Ctx.Response.StatusCode())
+			assert.Equal(t, tc.expectedRule, string(mock.Ctx.Response.Header.Peek(explainRuleHeader)))
+			assert.Equal(t, "two_factor", string(mock.Ctx.Response.Header.Peek(explainRequiredLevelHeader)))
+			assert.Equal(t, tc.expectedAuthn, string(mock.Ctx.Response.Header.Peek(explainCurrentLevelHeader)))
+			assert.Equal(t, tc.expectedWhy, string(mock.Ctx.Response.Header.Peek(explainReasonHeader)))
+		})
+	}
+}
+
+func TestShouldNotExplainVerifyDecisionOutsideExplainNetworks(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.Ctx.Configuration.Server.ExplainNetworks = []string{"10.0.0.0/8"}
+	mock.Ctx.Networks = middlewares.NewNetworks(mock.Ctx.Configuration.Server)
+
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://deny.example.com")
+	mock.Ctx.Request.Header.Set("X-Forwarded-For", "192.168.1.5")
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 401, mock.Ctx.Response.StatusCode())
+	assert.Nil(t, mock.Ctx.Response.Header.Peek(explainRuleHeader))
+	assert.Nil(t, mock.Ctx.Response.Header.Peek(explainReasonHeader))
+}
+
+func TestShouldRefreshUserAttributesFromBackend(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.StorageProviderMock.EXPECT().LoadSessionGeneration(testUsername).Return(0, nil).Times(2)
+
+	mock.Ctx.Providers.Authorizer = authorization.NewAuthorizer(schema.AccessControlConfiguration{
+		DefaultPolicy: "deny",
+		Rules: []schema.ACLRule{
+			{Domains: []string{"finance.example.com"}, Subjects: [][]string{{"attr:department=finance"}}, Policy: "one_factor"},
+			{Domains: []string{"partners.example.com"}, Subjects: [][]string{{"email:*@partner.com"}}, Policy: "one_factor"},
+		},
+	}, &mock.Clock)
+
+	user := &authentication.UserDetails{
+		Username:   "john",
+		Emails:     []string{"john@partner.com"},
+		Attributes: map[string][]string{"department": {"finance"}},
+	}
+
+	mock.UserProviderMock.EXPECT().GetDetails("john").Return(user, nil).Times(1)
+
+	userSession := mock.Ctx.GetSession()
+	userSession.Username = user.Username
+	userSession.AuthenticationLevel = authentication.OneFactor
+	userSession.LastActivity = mock.Clock.Now().Unix()
+	userSession.RefreshTTL = mock.Clock.Now().Add(-1 * time.Minute)
+	userSession.Emails = []string{"john@example.com"}
+	userSession.Attributes = map[string][]string{"department": {"sales"}}
+	userSession.KeepMeLoggedIn = true
+	err := mock.Ctx.SaveSession(userSession)
+	require.NoError(t, err)
+
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://finance.example.com")
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+	assert.Equal(t, 200, mock.Ctx.Response.StatusCode())
+
+	userSession = mock.Ctx.GetSession()
+	assert.Equal(t, user.Attributes, userSession.Attributes)
+	assert.Equal(t, user.Emails, userSession.Emails)
+
+	// The refreshed profile is used until the refresh TTL expires.
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://partners.example.com")
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+	assert.Equal(t, 200, mock.Ctx.Response.StatusCode())
+}
+
+func TestShouldRedirectToAccessDeniedPage(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.StorageProviderMock.EXPECT().LoadSessionGeneration(testUsername).Return(0, nil)
+
+	mock.Ctx.Providers.Authorizer = authorization.NewAuthorizer(schema.AccessControlConfiguration{
+		DefaultPolicy: "deny",
+		Rules: []schema.ACLRule{
+			{Domains: []string{"admin.example.com"}, Policy: "deny", DenyBehavior: "access_denied"},
+		},
+	}, &mock.Clock)
+
+	userSession := mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	userSession.AuthenticationLevel = authentication.TwoFactor
+	userSession.LastActivity = mock.Clock.Now().Unix()
+	err := mock.Ctx.SaveSession(userSession)
+	require.NoError(t, err)
+
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://admin.example.com/users")
+	mock.Ctx.QueryArgs().Add("rd", "https://login.example.com/")
+
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 302, mock.Ctx.Response.StatusCode())
+	assert.Equal(t, "https://login.example.com/denied?rd=https%3A%2F%2Fadmin.example.com%2Fusers",
+		string(mock.Ctx.Response.Header.Peek("Location")))
+}
+
+func TestShouldForbidAccessDeniedWithoutLoginPortal(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.Ctx.Providers.Authorizer = authorization.NewAuthorizer(schema.AccessControlConfiguration{
+		DefaultPolicy: "deny",
+		Rules: []schema.ACLRule{
+			{Domains: []string{"admin.example.com"}, Policy: "deny", DenyBehavior: "access_denied"},
+		},
+	}, &mock.Clock)
+
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://admin.example.com/users")
+
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 403, mock.Ctx.Response.StatusCode())
+}
+
+func TestShouldRedirectToPortalOfProtectedDomain(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.Ctx.Configuration.Domains = []schema.DomainConfiguration{
+		{Domain: "example.com"},
+		{Domain: "example.io", PortalURL: "https://auth.example.io"},
+	}
+	mock.Ctx.Providers.Authorizer = authorization.NewAuthorizer(schema.AccessControlConfiguration{
+		DefaultPolicy: "one_factor",
+	}, &mock.Clock)
+
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://app.example.io/")
+	mock.Ctx.QueryArgs().Add("rd", "https://login.example.com/")
+
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 302, mock.Ctx.Response.StatusCode())
+	assert.Equal(t, "https://auth.example.io/?rd=https%3A%2F%2Fapp.example.io%2F",
+		string(mock.Ctx.Response.Header.Peek("Location")))
+}
+
+func TestShouldRedirectToPortalOfRequestWhenDomainHasNone(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.Ctx.Configuration.Domains = []schema.DomainConfiguration{
+		{Domain: "example.com"},
+		{Domain: "example.io", PortalURL: "https://auth.example.io"},
+	}
+	mock.Ctx.Providers.Authorizer = authorization.NewAuthorizer(schema.AccessControlConfiguration{
+		DefaultPolicy: "one_factor",
+	}, &mock.Clock)
+
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://app.example.com/")
+	mock.Ctx.QueryArgs().Add("rd", "https://login.example.com/")
+
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 302, mock.Ctx.Response.StatusCode())
+	assert.Equal(t, "https://login.example.com/?rd=https%3A%2F%2Fapp.example.com%2F",
+		string(mock.Ctx.Response.Header.Peek("Location")))
+}
+
+func TestShouldRejectTargetNotUnderAnyProtectedDomain(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.Ctx.Configuration.Domains = []schema.DomainConfiguration{
+		{Domain: "example.com"},
+		{Domain: "example.io"},
+	}
+
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://app.example.org/")
+
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 401, mock.Ctx.Response.StatusCode())
+}
+
+func TestShouldGetMostSpecificProtectedDomain(t *testing.T) {
+	configuration := schema.Configuration{
+		Domains: []schema.DomainConfiguration{
+			{Domain: "example.com"},
+			{Domain: "secure.example.com"},
+		},
+	}
+
+	targetURL, _ := url.ParseRequestURI("https://app.secure.example.com/")
+	domain := getProtectedDomain(configuration, targetURL)
+	require.NotNil(t, domain)
+	assert.Equal(t, "secure.example.com", domain.Domain)
+
+	targetURL, _ = url.ParseRequestURI("https://app.example.com/")
+	domain = getProtectedDomain(configuration, targetURL)
+	require.NotNil(t, domain)
+	assert.Equal(t, "example.com", domain.Domain)
+
+	targetURL, _ = url.ParseRequestURI("https://app.example.org/")
+	assert.Nil(t, getProtectedDomain(configuration, targetURL))
+}
+
+func TestShouldVerifySessionBinding(t *testing.T) {
+	testCases := []struct {
+		description      string
+		action           string
+		xForwardedFor    string
+		userAgent        string
+		expectedStatus   int
+		expectedWhy      string
+		expectedUsername string
+	}{
+		{"client moved within the same network", schema.SessionBindingActionDegrade, "10.0.0.200", "Firefox",
+			200, reasonAuthorized, testUsername},
+		{"degraded for another network", schema.SessionBindingActionDegrade, "10.1.0.5", "Firefox",
+			401, reasonBindingMismatch, testUsername},
+		{"destroyed for another user agent", schema.SessionBindingActionDestroy, "10.0.0.5", "Chrome",
+			401, reasonBindingMismatch, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			mock := mocks.NewMockAutheliaCtx(t)
+			defer mock.Close()
+
+			mock.Ctx.Configuration.Session.Binding = &schema.SessionBindingConfiguration{
+				IPv4PrefixLength: 24,
+				UserAgent:        true,
+				Action:           tc.action,
+			}
+
+			// The requests are forwarded by a trusted proxy and the decisions are explained to the clients.
+			mock.Ctx.Configuration.Server.TrustedProxies = []string{"192.168.1.1"}
+			mock.Ctx.Configuration.Server.ExplainNetworks = []string{"10.0.0.0/8"}
+			mock.Ctx.Networks = middlewares.NewNetworks(mock.Ctx.Configuration.Server)
+			mock.Ctx.SetRemoteAddr(&net.TCPAddr{IP: net.ParseIP("192.168.1.1")})
+
+			mock.StorageProviderMock.EXPECT().LoadSessionGeneration(testUsername).Return(0, nil).AnyTimes()
+
+			// The user logged in from this client.
+			mock.Ctx.Request.Header.Set("X-Forwarded-For", "10.0.0.5")
+			mock.Ctx.Request.Header.SetUserAgent("Firefox")
+
+			userSession := mock.Ctx.GetSession()
+			userSession.Username = testUsername
+			userSession.AuthenticationLevel = authentication.TwoFactor
+			mock.Ctx.BindSession(&userSession)
+			require.NoError(t, mock.Ctx.SaveSession(userSession))
+
+			mock.Ctx.Request.Header.Set("X-Forwarded-For", tc.xForwardedFor)
+			mock.Ctx.Request.Header.SetUserAgent(tc.userAgent)
+			mock.Ctx.Request.Header.Set("X-Original-URL", "https://two-factor.example.com")
+
+			VerifyGet(verifyGetCfg)(mock.Ctx)
+
+			assert.Equal(t, tc.expectedStatus, mock.Ctx.Response.StatusCode())
+			assert.Equal(t, tc.expectedWhy, string(mock.Ctx.Response.Header.Peek(explainReasonHeader)))
+			assert.Equal(t, tc.expectedUsername, mock.Ctx.GetSession().Username)
+		})
+	}
+}
+
+func TestShouldNotVerifyBindingOfSessionOpenedBeforeBindingWasEnabled(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.StorageProviderMock.EXPECT().LoadSessionGeneration(testUsername).Return(0, nil)
+
+	userSession := mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	userSession.AuthenticationLevel = authentication.TwoFactor
+	require.NoError(t, mock.Ctx.SaveSession(userSession))
+
+	mock.Ctx.Configuration.Session.Binding = &schema.SessionBindingConfiguration{
+		UserAgent: true,
+		Action:    schema.SessionBindingActionDestroy,
+	}
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://two-factor.example.com")
+
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 200, mock.Ctx.Response.StatusCode())
+}
@@ -8,11 +8,12 @@ import (
 
 	"github.com/authelia/authelia/internal/authorization"
 	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/session"
 	"github.com/authelia/authelia/internal/utils"
 )
 
 // Handle1FAResponse handle the redirection upon 1FA authentication.
-func Handle1FAResponse(ctx *middlewares.AutheliaCtx, targetURI string, username string, groups []string) {
+func Handle1FAResponse(ctx *middlewares.AutheliaCtx, targetURI string, userSession session.UserSession) {
 	if targetURI == "" {
 		if !ctx.Providers.Authorizer.IsSecondFactorEnabled() && ctx.Configuration.DefaultRedirectionURL != "" {
 			err := ctx.SetJSONBody(redirectResponse{Redirect: ctx.Configuration.DefaultRedirectionURL})
@@ -32,11 +33,16 @@ func Handle1FAResponse(ctx *middlewares.AutheliaCtx, targetURI string, username
 		return
 	}
 
+	// The headers of the requests to the target URL are not known here, the header conditions of the rules are matched
+	// against the headers of the login request instead.
 	requiredLevel := ctx.Providers.Authorizer.GetRequiredLevel(authorization.Subject{
-		Username: username,
-		Groups:   groups,
-		IP:       ctx.RemoteIP(),
-	}, *targetURL)
+		Username:   userSession.Username,
+		Groups:     userSession.Groups,
+		Emails:     userSession.Emails,
+		Attributes: userSession.Attributes,
+		IP:         ctx.RemoteIP(),
+		Country:    ctx.RemoteCountry(),
+	}, authorization.NewObject(*targetURL, ctx.RequestHeaders()))
 
 	ctx.Logger.Debugf("Required level for the URL %s is %d", targetURI, requiredLevel)
 
@@ -47,7 +53,7 @@ func Handle1FAResponse(ctx *middlewares.AutheliaCtx, targetURI string, username
 		return
 	}
 
-	safeRedirection := utils.IsRedirectionSafe(*targetURL, ctx.Configuration.Session.Domain)
+	safeRedirection := isRedirectionSafe(ctx, targetURL)
 
 	if !safeRedirection {
 		if !ctx.Providers.Authorizer.IsSecondFactorEnabled() && ctx.Configuration.DefaultRedirectionURL != "" {
@@ -94,7 +100,7 @@ func Handle2FAResponse(ctx *middlewares.AutheliaCtx, targetURI string) {
 		return
 	}
 
-	if targetURL != nil && utils.IsRedirectionSafe(*targetURL, ctx.Configuration.Session.Domain) {
+	if targetURL != nil && isRedirectionSafe(ctx, targetURL) {
 		err := ctx.SetJSONBody(redirectResponse{Redirect: targetURI})
 		if err != nil {
 			ctx.Logger.Errorf("Unable to set redirection URL in body: %s", err)
@@ -109,3 +115,10 @@ func handleAuthenticationUnauthorized(ctx *middlewares.AutheliaCtx, err error, m
 	ctx.SetStatusCode(fasthttp.StatusUnauthorized)
 	ctx.Error(err, message)
 }
+
+// isRedirectionSafe determines if a redirection URL is secured and under one of the protected domains.
+func isRedirectionSafe(ctx *middlewares.AutheliaCtx, targetURL *url.URL) bool {
+	domain := getProtectedDomain(ctx.Configuration, targetURL)
+
+	return domain != nil && utils.IsRedirectionSafe(*targetURL, domain.Domain)
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/session"
+)
+
+// renewSessionGeneration invalidates all the sessions of a user by incrementing their generation. The session of the
+// request is stamped with the new generation when it belongs to the user so that it stays valid, it is up to the
+// caller to save it.
+func renewSessionGeneration(ctx *middlewares.AutheliaCtx, username string, userSession *session.UserSession) error {
+	err := ctx.Providers.StorageProvider.IncrementSessionGeneration(username)
+	if err != nil {
+		return err
+	}
+
+	ctx.Logger.Debugf("Sessions of user %s have been invalidated", username)
+
+	if userSession.Username != username {
+		return nil
+	}
+
+	generation, err := ctx.Providers.StorageProvider.LoadSessionGeneration(username)
+	if err != nil {
+		return err
+	}
+
+	userSession.Generation = generation
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/mocks"
+	"github.com/authelia/authelia/internal/session"
+)
+
+func TestShouldRenewSessionGenerationOfCurrentUser(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.StorageProviderMock.EXPECT().IncrementSessionGeneration(testUsername).Return(nil)
+	mock.StorageProviderMock.EXPECT().LoadSessionGeneration(testUsername).Return(3, nil)
+
+	userSession := session.UserSession{Username: testUsername, Generation: 2}
+
+	err := renewSessionGeneration(mock.Ctx, testUsername, &userSession)
+	require.NoError(t, err)
+	assert.Equal(t, 3, userSession.Generation)
+}
+
+func TestShouldRenewSessionGenerationOfOtherUser(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.StorageProviderMock.EXPECT().IncrementSessionGeneration("harry").Return(nil)
+
+	// The session of the request is anonymous, for instance during a password reset.
+	userSession := session.UserSession{}
+
+	err := renewSessionGeneration(mock.Ctx, "harry", &userSession)
+	require.NoError(t, err)
+	assert.Equal(t, 0, userSession.Generation)
+}
+
+func TestShouldFailToRenewSessionGenerationWhenStorageFails(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.StorageProviderMock.EXPECT().IncrementSessionGeneration(testUsername).Return(fmt.Errorf("failed"))
+
+	userSession := session.UserSession{Username: testUsername, Generation: 2}
+
+	err := renewSessionGeneration(mock.Ctx, testUsername, &userSession)
+	assert.EqualError(t, err, "failed")
+	assert.Equal(t, 2, userSession.Generation)
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/tstranex/u2f"
 
 	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/session"
 )
 
 // MethodList is the list of available methods.
@@ -11,6 +12,12 @@ type MethodList = []string
 
 type authorizationMatching int
 
+// verifiedUser is the user identified by the verify endpoint with their authentication level for the target URL.
+type verifiedUser struct {
+	authentication.UserDetails
+	AuthenticationLevel authentication.Level
+}
+
 // UserInfo is the model of user info and second factor preferences.
 type UserInfo struct {
 	// The users display name.
@@ -69,6 +76,15 @@ type StateResponse struct {
 	Username              string               `json:"username"`
 	AuthenticationLevel   authentication.Level `json:"authentication_level"`
 	DefaultRedirectionURL string               `json:"default_redirection_url"`
+
+	// The second factor methods allowed by the rule matching the target URL, any method when it is empty.
+	RequiredMethods []string `json:"required_methods,omitempty"`
+}
+
+// ActiveSessionResponse represents an active session of the user, flagged if it is the session of the request.
+type ActiveSessionResponse struct {
+	session.ActiveSession
+	Current bool `json:"current"`
 }
 
 // resetPasswordStep1RequestBody model of the reset password (step1) request body.
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"net/http"
 	"strings"
 
 	"github.com/asaskevich/govalidator"
@@ -24,27 +25,40 @@ func NewRequestLogger(ctx *AutheliaCtx) *logrus.Entry {
 	})
 }
 
+// NewNetworks parses the networks of the server configuration, which are checked by the validator.
+func NewNetworks(configuration schema.ServerConfiguration) Networks {
+	return Networks{
+		TrustedProxies:  utils.ParseNetworks(configuration.TrustedProxies),
+		ExplainNetworks: utils.ParseNetworks(configuration.ExplainNetworks),
+	}
+}
+
 // NewAutheliaCtx instantiate an AutheliaCtx out of a RequestCtx.
 func NewAutheliaCtx(ctx *fasthttp.RequestCtx, configuration schema.Configuration, providers Providers) (*AutheliaCtx, error) {
+	return newAutheliaCtx(ctx, configuration, NewNetworks(configuration.Server), providers), nil
+}
+
+func newAutheliaCtx(ctx *fasthttp.RequestCtx, configuration schema.Configuration, networks Networks,
+	providers Providers) *AutheliaCtx {
 	autheliaCtx := new(AutheliaCtx)
 	autheliaCtx.RequestCtx = ctx
 	autheliaCtx.Providers = providers
 	autheliaCtx.Configuration = configuration
+	autheliaCtx.Networks = networks
 	autheliaCtx.Logger = NewRequestLogger(autheliaCtx)
 	autheliaCtx.Clock = utils.RealClock{}
 
-	return autheliaCtx, nil
+	return autheliaCtx
 }
 
 // AutheliaMiddleware is wrapping the RequestCtx into an AutheliaCtx providing Authelia related objects.
 func AutheliaMiddleware(configuration schema.Configuration, providers Providers) func(next RequestHandler) fasthttp.RequestHandler {
+	// The networks are parsed once instead of on each request.
+	networks := NewNetworks(configuration.Server)
+
 	return func(next RequestHandler) fasthttp.RequestHandler {
 		return func(ctx *fasthttp.RequestCtx) {
-			autheliaCtx, err := NewAutheliaCtx(ctx, configuration, providers)
-			if err != nil {
-				autheliaCtx.Error(err, operationFailedMessage)
-				return
-			}
+			autheliaCtx := newAutheliaCtx(ctx, configuration, networks, providers)
 
 			next(autheliaCtx)
 		}
@@ -107,7 +121,8 @@ func (c *AutheliaCtx) XOriginalURL() []byte {
 	return c.RequestCtx.Request.Header.Peek(xOriginalURLHeader)
 }
 
-// GetSession return the user session. Any update will be saved in cache.
+// GetSession return the user session. Any update will be saved in cache. A session bound to another client than the
+// one of the request is returned unauthenticated.
 func (c *AutheliaCtx) GetSession() session.UserSession {
 	userSession, err := c.Providers.SessionProvider.GetSession(c.RequestCtx)
 	if err != nil {
@@ -115,12 +130,42 @@ func (c *AutheliaCtx) GetSession() session.UserSession {
 		return session.NewDefaultUserSession()
 	}
 
+	userSession, err = c.enforceSessionBinding(userSession)
+	if err != nil {
+		c.Logger.Errorf("Unable to revoke the session bound to another client: %s", err)
+	}
+
 	return userSession
 }
 
-// SaveSession save the content of the session.
+// SaveSession save the content of the session. The entry of the session in the index of the active sessions of the
+// user is only updated when the user logs in, changes authentication level or when it is outdated, not on every request,
+// and never by a client the session is not bound to.
 func (c *AutheliaCtx) SaveSession(userSession session.UserSession) error {
-	return c.Providers.SessionProvider.SaveSession(c.RequestCtx, userSession)
+	now := c.Clock.Now()
+
+	// The client of a session bound to another client is not recorded in the index.
+	updateActiveSession := userSession.IsActiveSessionOutdated(now) && c.isSessionBoundToClient(userSession)
+	if updateActiveSession {
+		userSession.MarkActiveSessionUpdated(now)
+	}
+
+	err := c.Providers.SessionProvider.SaveSession(c.RequestCtx, userSession)
+	if err != nil {
+		return err
+	}
+
+	if !updateActiveSession {
+		return nil
+	}
+
+	err = c.Providers.SessionProvider.UpdateActiveSession(c.RequestCtx, userSession, c.RemoteIP().String(), now)
+	if err != nil {
+		// The index of the active sessions is informative, failing to update it must not prevent the user to log in.
+		c.Logger.Errorf("Unable to update the active sessions of user %s: %s", userSession.Username, err)
+	}
+
+	return nil
 }
 
 // ReplyOK is a helper method to reply ok.
@@ -163,16 +208,95 @@ func (c *AutheliaCtx) SetJSONBody(value interface{}) error {
 	return nil
 }
 
-// RemoteIP return the remote IP taking X-Forwarded-For header into account if provided.
+// XForwardedFor return the hops of all the X-Forwarded-For headers of the request, from the leftmost to the rightmost.
+func (c *AutheliaCtx) XForwardedFor() []string {
+	var hops []string
+
+	c.Request.Header.VisitAll(func(key, value []byte) {
+		if !strings.EqualFold(string(key), xForwardedForHeader) {
+			return
+		}
+
+		for _, hop := range strings.Split(string(value), ",") {
+			hops = append(hops, strings.Trim(hop, " "))
+		}
+	})
+
+	return hops
+}
+
+// RemoteIP return the remote IP taking X-Forwarded-For header into account if provided. When trusted proxies are
+// configured, the header is walked from right to left and the first hop which is not a trusted proxy is returned.
 func (c *AutheliaCtx) RemoteIP() net.IP {
-	XForwardedFor := c.Request.Header.Peek("X-Forwarded-For")
-	if XForwardedFor != nil {
-		ips := strings.Split(string(XForwardedFor), ",")
+	hops := c.XForwardedFor()
+
+	if len(c.Networks.TrustedProxies) == 0 {
+		if len(hops) > 0 {
+			return net.ParseIP(hops[0])
+		}
+
+		return c.RequestCtx.RemoteIP()
+	}
+
+	remoteIP := c.RequestCtx.RemoteIP()
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		if !utils.IsIPInNetworks(remoteIP, c.Networks.TrustedProxies) {
+			return remoteIP
+		}
 
-		if len(ips) > 0 {
-			return net.ParseIP(strings.Trim(ips[0], " "))
+		// A malformed hop cannot be trusted, the last trusted proxy is returned instead.
+		ip := net.ParseIP(hops[i])
+		if ip == nil {
+			return remoteIP
 		}
+
+		remoteIP = ip
+	}
+
+	return remoteIP
+}
+
+// IsRemoteIPInExplainNetworks check whether the remote IP belongs to the networks receiving the explanation of the
+// verify decisions.
+func (c *AutheliaCtx) IsRemoteIPInExplainNetworks() bool {
+	return utils.IsIPInNetworks(c.RemoteIP(), c.Networks.ExplainNetworks)
+}
+
+// RequestHeaders return the headers of the request, which are the headers of the original request when they are
+// forwarded by the proxy.
+func (c *AutheliaCtx) RequestHeaders() http.Header {
+	headers := http.Header{}
+
+	c.Request.Header.VisitAll(func(key, value []byte) {
+		headers.Add(string(key), string(value))
+	})
+
+	return headers
+}
+
+// RemoteCountry return the ISO 3166-1 alpha-2 code of the country of the remote IP, empty when unknown. The database
+// is only looked up the first time during a request.
+func (c *AutheliaCtx) RemoteCountry() string {
+	if c.remoteCountryLoaded {
+		return c.remoteCountry
+	}
+
+	c.remoteCountryLoaded = true
+
+	if c.Providers.GeoIP == nil {
+		return ""
 	}
 
-	return c.RequestCtx.RemoteIP()
+	remoteIP := c.RemoteIP()
+
+	country, err := c.Providers.GeoIP.Country(remoteIP)
+	if err != nil {
+		c.Logger.Debugf("Unable to locate the country of IP %s: %s", remoteIP, err)
+		return ""
+	}
+
+	c.remoteCountry = country
+
+	return country
 }
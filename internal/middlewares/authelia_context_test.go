@@ -1,12 +1,16 @@
 package middlewares_test
 
 import (
+	"net"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/valyala/fasthttp"
 
+	"github.com/authelia/authelia/internal/authentication"
 	"github.com/authelia/authelia/internal/configuration/schema"
 	"github.com/authelia/authelia/internal/middlewares"
 	"github.com/authelia/authelia/internal/mocks"
@@ -18,7 +22,7 @@ func TestShouldCallNextWithAutheliaCtx(t *testing.T) {
 	ctx := &fasthttp.RequestCtx{}
 	configuration := schema.Configuration{}
 	userProvider := mocks.NewMockUserProvider(ctrl)
-	sessionProvider := session.NewProvider(configuration.Session)
+	sessionProvider := session.NewProvider(configuration.Session, configuration.Domains, nil, nil)
 	providers := middlewares.Providers{
 		UserProvider:    userProvider,
 		SessionProvider: sessionProvider,
@@ -33,3 +37,134 @@ func TestShouldCallNextWithAutheliaCtx(t *testing.T) {
 
 	assert.True(t, nextCalled)
 }
+
+func TestShouldResolveRemoteIPFromTrustedProxies(t *testing.T) {
+	testCases := []struct {
+		description    string
+		trustedProxies []string
+		remoteAddr     string
+		xForwardedFor  string
+		expected       string
+	}{
+		{"first hop without trusted proxies", nil, "10.0.0.1", "1.1.1.1, 2.2.2.2", "1.1.1.1"},
+		{"remote address without trusted proxies", nil, "10.0.0.1", "", "10.0.0.1"},
+		{"untrusted remote address", []string{"10.0.0.0/8"}, "192.168.1.1", "1.1.1.1", "192.168.1.1"},
+		{"trusted remote address without header", []string{"10.0.0.0/8"}, "10.0.0.1", "", "10.0.0.1"},
+		{"first untrusted hop", []string{"10.0.0.0/8", "192.168.1.1"}, "10.0.0.1", "1.1.1.1, 2.2.2.2, 192.168.1.1",
+			"2.2.2.2"},
+		{"leftmost hop when all are trusted", []string{"10.0.0.0/8"}, "10.0.0.1", "10.0.0.3, 10.0.0.2", "10.0.0.3"},
+		{"last trusted hop before a malformed hop", []string{"10.0.0.0/8"}, "10.0.0.1", "1.1.1.1, abc, 10.0.0.2",
+			"10.0.0.2"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Init(&fasthttp.Request{}, &net.TCPAddr{IP: net.ParseIP(tc.remoteAddr)}, nil)
+
+			if tc.xForwardedFor != "" {
+				ctx.Request.Header.Set("X-Forwarded-For", tc.xForwardedFor)
+			}
+
+			configuration := schema.Configuration{}
+			configuration.Server.TrustedProxies = tc.trustedProxies
+
+			autheliaCtx, err := middlewares.NewAutheliaCtx(ctx, configuration, middlewares.Providers{})
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expected, autheliaCtx.RemoteIP().String())
+		})
+	}
+}
+
+func TestShouldResolveRemoteIPFromRepeatedXForwardedForHeaders(t *testing.T) {
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Init(&fasthttp.Request{}, &net.TCPAddr{IP: net.ParseIP("10.0.0.1")}, nil)
+	ctx.Request.Header.Add("X-Forwarded-For", "1.1.1.1, 2.2.2.2")
+	ctx.Request.Header.Add("X-Forwarded-For", "3.3.3.3, 10.0.0.2")
+
+	configuration := schema.Configuration{}
+	configuration.Server.TrustedProxies = []string{"10.0.0.0/8"}
+
+	autheliaCtx, err := middlewares.NewAutheliaCtx(ctx, configuration, middlewares.Providers{})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"1.1.1.1", "2.2.2.2", "3.3.3.3", "10.0.0.2"}, autheliaCtx.XForwardedFor())
+	assert.Equal(t, "3.3.3.3", autheliaCtx.RemoteIP().String())
+}
+
+// countingGeoIPProvider locates every IP in France and counts the lookups.
+type countingGeoIPProvider struct {
+	lookups int
+}
+
+func (p *countingGeoIPProvider) Country(ip net.IP) (string, error) {
+	p.lookups++
+	return "FR", nil
+}
+
+func TestShouldLookUpRemoteCountryOncePerRequest(t *testing.T) {
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Init(&fasthttp.Request{}, &net.TCPAddr{IP: net.ParseIP("1.1.1.1")}, nil)
+
+	geoIP := &countingGeoIPProvider{}
+
+	autheliaCtx, err := middlewares.NewAutheliaCtx(ctx, schema.Configuration{}, middlewares.Providers{GeoIP: geoIP})
+	require.NoError(t, err)
+
+	assert.Equal(t, "FR", autheliaCtx.RemoteCountry())
+	assert.Equal(t, "FR", autheliaCtx.RemoteCountry())
+	assert.Equal(t, 1, geoIP.lookups)
+}
+
+func TestShouldOnlyUpdateActiveSessionWhenOutdated(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.Ctx.Clock = &mock.Clock
+	now := mock.Clock.Now()
+
+	saveSession := func(level authentication.Level) {
+		userSession := mock.Ctx.GetSession()
+		userSession.Username = "john"
+		userSession.AuthenticationLevel = level
+
+		require.NoError(t, mock.Ctx.SaveSession(userSession))
+	}
+
+	lastActivity := func() (int64, authentication.Level) {
+		sessions, err := mock.Ctx.Providers.SessionProvider.GetActiveSessions("john")
+		require.NoError(t, err)
+		require.Len(t, sessions, 1)
+
+		return sessions[0].LastActivity, sessions[0].AuthenticationLevel
+	}
+
+	saveSession(authentication.OneFactor)
+
+	activity, level := lastActivity()
+	assert.Equal(t, now.Unix(), activity)
+	assert.Equal(t, authentication.OneFactor, level)
+
+	// The entry is not rewritten by the requests of the same interval.
+	mock.Clock.Set(now.Add(30 * time.Second))
+	saveSession(authentication.OneFactor)
+
+	activity, _ = lastActivity()
+	assert.Equal(t, now.Unix(), activity)
+
+	// The entry is updated as soon as the authentication level changes.
+	mock.Clock.Set(now.Add(40 * time.Second))
+	saveSession(authentication.TwoFactor)
+
+	activity, level = lastActivity()
+	assert.Equal(t, now.Add(40*time.Second).Unix(), activity)
+	assert.Equal(t, authentication.TwoFactor, level)
+
+	// The entry is updated once outdated.
+	mock.Clock.Set(now.Add(100 * time.Second))
+	saveSession(authentication.TwoFactor)
+
+	activity, _ = lastActivity()
+	assert.Equal(t, now.Add(100*time.Second).Unix(), activity)
+}
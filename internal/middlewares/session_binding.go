@@ -0,0 +1,112 @@
+package middlewares
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/session"
+)
+
+// getSessionBinding computes the fingerprint of the client of the request, the prefix of its IP address and the hash
+// of its user agent. The parts which are not bound are empty.
+func (c *AutheliaCtx) getSessionBinding(configuration schema.SessionBindingConfiguration) (ip, userAgent string) {
+	remoteIP := c.RemoteIP()
+
+	var prefix *net.IPNet
+
+	if remoteIPv4 := remoteIP.To4(); remoteIPv4 != nil {
+		if configuration.IPv4PrefixLength > 0 {
+			mask := net.CIDRMask(configuration.IPv4PrefixLength, 32)
+			prefix = &net.IPNet{IP: remoteIPv4.Mask(mask), Mask: mask}
+		}
+	} else if remoteIP != nil && configuration.IPv6PrefixLength > 0 {
+		mask := net.CIDRMask(configuration.IPv6PrefixLength, 128)
+		prefix = &net.IPNet{IP: remoteIP.Mask(mask), Mask: mask}
+	}
+
+	if prefix != nil {
+		ip = prefix.String()
+	}
+
+	if configuration.UserAgent {
+		sum := sha256.Sum256(c.UserAgent())
+		userAgent = hex.EncodeToString(sum[:])
+	}
+
+	return ip, userAgent
+}
+
+// BindSession records the fingerprint of the client of the request in the session when the sessions are bound to
+// their clients.
+func (c *AutheliaCtx) BindSession(userSession *session.UserSession) {
+	if c.Configuration.Session.Binding == nil {
+		return
+	}
+
+	userSession.BindingIP, userSession.BindingUserAgent = c.getSessionBinding(*c.Configuration.Session.Binding)
+}
+
+// isSessionBoundToClient returns true unless the session is bound to another client than the one of the request. The
+// sessions opened before the binding was enabled have no fingerprint and are not bound.
+func (c *AutheliaCtx) isSessionBoundToClient(userSession session.UserSession) bool {
+	if c.Configuration.Session.Binding == nil || userSession.Username == "" ||
+		(userSession.BindingIP == "" && userSession.BindingUserAgent == "") {
+		return true
+	}
+
+	ip, userAgent := c.getSessionBinding(*c.Configuration.Session.Binding)
+
+	return ip == userSession.BindingIP && userAgent == userSession.BindingUserAgent
+}
+
+// IsSessionBindingMismatched returns true when the session of the request was found bound to another client.
+func (c *AutheliaCtx) IsSessionBindingMismatched() bool {
+	return c.sessionBindingMismatch
+}
+
+// enforceSessionBinding checks the session loaded for the request is used by the client it is bound to. On mismatch,
+// a security event is logged and the stored session is either destroyed or degraded to unauthenticated so that its
+// legitimate client must log in again, the unauthenticated session is returned for the rest of the request.
+func (c *AutheliaCtx) enforceSessionBinding(userSession session.UserSession) (session.UserSession, error) {
+	if c.isSessionBoundToClient(userSession) {
+		return userSession, nil
+	}
+
+	configuration := c.Configuration.Session.Binding
+
+	if !c.sessionBindingMismatch {
+		c.sessionBindingMismatch = true
+
+		ip, userAgent := c.getSessionBinding(*configuration)
+
+		c.Logger.WithFields(logrus.Fields{
+			"event":              "session_binding_mismatch",
+			"username":           userSession.Username,
+			"bound_ip":           userSession.BindingIP,
+			"ip":                 ip,
+			"ip_matched":         ip == userSession.BindingIP,
+			"user_agent_matched": userAgent == userSession.BindingUserAgent,
+			"action":             configuration.Action,
+		}).Warnf("Session of user %s is used by a client which does not match the one it is bound to, possible cookie theft detected",
+			userSession.Username)
+	}
+
+	if configuration.Action == schema.SessionBindingActionDestroy {
+		return session.NewDefaultUserSession(), c.Providers.SessionProvider.DestroySession(c.RequestCtx)
+	}
+
+	if userSession.AuthenticationLevel == authentication.NotAuthenticated {
+		return userSession, nil
+	}
+
+	// The session is saved without updating the index of the active sessions which keeps the client of the user.
+	userSession.AuthenticationLevel = authentication.NotAuthenticated
+	userSession.AuthenticationMethods = nil
+
+	return userSession, c.Providers.SessionProvider.SaveSession(c.RequestCtx, userSession)
+}
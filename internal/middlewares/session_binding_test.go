@@ -0,0 +1,160 @@
+package middlewares_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/mocks"
+	"github.com/authelia/authelia/internal/session"
+)
+
+func TestShouldBindSessionToClient(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.Ctx.Configuration.Session.Binding = &schema.SessionBindingConfiguration{
+		IPv4PrefixLength: 24,
+		IPv6PrefixLength: 64,
+		UserAgent:        true,
+	}
+
+	mock.Ctx.Request.Header.Set("X-Forwarded-For", "192.168.1.42")
+	mock.Ctx.Request.Header.SetUserAgent("Firefox")
+
+	sum := sha256.Sum256([]byte("Firefox"))
+
+	userSession := session.NewDefaultUserSession()
+	mock.Ctx.BindSession(&userSession)
+	assert.Equal(t, "192.168.1.0/24", userSession.BindingIP)
+	assert.Equal(t, hex.EncodeToString(sum[:]), userSession.BindingUserAgent)
+
+	mock.Ctx.Request.Header.Set("X-Forwarded-For", "2001:db8:1:2:3:4:5:6")
+
+	mock.Ctx.BindSession(&userSession)
+	assert.Equal(t, "2001:db8:1:2::/64", userSession.BindingIP)
+
+	// Only the IPv4 addresses are bound.
+	mock.Ctx.Configuration.Session.Binding.IPv6PrefixLength = 0
+	mock.Ctx.Configuration.Session.Binding.UserAgent = false
+
+	mock.Ctx.BindSession(&userSession)
+	assert.Equal(t, "", userSession.BindingIP)
+	assert.Equal(t, "", userSession.BindingUserAgent)
+}
+
+func TestShouldNotBindSessionByDefault(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	userSession := session.NewDefaultUserSession()
+	mock.Ctx.BindSession(&userSession)
+
+	assert.Equal(t, "", userSession.BindingIP)
+	assert.Equal(t, "", userSession.BindingUserAgent)
+}
+
+func newBoundSessionMock(t *testing.T, action string) *mocks.MockAutheliaCtx {
+	mock := mocks.NewMockAutheliaCtx(t)
+
+	mock.Ctx.Configuration.Session.Binding = &schema.SessionBindingConfiguration{
+		IPv4PrefixLength: 24,
+		UserAgent:        true,
+		Action:           action,
+	}
+
+	// The requests are forwarded by a trusted proxy.
+	mock.Ctx.Configuration.Server.TrustedProxies = []string{"192.168.1.1"}
+	mock.Ctx.Networks = middlewares.NewNetworks(mock.Ctx.Configuration.Server)
+	mock.Ctx.SetRemoteAddr(&net.TCPAddr{IP: net.ParseIP("192.168.1.1")})
+
+	mock.Ctx.Request.Header.Set("X-Forwarded-For", "10.0.0.5")
+	mock.Ctx.Request.Header.SetUserAgent("Firefox")
+
+	userSession := mock.Ctx.GetSession()
+	userSession.Username = "john"
+	userSession.AuthenticationLevel = authentication.TwoFactor
+	userSession.AuthenticationMethods = []string{authentication.TOTP}
+	mock.Ctx.BindSession(&userSession)
+
+	require.NoError(t, mock.Ctx.SaveSession(userSession))
+
+	// The following requests come from another client.
+	mock.Ctx.Request.Header.Set("X-Forwarded-For", "10.1.0.5")
+
+	return mock
+}
+
+func TestShouldDegradeSessionLoadedByAnotherClient(t *testing.T) {
+	mock := newBoundSessionMock(t, schema.SessionBindingActionDegrade)
+	defer mock.Close()
+
+	userSession := mock.Ctx.GetSession()
+	assert.True(t, mock.Ctx.IsSessionBindingMismatched())
+	assert.Equal(t, "john", userSession.Username)
+	assert.Equal(t, authentication.NotAuthenticated, userSession.AuthenticationLevel)
+	assert.Nil(t, userSession.AuthenticationMethods)
+
+	// The stored session is degraded as well, for the portal to ask its legitimate client to log in again.
+	stored, err := mock.Ctx.Providers.SessionProvider.GetSession(mock.Ctx.RequestCtx)
+	require.NoError(t, err)
+	assert.Equal(t, authentication.NotAuthenticated, stored.AuthenticationLevel)
+
+	// The session saved for another client does not update the index of the active sessions.
+	require.NoError(t, mock.Ctx.SaveSession(userSession))
+
+	sessions, err := mock.Ctx.Providers.SessionProvider.GetActiveSessions("john")
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "10.0.0.5", sessions[0].IP)
+	assert.Equal(t, authentication.TwoFactor, sessions[0].AuthenticationLevel)
+
+	// The event is only logged once per request.
+	mock.Ctx.GetSession()
+
+	var events []*logrus.Entry
+
+	for _, entry := range mock.Hook.AllEntries() {
+		if entry.Data["event"] == "session_binding_mismatch" {
+			events = append(events, entry)
+		}
+	}
+
+	require.Len(t, events, 1)
+	assert.Equal(t, logrus.WarnLevel, events[0].Level)
+	assert.Equal(t, false, events[0].Data["ip_matched"])
+	assert.Equal(t, true, events[0].Data["user_agent_matched"])
+}
+
+func TestShouldDestroySessionLoadedByAnotherClient(t *testing.T) {
+	mock := newBoundSessionMock(t, schema.SessionBindingActionDestroy)
+	defer mock.Close()
+
+	userSession := mock.Ctx.GetSession()
+	assert.True(t, mock.Ctx.IsSessionBindingMismatched())
+	assert.Equal(t, "", userSession.Username)
+
+	sessions, err := mock.Ctx.Providers.SessionProvider.GetActiveSessions("john")
+	require.NoError(t, err)
+	assert.Len(t, sessions, 0)
+}
+
+func TestShouldLoadSessionUsedByItsClient(t *testing.T) {
+	mock := newBoundSessionMock(t, schema.SessionBindingActionDestroy)
+	defer mock.Close()
+
+	// The client moved within the same network.
+	mock.Ctx.Request.Header.Set("X-Forwarded-For", "10.0.0.200")
+
+	userSession := mock.Ctx.GetSession()
+	assert.False(t, mock.Ctx.IsSessionBindingMismatched())
+	assert.Equal(t, authentication.TwoFactor, userSession.AuthenticationLevel)
+}
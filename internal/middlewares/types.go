@@ -1,6 +1,8 @@
 package middlewares
 
 import (
+	"net"
+
 	jwt "github.com/dgrijalva/jwt-go"
 	"github.com/sirupsen/logrus"
 	"github.com/valyala/fasthttp"
@@ -8,6 +10,7 @@ import (
 	"github.com/authelia/authelia/internal/authentication"
 	"github.com/authelia/authelia/internal/authorization"
 	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/geoip"
 	"github.com/authelia/authelia/internal/notification"
 	"github.com/authelia/authelia/internal/regulation"
 	"github.com/authelia/authelia/internal/session"
@@ -22,8 +25,22 @@ type AutheliaCtx struct {
 	Logger        *logrus.Entry
 	Providers     Providers
 	Configuration schema.Configuration
+	Networks      Networks
 
 	Clock utils.Clock
+
+	// Whether the session of the request is bound to another client, see enforceSessionBinding.
+	sessionBindingMismatch bool
+
+	// The country of the remote IP, looked up once per request, see RemoteCountry.
+	remoteCountry       string
+	remoteCountryLoaded bool
+}
+
+// Networks are the networks of the configuration checked on each request, they are parsed once at startup.
+type Networks struct {
+	TrustedProxies  []*net.IPNet
+	ExplainNetworks []*net.IPNet
 }
 
 // Providers contain all provider provided to Authelia.
@@ -35,6 +52,9 @@ type Providers struct {
 	UserProvider    authentication.UserProvider
 	StorageProvider storage.Provider
 	Notifier        notification.Notifier
+
+	// GeoIP is nil when no GeoIP database is configured.
+	GeoIP geoip.Provider
 }
 
 // RequestHandler represents an Authelia request handler.
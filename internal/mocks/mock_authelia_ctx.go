@@ -105,10 +105,10 @@ func NewMockAutheliaCtx(t *testing.T) *MockAutheliaCtx {
 	providers.Notifier = mockAuthelia.NotifierMock
 
 	providers.Authorizer = authorization.NewAuthorizer(
-		configuration.AccessControl)
+		configuration.AccessControl, &mockAuthelia.Clock)
 
 	providers.SessionProvider = session.NewProvider(
-		configuration.Session)
+		configuration.Session, configuration.Domains, configuration.Server.TrustedProxies, providers.StorageProvider)
 
 	providers.Regulator = regulation.NewRegulator(configuration.Regulation, providers.StorageProvider, &mockAuthelia.Clock)
 
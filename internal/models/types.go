@@ -11,3 +11,19 @@ type AuthenticationAttempt struct {
 	// The time of the attempt.
 	Time time.Time
 }
+
+// AccessGrant represent a temporary access to a domain granted to a subject on top of the access control rules.
+type AccessGrant struct {
+	// The identifier of the grant.
+	ID string
+	// The user or group the access is granted to, of the form 'user:<username>' or 'group:<groupname>'.
+	Subject string
+	// The domain the access is granted to.
+	Domain string
+	// The pattern the path of the resource must match, empty to grant the access to any resource of the domain.
+	Resource string
+	// The policy applied to the subject.
+	Policy string
+	// The time the grant expires.
+	ExpiresAt time.Time
+}
@@ -4,9 +4,11 @@ import (
 	"io/ioutil"
 	"net"
 	"os"
+	"os/signal"
 	"runtime"
 	"strconv"
 	"strings"
+	"syscall"
 
 	duoapi "github.com/duosecurity/duo_api_golang"
 	"github.com/fasthttp/router"
@@ -26,12 +28,13 @@ import (
 func StartServer(configuration schema.Configuration, providers middlewares.Providers) {
 	autheliaMiddleware := middlewares.AutheliaMiddleware(configuration, providers)
 	embeddedAssets := "/public_html"
-	rememberMe := strconv.FormatBool(configuration.Session.RememberMeDuration != "0")
 	resetPassword := strconv.FormatBool(!configuration.AuthenticationBackend.DisableResetPassword)
 
 	rootFiles := []string{"favicon.ico", "manifest.json", "robots.txt"}
 
-	serveIndexHandler := ServeIndex(embeddedAssets, configuration.Server.Path, rememberMe, resetPassword)
+	// The remember me checkbox is displayed when the domain of the portal has a remember me duration.
+	serveIndexHandler := ServeIndex(embeddedAssets, configuration.Server.Path, providers.SessionProvider.GetRememberMe,
+		resetPassword)
 
 	r := router.New()
 	r.GET("/", serveIndexHandler)
@@ -71,6 +74,12 @@ func StartServer(configuration schema.Configuration, providers middlewares.Provi
 	r.POST("/api/user/info/2fa_method", autheliaMiddleware(
 		middlewares.RequireFirstFactor(handlers.MethodPreferencePost)))
 
+	// Active sessions of the user.
+	r.GET("/api/user/sessions", autheliaMiddleware(
+		middlewares.RequireFirstFactor(handlers.UserSessionsGet)))
+	r.DELETE("/api/user/sessions/{id}", autheliaMiddleware(
+		middlewares.RequireFirstFactor(handlers.UserSessionDelete)))
+
 	// TOTP related endpoints.
 	r.POST("/api/secondfactor/totp/identity/start", autheliaMiddleware(
 		middlewares.RequireFirstFactor(handlers.SecondFactorTOTPIdentityStart)))
@@ -160,11 +169,35 @@ func StartServer(configuration schema.Configuration, providers middlewares.Provi
 		}
 	}
 
+	// On SIGINT or SIGTERM, the server stops accepting connections and finishes the pending requests before the
+	// sessions are closed.
+	signalChannel := make(chan os.Signal, 1)
+	signal.Notify(signalChannel, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		sig := <-signalChannel
+		logging.Logger().Infof("Received signal %s, shutting down", sig)
+
+		if err := server.Shutdown(); err != nil {
+			logging.Logger().Errorf("Unable to shut down the server: %s", err)
+		}
+	}()
+
 	if configuration.TLSCert != "" && configuration.TLSKey != "" {
 		logging.Logger().Infof("Authelia is listening for TLS connections on %s%s", addrPattern, configuration.Server.Path)
-		logging.Logger().Fatal(server.ServeTLS(listener, configuration.TLSCert, configuration.TLSKey))
+		err = server.ServeTLS(listener, configuration.TLSCert, configuration.TLSKey)
 	} else {
 		logging.Logger().Infof("Authelia is listening for non-TLS connections on %s%s", addrPattern, configuration.Server.Path)
-		logging.Logger().Fatal(server.Serve(listener))
+		err = server.Serve(listener)
+	}
+
+	if err != nil {
+		logging.Logger().Fatal(err)
+	}
+
+	providers.Authorizer.StopGrantsReload()
+
+	if err := providers.SessionProvider.Close(); err != nil {
+		logging.Logger().Errorf("Unable to save the sessions: %s", err)
 	}
 }
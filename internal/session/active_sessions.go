@@ -0,0 +1,189 @@
+package session
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// activeSessionsIndex is the index of the active sessions of a user kept in the session store, indexed by the public
+// identifiers of the sessions.
+type activeSessionsIndex map[string]activeSessionsIndexEntry
+
+// activeSessionsIndexEntry is an entry of the index, the key of the session in the store is kept private since it
+// contains the session ID which is the value of the cookie.
+type activeSessionsIndexEntry struct {
+	ActiveSession
+	SessionID string `json:"session_id"`
+}
+
+// getActiveSessionID computes the public identifier of a session from its key in the store.
+func getActiveSessionID(storeKey []byte) string {
+	sum := sha256.Sum256(storeKey)
+	return hex.EncodeToString(sum[:])[:activeSessionIDLength]
+}
+
+func getActiveSessionsIndexKey(username string) []byte {
+	return []byte(activeSessionsIndexKeyPrefix + username)
+}
+
+// GetActiveSessionID return the public identifier of the session of the request.
+func (p *Provider) GetActiveSessionID(ctx *fasthttp.RequestCtx) (string, error) {
+	store, err := p.getSessionHolder(ctx).Get(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return getActiveSessionID(p.getStoreKey(ctx, store.GetSessionID())), nil
+}
+
+// UpdateActiveSession records the session of the request in the index of the active sessions of the user.
+func (p *Provider) UpdateActiveSession(ctx *fasthttp.RequestCtx, userSession UserSession, ip string, now time.Time) error {
+	if userSession.Username == "" {
+		return nil
+	}
+
+	store, err := p.getSessionHolder(ctx).Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	index, err := p.activeSessions.Load(userSession.Username)
+	if err != nil {
+		return err
+	}
+
+	storeKey := p.getStoreKey(ctx, store.GetSessionID())
+	id := getActiveSessionID(storeKey)
+
+	entry, ok := index[id]
+	if !ok {
+		entry = activeSessionsIndexEntry{
+			ActiveSession: ActiveSession{ID: id, CreatedAt: now.Unix()},
+			SessionID:     string(storeKey),
+		}
+	}
+
+	entry.LastActivity = now.Unix()
+	entry.IP = ip
+	entry.UserAgent = string(ctx.UserAgent())
+	entry.AuthenticationLevel = userSession.AuthenticationLevel
+
+	return p.activeSessions.Save(userSession.Username, entry)
+}
+
+// moveActiveSession moves the entry of a regenerated session to its new key in the store.
+func (p *Provider) moveActiveSession(username string, storeKey, newStoreKey []byte) error {
+	index, err := p.activeSessions.Load(username)
+	if err != nil {
+		return err
+	}
+
+	entry, ok := index[getActiveSessionID(storeKey)]
+	if !ok {
+		return nil
+	}
+
+	oldID := entry.ID
+
+	entry.ID = getActiveSessionID(newStoreKey)
+	entry.SessionID = string(newStoreKey)
+
+	if err := p.activeSessions.Save(username, entry); err != nil {
+		return err
+	}
+
+	return p.activeSessions.Delete(username, oldID)
+}
+
+// removeActiveSession removes the entry of a destroyed session from the index.
+func (p *Provider) removeActiveSession(username string, storeKey []byte) error {
+	return p.activeSessions.Delete(username, getActiveSessionID(storeKey))
+}
+
+// RemoveActiveSession removes the entry of a session from the index of the active sessions of a user, the session itself
+// is left untouched.
+func (p *Provider) RemoveActiveSession(username, id string) error {
+	return p.activeSessions.Delete(username, id)
+}
+
+// GetActiveSessions retrieve the active sessions of a user, the most recently active first. The sessions which expired
+// since they were recorded are pruned from the index.
+func (p *Provider) GetActiveSessions(username string) ([]ActiveSession, error) {
+	index, err := p.activeSessions.Load(username)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]ActiveSession, 0, len(index))
+
+	for id, entry := range index {
+		data, err := p.store.Get([]byte(entry.SessionID))
+		if err != nil {
+			return nil, err
+		}
+
+		if len(data) == 0 {
+			if err := p.activeSessions.Delete(username, id); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		sessions = append(sessions, entry.ActiveSession)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastActivity > sessions[j].LastActivity
+	})
+
+	return sessions, nil
+}
+
+// RevokeActiveSession destroys the session of a user with the given public identifier, false is returned if the user
+// has no such session.
+func (p *Provider) RevokeActiveSession(username, id string) (bool, error) {
+	index, err := p.activeSessions.Load(username)
+	if err != nil {
+		return false, err
+	}
+
+	entry, ok := index[id]
+	if !ok {
+		return false, nil
+	}
+
+	if err := p.store.Destroy([]byte(entry.SessionID)); err != nil {
+		return false, err
+	}
+
+	return true, p.activeSessions.Delete(username, id)
+}
+
+// RevokeActiveSessions destroys all the sessions of a user and returns the number of revoked sessions.
+func (p *Provider) RevokeActiveSessions(username string) (int, error) {
+	index, err := p.activeSessions.Load(username)
+	if err != nil {
+		return 0, err
+	}
+
+	revoked := 0
+
+	for id, entry := range index {
+		if err := p.store.Destroy([]byte(entry.SessionID)); err != nil {
+			return revoked, err
+		}
+
+		if err := p.activeSessions.Delete(username, id); err != nil {
+			return revoked, err
+		}
+
+		revoked++
+	}
+
+	return revoked, nil
+}
@@ -0,0 +1,108 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+)
+
+// The indexes of the active sessions are kept in Redis as hashes with this prefix, each field being the entry of a
+// session.
+const redisActiveSessionsKeyPrefix = "authelia-active-sessions:"
+
+// redisActiveSessionsStore keeps the index of each user as a Redis hash, the entries are written with single commands
+// which are atomic across the instances sharing the Redis server.
+type redisActiveSessionsStore struct {
+	client     *goredis.Client
+	expiration time.Duration
+}
+
+// newRedisActiveSessionsStore creates a store keeping the indexes with the client of the Redis server holding the
+// sessions.
+func newRedisActiveSessionsStore(client *goredis.Client, expiration time.Duration) *redisActiveSessionsStore {
+	return &redisActiveSessionsStore{client: client, expiration: expiration}
+}
+
+// newRedisClient creates a client connecting to the Redis server, or the master elected by Sentinel, which holds the
+// sessions.
+func newRedisClient(providerConfig ProviderConfig) *goredis.Client {
+	var client *goredis.Client
+
+	if config := providerConfig.redisSentinelConfig; config != nil {
+		client = goredis.NewFailoverClient(&goredis.FailoverOptions{
+			MasterName:       config.MasterName,
+			SentinelAddrs:    config.SentinelAddrs,
+			SentinelPassword: config.SentinelPassword,
+			Username:         config.Username,
+			Password:         config.Password,
+			DB:               config.DB,
+			PoolSize:         config.PoolSize,
+			IdleTimeout:      config.IdleTimeout,
+			DialTimeout:      config.DialTimeout,
+			ReadTimeout:      config.ReadTimeout,
+			WriteTimeout:     config.WriteTimeout,
+			TLSConfig:        config.TLSConfig,
+		})
+	} else {
+		config := providerConfig.redisConfig
+		client = goredis.NewClient(&goredis.Options{
+			Network:      config.Network,
+			Addr:         config.Addr,
+			Username:     config.Username,
+			Password:     config.Password,
+			DB:           config.DB,
+			PoolSize:     config.PoolSize,
+			IdleTimeout:  config.IdleTimeout,
+			DialTimeout:  config.DialTimeout,
+			ReadTimeout:  config.ReadTimeout,
+			WriteTimeout: config.WriteTimeout,
+			TLSConfig:    config.TLSConfig,
+		})
+	}
+
+	return client
+}
+
+// Load returns the index of the active sessions of a user.
+func (s *redisActiveSessionsStore) Load(username string) (activeSessionsIndex, error) {
+	fields, err := s.client.HGetAll(context.Background(), redisActiveSessionsKeyPrefix+username).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([][]byte, 0, len(fields))
+	for _, data := range fields {
+		entries = append(entries, []byte(data))
+	}
+
+	return decodeActiveSessionsIndex(entries)
+}
+
+// Save records the entry of a session in the index of a user and extends the expiration of the index.
+func (s *redisActiveSessionsStore) Save(username string, entry activeSessionsIndexEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	key := redisActiveSessionsKeyPrefix + username
+
+	_, err = s.client.TxPipelined(context.Background(), func(pipe goredis.Pipeliner) error {
+		pipe.HSet(context.Background(), key, entry.ID, data)
+
+		if s.expiration > 0 {
+			pipe.Expire(context.Background(), key, s.expiration)
+		}
+
+		return nil
+	})
+
+	return err
+}
+
+// Delete removes the entry of a session from the index of a user.
+func (s *redisActiveSessionsStore) Delete(username, id string) error {
+	return s.client.HDel(context.Background(), redisActiveSessionsKeyPrefix+username, id).Err()
+}
@@ -0,0 +1,156 @@
+package session
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	fasthttpsession "github.com/fasthttp/session/v2"
+)
+
+// activeSessionsStore keeps the indexes of the active sessions of the users. Each entry is saved and deleted on its own
+// so that concurrent updates of the sessions of a user, possibly by several instances, don't overwrite each other. The
+// entries last as long as the longest session.
+type activeSessionsStore interface {
+	Load(username string) (activeSessionsIndex, error)
+	Save(username string, entry activeSessionsIndexEntry) error
+	Delete(username, id string) error
+}
+
+// memoryActiveSessionsStore keeps the index of each user as a single item of the session store, it is only used with
+// the memory providers which are not shared between instances. The index is then part of the memory snapshot.
+type memoryActiveSessionsStore struct {
+	store      fasthttpsession.Provider
+	expiration time.Duration
+	mutex      sync.Mutex
+}
+
+func newMemoryActiveSessionsStore(store fasthttpsession.Provider, expiration time.Duration) *memoryActiveSessionsStore {
+	return &memoryActiveSessionsStore{store: store, expiration: expiration}
+}
+
+// Load returns the index of the active sessions of a user.
+func (s *memoryActiveSessionsStore) Load(username string) (activeSessionsIndex, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.load(username)
+}
+
+// Save records the entry of a session in the index of a user.
+func (s *memoryActiveSessionsStore) Save(username string, entry activeSessionsIndexEntry) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	index, err := s.load(username)
+	if err != nil {
+		return err
+	}
+
+	index[entry.ID] = entry
+
+	return s.save(username, index)
+}
+
+// Delete removes the entry of a session from the index of a user.
+func (s *memoryActiveSessionsStore) Delete(username, id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	index, err := s.load(username)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := index[id]; !ok {
+		return nil
+	}
+
+	delete(index, id)
+
+	if len(index) == 0 {
+		return s.store.Destroy(getActiveSessionsIndexKey(username))
+	}
+
+	return s.save(username, index)
+}
+
+func (s *memoryActiveSessionsStore) load(username string) (activeSessionsIndex, error) {
+	data, err := s.store.Get(getActiveSessionsIndexKey(username))
+	if err != nil {
+		return nil, err
+	}
+
+	index := activeSessionsIndex{}
+
+	if len(data) == 0 {
+		return index, nil
+	}
+
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, err
+	}
+
+	return index, nil
+}
+
+func (s *memoryActiveSessionsStore) save(username string, index activeSessionsIndex) error {
+	data, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+
+	return s.store.Save(getActiveSessionsIndexKey(username), data, s.expiration)
+}
+
+// sqlActiveSessionsStore keeps each entry of the indexes as a row of the SQL storage.
+type sqlActiveSessionsStore struct {
+	storage    SQLStorage
+	expiration time.Duration
+}
+
+func newSQLActiveSessionsStore(storage SQLStorage, expiration time.Duration) *sqlActiveSessionsStore {
+	return &sqlActiveSessionsStore{storage: storage, expiration: expiration}
+}
+
+// Load returns the index of the active sessions of a user.
+func (s *sqlActiveSessionsStore) Load(username string) (activeSessionsIndex, error) {
+	entries, err := s.storage.LoadActiveSessions(username, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeActiveSessionsIndex(entries)
+}
+
+// Save records the entry of a session in the index of a user.
+func (s *sqlActiveSessionsStore) Save(username string, entry activeSessionsIndexEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return s.storage.SaveActiveSession(username, entry.ID, data, storeExpiresAt(s.expiration))
+}
+
+// Delete removes the entry of a session from the index of a user.
+func (s *sqlActiveSessionsStore) Delete(username, id string) error {
+	return s.storage.DeleteActiveSession(username, id)
+}
+
+// decodeActiveSessionsIndex decodes the entries of an index saved one by one.
+func decodeActiveSessionsIndex(entries [][]byte) (activeSessionsIndex, error) {
+	index := activeSessionsIndex{}
+
+	for _, data := range entries {
+		var entry activeSessionsIndexEntry
+
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, err
+		}
+
+		index[entry.ID] = entry
+	}
+
+	return index, nil
+}
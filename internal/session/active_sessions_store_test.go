@@ -0,0 +1,53 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/storage"
+)
+
+func TestShouldKeepActiveSessionsAsRowsOfSQLStorage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storageMock := storage.NewMockProvider(ctrl)
+	store := newSQLActiveSessionsStore(storageMock, time.Hour)
+
+	entry := activeSessionsIndexEntry{
+		ActiveSession: ActiveSession{ID: "abc", CreatedAt: 1600000000, AuthenticationLevel: authentication.OneFactor},
+		SessionID:     "session-abc",
+	}
+
+	before := time.Now()
+
+	// Each entry is saved on its own, the other entries of the user are left untouched.
+	storageMock.EXPECT().
+		SaveActiveSession(testUsername, "abc", gomock.Any(), gomock.Any()).
+		DoAndReturn(func(username, id string, data []byte, expiresAt time.Time) error {
+			assert.JSONEq(t, `{"id":"abc","created_at":1600000000,"last_activity":0,"ip":"","user_agent":"",`+
+				`"authentication_level":1,"session_id":"session-abc"}`, string(data))
+			assert.False(t, expiresAt.Before(before.Add(time.Hour)))
+
+			return nil
+		})
+	require.NoError(t, store.Save(testUsername, entry))
+
+	storageMock.EXPECT().
+		LoadActiveSessions(testUsername, gomock.Any()).
+		Return([][]byte{[]byte(`{"id":"abc","session_id":"session-abc"}`), []byte(`{"id":"def","session_id":"session-def"}`)}, nil)
+
+	index, err := store.Load(testUsername)
+	require.NoError(t, err)
+	require.Len(t, index, 2)
+	assert.Equal(t, "session-abc", index["abc"].SessionID)
+	assert.Equal(t, "session-def", index["def"].SessionID)
+
+	storageMock.EXPECT().DeleteActiveSession(testUsername, "abc").Return(nil)
+	require.NoError(t, store.Delete(testUsername, "abc"))
+}
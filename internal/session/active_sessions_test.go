@@ -0,0 +1,210 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+func newActiveSessionsTestProvider() *Provider {
+	configuration := schema.SessionConfiguration{}
+	configuration.Domain = testDomain
+	configuration.Name = testName
+	configuration.Expiration = testExpiration
+
+	return NewProvider(configuration, nil, nil, nil)
+}
+
+func loginActiveSession(t *testing.T, provider *Provider, userAgent string, now time.Time) *fasthttp.RequestCtx {
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetUserAgent(userAgent)
+
+	userSession, err := provider.GetSession(ctx)
+	require.NoError(t, err)
+
+	userSession.Username = testUsername
+	userSession.AuthenticationLevel = authentication.OneFactor
+
+	require.NoError(t, provider.SaveSession(ctx, userSession))
+	require.NoError(t, provider.UpdateActiveSession(ctx, userSession, "192.168.0.1", now))
+
+	return ctx
+}
+
+func TestShouldListActiveSessionsOfUser(t *testing.T) {
+	provider := newActiveSessionsTestProvider()
+	now := time.Unix(1600000000, 0)
+
+	firstCtx := loginActiveSession(t, provider, "firefox", now)
+	secondCtx := loginActiveSession(t, provider, "chrome", now.Add(time.Minute))
+
+	firstID, err := provider.GetActiveSessionID(firstCtx)
+	require.NoError(t, err)
+
+	secondID, err := provider.GetActiveSessionID(secondCtx)
+	require.NoError(t, err)
+
+	sessions, err := provider.GetActiveSessions(testUsername)
+	require.NoError(t, err)
+
+	assert.Equal(t, []ActiveSession{
+		{
+			ID:                  secondID,
+			CreatedAt:           now.Add(time.Minute).Unix(),
+			LastActivity:        now.Add(time.Minute).Unix(),
+			IP:                  "192.168.0.1",
+			UserAgent:           "chrome",
+			AuthenticationLevel: authentication.OneFactor,
+		},
+		{
+			ID:                  firstID,
+			CreatedAt:           now.Unix(),
+			LastActivity:        now.Unix(),
+			IP:                  "192.168.0.1",
+			UserAgent:           "firefox",
+			AuthenticationLevel: authentication.OneFactor,
+		},
+	}, sessions)
+
+	sessions, err = provider.GetActiveSessions("harry")
+	require.NoError(t, err)
+	assert.Len(t, sessions, 0)
+}
+
+func TestShouldUpdateLastActivityOfActiveSession(t *testing.T) {
+	provider := newActiveSessionsTestProvider()
+	now := time.Unix(1600000000, 0)
+
+	ctx := loginActiveSession(t, provider, "firefox", now)
+
+	userSession, err := provider.GetSession(ctx)
+	require.NoError(t, err)
+
+	userSession.AuthenticationLevel = authentication.TwoFactor
+	require.NoError(t, provider.UpdateActiveSession(ctx, userSession, "192.168.0.2", now.Add(time.Hour)))
+
+	sessions, err := provider.GetActiveSessions(testUsername)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+
+	assert.Equal(t, now.Unix(), sessions[0].CreatedAt)
+	assert.Equal(t, now.Add(time.Hour).Unix(), sessions[0].LastActivity)
+	assert.Equal(t, "192.168.0.2", sessions[0].IP)
+	assert.Equal(t, authentication.TwoFactor, sessions[0].AuthenticationLevel)
+}
+
+func TestShouldRevokeActiveSession(t *testing.T) {
+	provider := newActiveSessionsTestProvider()
+	now := time.Unix(1600000000, 0)
+
+	firstCtx := loginActiveSession(t, provider, "firefox", now)
+	loginActiveSession(t, provider, "chrome", now)
+
+	firstID, err := provider.GetActiveSessionID(firstCtx)
+	require.NoError(t, err)
+
+	revoked, err := provider.RevokeActiveSession(testUsername, firstID)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	revoked, err = provider.RevokeActiveSession(testUsername, firstID)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	sessions, err := provider.GetActiveSessions(testUsername)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "chrome", sessions[0].UserAgent)
+
+	// The revoked session is not authenticated anymore.
+	userSession, err := provider.GetSession(firstCtx)
+	require.NoError(t, err)
+	assert.Equal(t, "", userSession.Username)
+}
+
+func TestShouldRevokeAllActiveSessionsOfUser(t *testing.T) {
+	provider := newActiveSessionsTestProvider()
+	now := time.Unix(1600000000, 0)
+
+	firstCtx := loginActiveSession(t, provider, "firefox", now)
+	secondCtx := loginActiveSession(t, provider, "chrome", now)
+
+	revoked, err := provider.RevokeActiveSessions(testUsername)
+	require.NoError(t, err)
+	assert.Equal(t, 2, revoked)
+
+	sessions, err := provider.GetActiveSessions(testUsername)
+	require.NoError(t, err)
+	assert.Len(t, sessions, 0)
+
+	for _, ctx := range []*fasthttp.RequestCtx{firstCtx, secondCtx} {
+		userSession, err := provider.GetSession(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "", userSession.Username)
+	}
+}
+
+func TestShouldRemoveDestroyedSessionFromActiveSessions(t *testing.T) {
+	provider := newActiveSessionsTestProvider()
+	now := time.Unix(1600000000, 0)
+
+	ctx := loginActiveSession(t, provider, "firefox", now)
+	loginActiveSession(t, provider, "chrome", now)
+
+	require.NoError(t, provider.DestroySession(ctx))
+
+	sessions, err := provider.GetActiveSessions(testUsername)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "chrome", sessions[0].UserAgent)
+}
+
+func TestShouldKeepActiveSessionWhenRegenerated(t *testing.T) {
+	provider := newActiveSessionsTestProvider()
+	now := time.Unix(1600000000, 0)
+
+	ctx := loginActiveSession(t, provider, "firefox", now)
+
+	oldID, err := provider.GetActiveSessionID(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, provider.RegenerateSession(ctx))
+
+	newID, err := provider.GetActiveSessionID(ctx)
+	require.NoError(t, err)
+	assert.NotEqual(t, oldID, newID)
+
+	sessions, err := provider.GetActiveSessions(testUsername)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, newID, sessions[0].ID)
+	assert.Equal(t, now.Unix(), sessions[0].CreatedAt)
+}
+
+func TestShouldPruneExpiredActiveSessions(t *testing.T) {
+	provider := newActiveSessionsTestProvider()
+	now := time.Unix(1600000000, 0)
+
+	ctx := loginActiveSession(t, provider, "firefox", now)
+	loginActiveSession(t, provider, "chrome", now)
+
+	// Simulate the expiration of the session in the store.
+	store, err := provider.sessionHolder.Get(ctx)
+	require.NoError(t, err)
+	require.NoError(t, provider.store.Destroy(store.GetSessionID()))
+
+	sessions, err := provider.GetActiveSessions(testUsername)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "chrome", sessions[0].UserAgent)
+
+	index, err := provider.activeSessions.Load(testUsername)
+	require.NoError(t, err)
+	assert.Len(t, index, 1)
+}
@@ -1,8 +1,39 @@
 package session
 
+import "time"
+
 const userSessionStorerKey = "UserSession"
 
+// The sessions decrypted with a previous secret are flagged with this key until they are saved again.
+const previousSecretStorerKey = "PreviousSecret"
+
+// The indexes of the active sessions of the users are kept in the session store with this prefix, which cannot collide
+// with the generated session IDs.
+const activeSessionsIndexKeyPrefix = "active-sessions:"
+
+// The entry of a session in the index of the active sessions is updated at most once per interval, unless the user
+// logs in or changes authentication level.
+const activeSessionUpdateInterval = time.Minute
+
+// The length of the public identifiers of the sessions.
+const activeSessionIDLength = 16
+
+// The number of stored sessions still encrypted with the previous secrets is logged at this interval.
+const previousSecretsReportInterval = 10 * time.Minute
+
+// The sessions are kept by the Redis provider under keys with this prefix.
+const redisSessionKeyPrefix = "authelia-session"
+
+// The expired sessions of the memory store are deleted at this interval.
+const memoryStoreGCInterval = time.Minute
+
+const xForwardedHostHeader = "X-Forwarded-Host"
+const xOriginalURLHeader = "X-Original-URL"
+
 const testDomain = "example.com"
 const testExpiration = "40"
 const testName = "my_session"
 const testUsername = "john"
+
+// memorySnapshotKeyContext is the context of the derivation of the key encrypting the snapshot of the memory provider.
+const memorySnapshotKeyContext = "authelia-memory-snapshot"
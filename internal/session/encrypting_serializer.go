@@ -12,12 +12,23 @@ import (
 // EncryptingSerializer a serializer encrypting the data with AES-GCM with 256-bit keys.
 type EncryptingSerializer struct {
 	key [32]byte
+
+	// The keys derived from the previous secrets, they are only used to decrypt the sessions created before the
+	// rotation of the secret.
+	previousKeys [][32]byte
 }
 
-// NewEncryptingSerializer return new encrypt instance.
-func NewEncryptingSerializer(secret string) *EncryptingSerializer {
-	key := sha256.Sum256([]byte(secret))
-	return &EncryptingSerializer{key}
+// NewEncryptingSerializer return new encrypt instance. The previous secrets are only used to decrypt the sessions.
+func NewEncryptingSerializer(secret string, previousSecrets ...string) *EncryptingSerializer {
+	serializer := &EncryptingSerializer{
+		key: sha256.Sum256([]byte(secret)),
+	}
+
+	for _, previousSecret := range previousSecrets {
+		serializer.previousKeys = append(serializer.previousKeys, sha256.Sum256([]byte(previousSecret)))
+	}
+
+	return serializer
 }
 
 // Encode encode and encrypt session.
@@ -47,8 +58,15 @@ func (e *EncryptingSerializer) Decode(dst *session.Dict, src []byte) error {
 
 	dst.Reset()
 
+	previousKey := false
+
 	decryptedSrc, err := utils.Decrypt(src, &e.key)
 	if err != nil {
+		decryptedSrc = e.decryptWithPreviousKeys(src)
+		previousKey = decryptedSrc != nil
+	}
+
+	if decryptedSrc == nil {
 		// If an error is thrown while decrypting, it's probably an old unencrypted session
 		// so we just unmarshall it without decrypting. It's a way to avoid a breaking change
 		// requiring to flush redis.
@@ -61,7 +79,43 @@ func (e *EncryptingSerializer) Decode(dst *session.Dict, src []byte) error {
 		return nil
 	}
 
-	_, err = dst.UnmarshalMsg(decryptedSrc)
+	if _, err = dst.UnmarshalMsg(decryptedSrc); err != nil {
+		return err
+	}
+
+	if previousKey {
+		dst.Set(previousSecretStorerKey, true)
+	}
+
+	return nil
+}
+
+// decryptWithPreviousKeys decrypts a session with the previous keys, it returns nil if none of them can decrypt it.
+func (e *EncryptingSerializer) decryptWithPreviousKeys(src []byte) []byte {
+	for i := range e.previousKeys {
+		decryptedSrc, err := utils.Decrypt(src, &e.previousKeys[i])
+		if err == nil {
+			return decryptedSrc
+		}
+	}
+
+	return nil
+}
+
+// HasPreviousKeys returns true if the serializer can decrypt the sessions encrypted with previous secrets.
+func (e *EncryptingSerializer) HasPreviousKeys() bool {
+	return len(e.previousKeys) > 0
+}
+
+// IsEncryptedWithPreviousKey returns true if the data of a stored session can only be decrypted with a previous key.
+func (e *EncryptingSerializer) IsEncryptedWithPreviousKey(src []byte) bool {
+	if len(src) == 0 || len(e.previousKeys) == 0 {
+		return false
+	}
+
+	if _, err := utils.Decrypt(src, &e.key); err == nil {
+		return false
+	}
 
-	return err
+	return e.decryptWithPreviousKeys(src) != nil
 }
@@ -44,3 +44,46 @@ func TestShouldSupportUnencryptedSessionForBackwardCompatibility(t *testing.T) {
 
 	assert.Equal(t, "value", decodedPayload.Get("key"))
 }
+
+func TestShouldDecryptSessionEncryptedWithPreviousSecret(t *testing.T) {
+	payload := session.Dict{}
+	payload.Set("key", "value")
+
+	encryptedDst, err := NewEncryptingSerializer("oldsecret").Encode(payload)
+	require.NoError(t, err)
+
+	serializer := NewEncryptingSerializer("newsecret", "anothersecret", "oldsecret")
+
+	decodedPayload := session.Dict{}
+	err = serializer.Decode(&decodedPayload, encryptedDst)
+	require.NoError(t, err)
+
+	assert.Equal(t, "value", decodedPayload.Get("key"))
+
+	assert.True(t, serializer.IsEncryptedWithPreviousKey(encryptedDst))
+
+	// The session is always encrypted with the current secret.
+	reencryptedDst, err := serializer.Encode(decodedPayload)
+	require.NoError(t, err)
+
+	err = NewEncryptingSerializer("newsecret").Decode(&decodedPayload, reencryptedDst)
+	require.NoError(t, err)
+	assert.Equal(t, "value", decodedPayload.Get("key"))
+
+	assert.False(t, serializer.IsEncryptedWithPreviousKey(reencryptedDst))
+}
+
+func TestShouldNotDecryptSessionEncryptedWithUnknownSecret(t *testing.T) {
+	payload := session.Dict{}
+	payload.Set("key", "value")
+
+	encryptedDst, err := NewEncryptingSerializer("unknownsecret").Encode(payload)
+	require.NoError(t, err)
+
+	serializer := NewEncryptingSerializer("newsecret", "oldsecret")
+
+	decodedPayload := session.Dict{}
+	err = serializer.Decode(&decodedPayload, encryptedDst)
+	assert.EqualError(t, err, "Unable to decrypt session: cipher: message authentication failed")
+	assert.False(t, serializer.IsEncryptedWithPreviousKey(encryptedDst))
+}
@@ -0,0 +1,31 @@
+package session
+
+import "time"
+
+// runEvery calls the function every interval until the returned function is called, which waits for the call in
+// progress if any. It runs the collections of the expired sessions and the report of the previous secrets.
+func runEvery(interval time.Duration, fn func()) (stop func()) {
+	stopCh := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				fn()
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(stopCh)
+		<-done
+	}
+}
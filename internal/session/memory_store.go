@@ -0,0 +1,232 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/authelia/authelia/internal/logging"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// memoryStore is a session store keeping the sessions in the memory of the process, which can be saved to an
+// encrypted snapshot file and restored from it to survive the restarts.
+type memoryStore struct {
+	items  map[string]memoryStoreItem
+	mutex  sync.RWMutex
+	stopGC func()
+}
+
+// memoryStoreItem is the data of a session and its expiry time, the zero time if it never expires.
+type memoryStoreItem struct {
+	Data      []byte    `json:"data"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (i memoryStoreItem) isExpired(now time.Time) bool {
+	return !i.ExpiresAt.IsZero() && !i.ExpiresAt.After(now)
+}
+
+// newMemoryStore creates an empty session store kept in memory.
+func newMemoryStore() *memoryStore {
+	return &memoryStore{items: make(map[string]memoryStoreItem)}
+}
+
+// Get returns the data of a session, nil if it does not exist or has expired.
+func (s *memoryStore) Get(id []byte) ([]byte, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	item, ok := s.items[string(id)]
+	if !ok || item.isExpired(time.Now()) {
+		return nil, nil
+	}
+
+	return item.Data, nil
+}
+
+// Save saves the data of a session, an expiration of 0 means the session never expires.
+func (s *memoryStore) Save(id, data []byte, expiration time.Duration) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.items[string(id)] = memoryStoreItem{Data: data, ExpiresAt: storeExpiresAt(expiration)}
+
+	return nil
+}
+
+// Regenerate changes the identifier of a session.
+func (s *memoryStore) Regenerate(id, newID []byte, expiration time.Duration) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	item, ok := s.items[string(id)]
+	if !ok {
+		return nil
+	}
+
+	delete(s.items, string(id))
+
+	item.ExpiresAt = storeExpiresAt(expiration)
+	s.items[string(newID)] = item
+
+	return nil
+}
+
+// Destroy deletes a session.
+func (s *memoryStore) Destroy(id []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.items, string(id))
+
+	return nil
+}
+
+// Count returns the number of sessions which have not expired yet.
+func (s *memoryStore) Count() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	now := time.Now()
+	count := 0
+
+	for _, item := range s.items {
+		if !item.isExpired(now) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// NeedGC returns false since the expired sessions are collected by the loop started with StartGC instead of one loop
+// per session holder.
+func (s *memoryStore) NeedGC() bool {
+	return false
+}
+
+// GC deletes the sessions which have expired.
+func (s *memoryStore) GC() error {
+	s.deleteExpired()
+
+	return nil
+}
+
+func (s *memoryStore) deleteExpired() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+
+	for id, item := range s.items {
+		if item.isExpired(now) {
+			delete(s.items, id)
+		}
+	}
+}
+
+// StartGC deletes the expired sessions every interval until StopGC is called.
+func (s *memoryStore) StartGC(interval time.Duration) {
+	s.stopGC = runEvery(interval, s.deleteExpired)
+}
+
+// StopGC stops the deletion of the expired sessions started with StartGC.
+func (s *memoryStore) StopGC() {
+	if s.stopGC != nil {
+		s.stopGC()
+		s.stopGC = nil
+	}
+}
+
+// SaveSnapshot writes the sessions which have not expired yet to a file encrypted with the key. The file is replaced
+// atomically so that a crash while writing does not lose the previous snapshot.
+func (s *memoryStore) SaveSnapshot(path string, key [32]byte) (int, error) {
+	s.deleteExpired()
+
+	s.mutex.RLock()
+	data, err := json.Marshal(s.items)
+	count := len(s.items)
+	s.mutex.RUnlock()
+
+	if err != nil {
+		return 0, fmt.Errorf("Unable to marshal the sessions: %s", err)
+	}
+
+	encrypted, err := utils.Encrypt(data, &key)
+	if err != nil {
+		return 0, fmt.Errorf("Unable to encrypt the sessions: %s", err)
+	}
+
+	tmpPath := path + ".tmp"
+
+	if err := ioutil.WriteFile(tmpPath, encrypted, 0600); err != nil {
+		return 0, fmt.Errorf("Unable to write the sessions snapshot: %s", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return 0, fmt.Errorf("Unable to write the sessions snapshot: %s", err)
+	}
+
+	return count, nil
+}
+
+// LoadSnapshot restores the sessions of a snapshot file encrypted with one of the keys, the sessions which expired
+// while the process was stopped are dropped. The file is removed once read. A missing file is not an error since there
+// is no snapshot before the first shutdown.
+func (s *memoryStore) LoadSnapshot(path string, keys ...[32]byte) (int, error) {
+	encrypted, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+
+		return 0, fmt.Errorf("Unable to read the sessions snapshot: %s", err)
+	}
+
+	var data []byte
+
+	for i := range keys {
+		data, err = utils.Decrypt(encrypted, &keys[i])
+		if err == nil {
+			break
+		}
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("Unable to decrypt the sessions snapshot: %s", err)
+	}
+
+	items := make(map[string]memoryStoreItem)
+
+	if err := json.Unmarshal(data, &items); err != nil {
+		return 0, fmt.Errorf("Unable to unmarshal the sessions snapshot: %s", err)
+	}
+
+	// The sessions destroyed from now on must not come back if the process crashes before the next snapshot.
+	if err := os.Remove(path); err != nil {
+		return 0, fmt.Errorf("Unable to remove the sessions snapshot: %s", err)
+	}
+
+	now := time.Now()
+	restored := 0
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for id, item := range items {
+		if item.isExpired(now) {
+			continue
+		}
+
+		s.items[id] = item
+		restored++
+	}
+
+	logging.Logger().Debugf("%d session(s) of the snapshot have expired", len(items)-restored)
+
+	return restored, nil
+}
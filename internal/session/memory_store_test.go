@@ -0,0 +1,178 @@
+package session
+
+import (
+	"crypto/sha256"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+func TestShouldSaveSessionInMemoryStore(t *testing.T) {
+	store := newMemoryStore()
+
+	require.NoError(t, store.Save([]byte("abc"), []byte("data"), time.Hour))
+	require.NoError(t, store.Save([]byte("def"), []byte("data"), 0))
+	require.NoError(t, store.Save([]byte("ghi"), []byte("data"), time.Nanosecond))
+
+	time.Sleep(time.Millisecond)
+
+	data, err := store.Get([]byte("abc"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data"), data)
+
+	// The expired sessions are not returned.
+	data, err = store.Get([]byte("ghi"))
+	require.NoError(t, err)
+	assert.Nil(t, data)
+	assert.Equal(t, 2, store.Count())
+
+	require.NoError(t, store.Regenerate([]byte("abc"), []byte("jkl"), time.Hour))
+
+	data, err = store.Get([]byte("abc"))
+	require.NoError(t, err)
+	assert.Nil(t, data)
+
+	data, err = store.Get([]byte("jkl"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data"), data)
+
+	require.NoError(t, store.Destroy([]byte("jkl")))
+
+	assert.NoError(t, store.GC())
+	assert.Len(t, store.items, 1)
+	assert.False(t, store.NeedGC())
+}
+
+func TestShouldSaveAndLoadMemoryStoreSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "authelia-sessions")
+	require.NoError(t, err)
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "sessions.db")
+	key := sha256.Sum256([]byte("secret"))
+
+	// There is no snapshot before the first shutdown.
+	restored, err := newMemoryStore().LoadSnapshot(path, key)
+	require.NoError(t, err)
+	assert.Equal(t, 0, restored)
+
+	store := newMemoryStore()
+	require.NoError(t, store.Save([]byte("abc"), []byte("data"), time.Hour))
+	require.NoError(t, store.Save([]byte("def"), []byte("data"), 0))
+	require.NoError(t, store.Save([]byte("ghi"), []byte("data"), 10*time.Millisecond))
+
+	saved, err := store.SaveSnapshot(path, key)
+	require.NoError(t, err)
+	assert.Equal(t, 3, saved)
+
+	encrypted, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(encrypted), "abc")
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// The snapshot is kept when it can't be restored.
+	_, err = newMemoryStore().LoadSnapshot(path, sha256.Sum256([]byte("othersecret")))
+	assert.EqualError(t, err, "Unable to decrypt the sessions snapshot: cipher: message authentication failed")
+
+	// The sessions which expire while the process is stopped are dropped.
+	time.Sleep(20 * time.Millisecond)
+
+	store = newMemoryStore()
+	restored, err = store.LoadSnapshot(path, sha256.Sum256([]byte("newsecret")), key)
+	require.NoError(t, err)
+	assert.Equal(t, 2, restored)
+
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+
+	data, err := store.Get([]byte("abc"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data"), data)
+
+	data, err = store.Get([]byte("ghi"))
+	require.NoError(t, err)
+	assert.Nil(t, data)
+}
+
+func TestShouldRestoreSessionsOfMemoryProviderAfterRestart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "authelia-sessions")
+	require.NoError(t, err)
+
+	defer os.RemoveAll(dir)
+
+	configuration := schema.SessionConfiguration{}
+	configuration.Domain = testDomain
+	configuration.Name = testName
+	configuration.Expiration = testExpiration
+	configuration.Secret = "abc"
+	configuration.Memory = &schema.MemorySessionConfiguration{SnapshotPath: filepath.Join(dir, "sessions.db")}
+
+	provider := NewProvider(configuration, nil, nil, nil)
+
+	ctx := &fasthttp.RequestCtx{}
+
+	userSession, err := provider.GetSession(ctx)
+	require.NoError(t, err)
+
+	userSession.Username = testUsername
+	userSession.AuthenticationLevel = authentication.OneFactor
+	require.NoError(t, provider.SaveSession(ctx, userSession))
+
+	cookie := fasthttp.AcquireCookie()
+	defer fasthttp.ReleaseCookie(cookie)
+
+	cookie.SetKey(testName)
+	require.True(t, ctx.Response.Header.Cookie(cookie))
+
+	require.NoError(t, provider.Close())
+
+	// The restarted instance finds the session of the cookie.
+	provider = NewProvider(configuration, nil, nil, nil)
+
+	ctx = &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetCookieBytesKV([]byte(testName), cookie.Value())
+
+	userSession, err = provider.GetSession(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, testUsername, userSession.Username)
+	assert.Equal(t, authentication.OneFactor, userSession.AuthenticationLevel)
+
+	// The session destroyed after the restart doesn't come back after a crash.
+	require.NoError(t, provider.DestroySession(ctx))
+
+	provider = NewProvider(configuration, nil, nil, nil)
+
+	userSession, err = provider.GetSession(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "", userSession.Username)
+}
+
+func TestShouldNotEncryptMemorySnapshotWithSessionKey(t *testing.T) {
+	sessionKey := sha256.Sum256([]byte("abc"))
+	assert.NotEqual(t, sessionKey, getMemorySnapshotKey("abc"))
+	assert.NotEqual(t, getMemorySnapshotKey("abc"), getMemorySnapshotKey("def"))
+}
+
+func TestShouldNotSaveSessionsOfMemoryProviderWithoutSnapshot(t *testing.T) {
+	configuration := schema.SessionConfiguration{}
+	configuration.Domain = testDomain
+	configuration.Name = testName
+	configuration.Expiration = testExpiration
+
+	provider := NewProvider(configuration, nil, nil, nil)
+	assert.Nil(t, provider.memoryStore)
+	assert.NoError(t, provider.Close())
+}
@@ -0,0 +1,47 @@
+package session
+
+import (
+	"time"
+
+	fasthttpsession "github.com/fasthttp/session/v2"
+)
+
+// namespacedStore is the view of a protected root domain on the store shared by the domains. The session IDs are
+// prefixed with the namespace of the domain so that the cookie of a domain cannot be replayed against another one.
+type namespacedStore struct {
+	fasthttpsession.Provider
+	namespace string
+}
+
+// newNamespacedStore creates the view of a domain on the store, the IDs are kept as is if the namespace is empty.
+func newNamespacedStore(store fasthttpsession.Provider, namespace string) *namespacedStore {
+	return &namespacedStore{Provider: store, namespace: namespace}
+}
+
+// key returns the key of a session ID in the shared store.
+func (s *namespacedStore) key(id []byte) []byte {
+	key := make([]byte, 0, len(s.namespace)+len(id))
+	key = append(key, s.namespace...)
+
+	return append(key, id...)
+}
+
+// Get returns the data of a session of the domain.
+func (s *namespacedStore) Get(id []byte) ([]byte, error) {
+	return s.Provider.Get(s.key(id))
+}
+
+// Save saves the data of a session of the domain.
+func (s *namespacedStore) Save(id, data []byte, expiration time.Duration) error {
+	return s.Provider.Save(s.key(id), data, expiration)
+}
+
+// Regenerate changes the identifier of a session of the domain.
+func (s *namespacedStore) Regenerate(id, newID []byte, expiration time.Duration) error {
+	return s.Provider.Regenerate(s.key(id), s.key(newID), expiration)
+}
+
+// Destroy deletes a session of the domain.
+func (s *namespacedStore) Destroy(id []byte) error {
+	return s.Provider.Destroy(s.key(id))
+}
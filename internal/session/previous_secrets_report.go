@@ -0,0 +1,67 @@
+package session
+
+import (
+	"context"
+
+	goredis "github.com/go-redis/redis/v8"
+
+	"github.com/authelia/authelia/internal/logging"
+)
+
+// sessionsScanner lists the data of the stored sessions which have not expired yet.
+type sessionsScanner interface {
+	Sessions() ([][]byte, error)
+}
+
+// redisSessionsScanner lists the sessions kept by the Redis provider, which are the keys with its prefix.
+type redisSessionsScanner struct {
+	client *goredis.Client
+}
+
+// Sessions returns the data of the sessions of all the protected root domains.
+func (s *redisSessionsScanner) Sessions() ([][]byte, error) {
+	var sessions [][]byte
+
+	iter := s.client.Scan(context.Background(), 0, redisSessionKeyPrefix+":*", 0).Iterator()
+
+	for iter.Next(context.Background()) {
+		data, err := s.client.Get(context.Background(), iter.Val()).Bytes()
+
+		switch {
+		case err == goredis.Nil:
+			// The session expired since it was listed.
+			continue
+		case err != nil:
+			return nil, err
+		}
+
+		sessions = append(sessions, data)
+	}
+
+	return sessions, iter.Err()
+}
+
+// reportPreviousSecretsSessions logs the number of stored sessions which can only be decrypted with a previous secret,
+// including the sessions which have not been used since the secret was rotated.
+func reportPreviousSecretsSessions(scanner sessionsScanner, serializer *EncryptingSerializer) {
+	sessions, err := scanner.Sessions()
+	if err != nil {
+		logging.Logger().Errorf("Unable to count the sessions encrypted with a previous secret: %s", err)
+		return
+	}
+
+	count := 0
+
+	for _, data := range sessions {
+		if serializer.IsEncryptedWithPreviousKey(data) {
+			count++
+		}
+	}
+
+	if count > 0 {
+		logging.Logger().Infof("%d stored session(s) are still encrypted with a previous secret", count)
+		return
+	}
+
+	logging.Logger().Info("No stored session is encrypted with a previous secret, they can be removed")
+}
@@ -0,0 +1,109 @@
+package session
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/fasthttp/session/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/logging"
+	"github.com/authelia/authelia/internal/storage"
+)
+
+func encodeTestSession(t *testing.T, secret string) []byte {
+	payload := session.Dict{}
+	payload.Set(userSessionStorerKey, []byte("{}"))
+
+	data, err := NewEncryptingSerializer(secret).Encode(payload)
+	require.NoError(t, err)
+
+	return data
+}
+
+func TestShouldReportStoredSessionsEncryptedWithPreviousSecret(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	hook := test.NewLocal(logging.Logger())
+	defer hook.Reset()
+
+	serializer := NewEncryptingSerializer("newsecret", "oldsecret")
+
+	storageMock := storage.NewMockProvider(ctrl)
+
+	gomock.InOrder(
+		storageMock.EXPECT().LoadSessions(gomock.Any()).Return([][]byte{
+			encodeTestSession(t, "oldsecret"),
+			encodeTestSession(t, "oldsecret"),
+			encodeTestSession(t, "newsecret"),
+		}, nil),
+		storageMock.EXPECT().LoadSessions(gomock.Any()).Return([][]byte{
+			encodeTestSession(t, "newsecret"),
+		}, nil),
+	)
+
+	store := newSQLStore(storageMock)
+
+	reportPreviousSecretsSessions(store, serializer)
+	assert.Equal(t, "2 stored session(s) are still encrypted with a previous secret", hook.LastEntry().Message)
+
+	reportPreviousSecretsSessions(store, serializer)
+	assert.Equal(t, "No stored session is encrypted with a previous secret, they can be removed",
+		hook.LastEntry().Message)
+}
+
+func TestShouldReportErrorWhenStoredSessionsCannotBeScanned(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	hook := test.NewLocal(logging.Logger())
+	defer hook.Reset()
+
+	storageMock := storage.NewMockProvider(ctrl)
+	storageMock.EXPECT().LoadSessions(gomock.Any()).Return(nil, errors.New("failed"))
+
+	reportPreviousSecretsSessions(newSQLStore(storageMock), NewEncryptingSerializer("newsecret", "oldsecret"))
+	assert.Equal(t, "Unable to count the sessions encrypted with a previous secret: failed", hook.LastEntry().Message)
+}
+
+func TestShouldStopPreviousSecretsReportWhenProviderIsClosed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storageMock := storage.NewMockProvider(ctrl)
+
+	configuration := schema.SessionConfiguration{}
+	configuration.Domain = testDomain
+	configuration.Name = testName
+	configuration.Expiration = testExpiration
+	configuration.Secret = "newsecret"
+	configuration.SQL = &schema.SQLSessionConfiguration{GCInterval: "1h"}
+
+	provider := NewProvider(configuration, nil, nil, storageMock)
+	assert.Nil(t, provider.stopPreviousSecretsReport)
+	require.NoError(t, provider.Close())
+
+	configuration.PreviousSecrets = []string{"oldsecret"}
+
+	provider = NewProvider(configuration, nil, nil, storageMock)
+	assert.NotNil(t, provider.stopPreviousSecretsReport)
+	require.NoError(t, provider.Close())
+	assert.Nil(t, provider.stopPreviousSecretsReport)
+}
+
+func TestShouldNotReportSessionsOfMemoryProvider(t *testing.T) {
+	configuration := schema.SessionConfiguration{}
+	configuration.Domain = testDomain
+	configuration.Name = testName
+	configuration.Expiration = testExpiration
+	configuration.Secret = "newsecret"
+	configuration.PreviousSecrets = []string{"oldsecret"}
+
+	provider := NewProvider(configuration, nil, nil, nil)
+	assert.Nil(t, provider.stopPreviousSecretsReport)
+}
@@ -1,7 +1,12 @@
 package session
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
 	"encoding/json"
+	"errors"
+	"net"
+	"net/url"
 	"time"
 
 	fasthttpsession "github.com/fasthttp/session/v2"
@@ -10,22 +15,68 @@ import (
 	"github.com/valyala/fasthttp"
 
 	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/logging"
 	"github.com/authelia/authelia/internal/utils"
 )
 
 // Provider a session provider.
 type Provider struct {
 	sessionHolder *fasthttpsession.Session
+	sessionStore  *namespacedStore
 	RememberMe    time.Duration
 	Inactivity    time.Duration
+
+	// The sessions of the protected root domains, they share the same store but each one issues its own cookie and
+	// keeps its sessions in its own namespace of the store.
+	domains     []*domainSession
+	domainNames []string
+
+	// The proxies allowed to forward the host the user is visiting.
+	trustedProxies []*net.IPNet
+
+	// The store shared by the sessions.
+	store fasthttpsession.Provider
+
+	// The store of the indexes of the active sessions of the users, they last as long as the longest session.
+	activeSessions  activeSessionsStore
+	indexExpiration time.Duration
+
+	// Stops the periodic report of the stored sessions encrypted with the previous secrets, nil if not started.
+	stopPreviousSecretsReport func()
+
+	// The store keeping the sessions in the SQL storage, nil with the other providers.
+	sqlStore *sqlStore
+
+	// The store keeping the sessions in memory when they are saved to a snapshot file on shutdown.
+	memoryStore        *memoryStore
+	memorySnapshotPath string
+	memorySnapshotKey  [32]byte
+
+	// The attributes of the session cookie which are not configurable in the session holders.
+	cookieName string
+	cookiePath string
+	sameSite   fasthttp.CookieSameSite
+}
+
+// domainSession is the session of a protected root domain.
+type domainSession struct {
+	domain        string
+	sessionHolder *fasthttpsession.Session
+	store         *namespacedStore
+	rememberMe    time.Duration
+	sameSite      fasthttp.CookieSameSite
 }
 
-// NewProvider instantiate a session provider given a configuration.
-func NewProvider(configuration schema.SessionConfiguration) *Provider {
+// NewProvider instantiate a session provider given a configuration. A cookie is issued for each protected root domain,
+// or only for the domain of the session if none is provided. The host the user is visiting is read from the headers
+// forwarded by the trusted proxies, or by any client if there is none. The SQL storage is only used by the SQL session
+// provider.
+func NewProvider(configuration schema.SessionConfiguration, domains []schema.DomainConfiguration,
+	trustedProxies []string, sqlStorage SQLStorage) *Provider {
 	providerConfig := NewProviderConfig(configuration)
 
 	provider := new(Provider)
-	provider.sessionHolder = fasthttpsession.New(providerConfig.config)
+	provider.trustedProxies = utils.ParseNetworks(trustedProxies)
 
 	duration, err := utils.ParseDurationString(configuration.RememberMeDuration)
 	if err != nil {
@@ -41,30 +92,282 @@ func NewProvider(configuration schema.SessionConfiguration) *Provider {
 
 	provider.Inactivity = duration
 
+	provider.cookieName = configuration.Name
+	provider.cookiePath = configuration.CookiePath
+	provider.sameSite = toCookieSameSite(configuration.SameSite)
+
+	if provider.cookiePath == "" {
+		provider.cookiePath = "/"
+	}
+
 	var providerImpl fasthttpsession.Provider
-	if providerConfig.redisConfig != nil {
+
+	switch {
+	case providerConfig.redisConfig != nil:
 		providerImpl, err = redis.New(*providerConfig.redisConfig)
-		if err != nil {
-			panic(err)
+	case providerConfig.redisSentinelConfig != nil:
+		// The failover cluster client follows the master elected by Sentinel and can route the read-only commands
+		// to the replicas.
+		providerImpl, err = redis.NewFailoverCluster(*providerConfig.redisSentinelConfig)
+	case providerConfig.providerName == "sql":
+		if sqlStorage == nil {
+			panic(errors.New("The SQL session provider requires a SQL storage backend"))
 		}
-	} else {
+
+		provider.sqlStore = newSQLStore(sqlStorage)
+		provider.sqlStore.StartGC(providerConfig.sqlGCInterval)
+
+		providerImpl = provider.sqlStore
+	case providerConfig.memorySnapshotPath != "":
+		provider.memoryStore = newMemoryStore()
+		provider.memorySnapshotPath = providerConfig.memorySnapshotPath
+		provider.memorySnapshotKey = getMemorySnapshotKey(configuration.Secret)
+
+		provider.restoreMemorySnapshot(configuration.PreviousSecrets)
+		provider.memoryStore.StartGC(memoryStoreGCInterval)
+
+		providerImpl = provider.memoryStore
+	default:
 		providerImpl, err = memory.New(memory.Config{})
+	}
+
+	if err != nil {
+		panic(err)
+	}
+
+	provider.store = providerImpl
+
+	if len(domains) == 0 {
+		domains = []schema.DomainConfiguration{{Domain: configuration.Domain}}
+	}
+
+	for _, domain := range domains {
+		config := providerConfig.config
+		config.Domain = domain.Domain
+
+		ds := &domainSession{
+			domain:     domain.Domain,
+			rememberMe: provider.RememberMe,
+			sameSite:   provider.sameSite,
+		}
+
+		if domain.SameSite != "" {
+			ds.sameSite = toCookieSameSite(domain.SameSite)
+		}
+
+		if domain.Expiration != "" {
+			config.Expiration, err = utils.ParseDurationString(domain.Expiration)
+			if err != nil {
+				panic(err)
+			}
+		}
+
+		if domain.RememberMeDuration != "" {
+			ds.rememberMe, err = utils.ParseDurationString(domain.RememberMeDuration)
+			if err != nil {
+				panic(err)
+			}
+		}
+
+		// The sessions of the domain of the session keep their IDs as keys for the existing sessions to stay valid.
+		namespace := ""
+		if domain.Domain != configuration.Domain {
+			namespace = domain.Domain + ":"
+		}
+
+		ds.store = newNamespacedStore(providerImpl, namespace)
+		ds.sessionHolder = fasthttpsession.New(config)
+
+		err = ds.sessionHolder.SetProvider(ds.store)
 		if err != nil {
 			panic(err)
 		}
+
+		provider.domains = append(provider.domains, ds)
+		provider.domainNames = append(provider.domainNames, ds.domain)
+
+		// The index of the active sessions of a user lasts as long as the longest session.
+		for _, duration := range []time.Duration{config.Expiration, ds.rememberMe} {
+			if duration > provider.indexExpiration {
+				provider.indexExpiration = duration
+			}
+		}
+
+		if domain.Domain == configuration.Domain || provider.sessionHolder == nil {
+			provider.sessionHolder = ds.sessionHolder
+			provider.sessionStore = ds.store
+		}
 	}
 
-	err = provider.sessionHolder.SetProvider(providerImpl)
-	if err != nil {
-		panic(err)
+	// The sessions of the memory providers are not encrypted, there is nothing to report.
+	var scanner sessionsScanner
+
+	switch {
+	case providerConfig.redisConfig != nil || providerConfig.redisSentinelConfig != nil:
+		client := newRedisClient(providerConfig)
+		provider.activeSessions = newRedisActiveSessionsStore(client, provider.indexExpiration)
+		scanner = &redisSessionsScanner{client: client}
+	case providerConfig.providerName == "sql":
+		provider.activeSessions = newSQLActiveSessionsStore(sqlStorage, provider.indexExpiration)
+		scanner = provider.sqlStore
+	default:
+		provider.activeSessions = newMemoryActiveSessionsStore(providerImpl, provider.indexExpiration)
 	}
 
+	provider.startPreviousSecretsReport(providerConfig.serializer, scanner)
+
 	return provider
 }
 
+// startPreviousSecretsReport logs periodically the number of stored sessions still encrypted with a previous secret
+// until the provider is closed, it does nothing if there is no previous secret or the store can't be scanned.
+func (p *Provider) startPreviousSecretsReport(serializer *EncryptingSerializer, scanner sessionsScanner) {
+	if serializer == nil || scanner == nil || !serializer.HasPreviousKeys() {
+		return
+	}
+
+	p.stopPreviousSecretsReport = runEvery(previousSecretsReportInterval, func() {
+		reportPreviousSecretsSessions(scanner, serializer)
+	})
+}
+
+// getDomainSession retrieve the session of the most specific protected root domain of the host the user is visiting,
+// nil if the host is not under any of them.
+func (p *Provider) getDomainSession(ctx *fasthttp.RequestCtx) *domainSession {
+	host := p.getRequestHost(ctx)
+
+	if i := utils.GetMostSpecificDomain(host, p.domainNames); i != -1 {
+		return p.domains[i]
+	}
+
+	return nil
+}
+
+// getSessionHolder retrieve the session holder issuing the cookie of the domain of the request, the one of the domain
+// of the session if the request is not under any protected domain.
+func (p *Provider) getSessionHolder(ctx *fasthttp.RequestCtx) *fasthttpsession.Session {
+	if ds := p.getDomainSession(ctx); ds != nil {
+		return ds.sessionHolder
+	}
+
+	return p.sessionHolder
+}
+
+// getStoreKey retrieve the key of a session of the domain of the request in the store shared by the domains.
+func (p *Provider) getStoreKey(ctx *fasthttp.RequestCtx, sessionID []byte) []byte {
+	if ds := p.getDomainSession(ctx); ds != nil {
+		return ds.store.key(sessionID)
+	}
+
+	return p.sessionStore.key(sessionID)
+}
+
+// restoreMemorySnapshot restores the sessions of the snapshot file saved on the last shutdown, the snapshots encrypted
+// with a previous secret can still be restored. Authelia starts without the sessions if they can't be restored.
+func (p *Provider) restoreMemorySnapshot(previousSecrets []string) {
+	keys := [][32]byte{p.memorySnapshotKey}
+	for _, previousSecret := range previousSecrets {
+		keys = append(keys, getMemorySnapshotKey(previousSecret))
+	}
+
+	restored, err := p.memoryStore.LoadSnapshot(p.memorySnapshotPath, keys...)
+	if err != nil {
+		logging.Logger().Errorf("Unable to restore the sessions from %s, they are lost: %s", p.memorySnapshotPath, err)
+		return
+	}
+
+	logging.Logger().Infof("%d session(s) have been restored from %s", restored, p.memorySnapshotPath)
+}
+
+// getMemorySnapshotKey derives the key encrypting the snapshot of the sessions from the secret of the sessions. It
+// differs from the key encrypting each session so that one can't be used in place of the other.
+func getMemorySnapshotKey(secret string) (key [32]byte) {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(memorySnapshotKeyContext))
+	copy(key[:], mac.Sum(nil))
+
+	return key
+}
+
+// Close stops the deletion of the expired sessions and the report of the previous secrets, and saves the sessions kept
+// in memory to the snapshot file if configured so, for them to be restored on the next start. It must be called once
+// the server stopped handling the requests.
+func (p *Provider) Close() error {
+	if p.stopPreviousSecretsReport != nil {
+		p.stopPreviousSecretsReport()
+		p.stopPreviousSecretsReport = nil
+	}
+
+	if p.sqlStore != nil {
+		p.sqlStore.StopGC()
+	}
+
+	if p.memoryStore == nil {
+		return nil
+	}
+
+	p.memoryStore.StopGC()
+
+	saved, err := p.memoryStore.SaveSnapshot(p.memorySnapshotPath, p.memorySnapshotKey)
+	if err != nil {
+		return err
+	}
+
+	logging.Logger().Infof("%d session(s) have been saved to %s", saved, p.memorySnapshotPath)
+
+	return nil
+}
+
+// setCookieAttributes sets the attributes of the session cookie of the response which the session holders don't let
+// configure: the path, the SameSite mode of the domain of the request and HttpOnly which is always set.
+func (p *Provider) setCookieAttributes(ctx *fasthttp.RequestCtx) {
+	cookie := fasthttp.AcquireCookie()
+	defer fasthttp.ReleaseCookie(cookie)
+
+	cookie.SetKey(p.cookieName)
+
+	if !ctx.Response.Header.Cookie(cookie) {
+		return
+	}
+
+	sameSite := p.sameSite
+	if ds := p.getDomainSession(ctx); ds != nil {
+		sameSite = ds.sameSite
+	}
+
+	cookie.SetPath(p.cookiePath)
+	cookie.SetHTTPOnly(true)
+	cookie.SetSameSite(sameSite)
+
+	ctx.Response.Header.SetCookie(cookie)
+}
+
+// toCookieSameSite converts a SameSite mode of the configuration, lax if it is not set.
+func toCookieSameSite(sameSite string) fasthttp.CookieSameSite {
+	switch sameSite {
+	case schema.SessionSameSiteStrict:
+		return fasthttp.CookieSameSiteStrictMode
+	case schema.SessionSameSiteNone:
+		return fasthttp.CookieSameSiteNoneMode
+	default:
+		return fasthttp.CookieSameSiteLaxMode
+	}
+}
+
+// GetRememberMe return the remember me duration of the domain of the request.
+func (p *Provider) GetRememberMe(ctx *fasthttp.RequestCtx) time.Duration {
+	if ds := p.getDomainSession(ctx); ds != nil {
+		return ds.rememberMe
+	}
+
+	return p.RememberMe
+}
+
 // GetSession return the user session from a request.
 func (p *Provider) GetSession(ctx *fasthttp.RequestCtx) (UserSession, error) {
-	store, err := p.sessionHolder.Get(ctx)
+	defer p.setCookieAttributes(ctx)
+
+	store, err := p.getSessionHolder(ctx).Get(ctx)
 
 	if err != nil {
 		return NewDefaultUserSession(), err
@@ -88,12 +391,25 @@ func (p *Provider) GetSession(ctx *fasthttp.RequestCtx) (UserSession, error) {
 		return NewDefaultUserSession(), err
 	}
 
+	// The session is encrypted again with the current secret right away, the sessions of the users who chose to stay
+	// logged in are otherwise only saved again when their authentication changes.
+	if store.Get(previousSecretStorerKey) != nil {
+		store.Delete(previousSecretStorerKey)
+
+		if err := p.getSessionHolder(ctx).Save(ctx, store); err != nil {
+			logging.Logger().Errorf("Unable to encrypt the session of user %s with the current secret: %s",
+				userSession.Username, err)
+		}
+	}
+
 	return userSession, nil
 }
 
 // SaveSession save the user session.
 func (p *Provider) SaveSession(ctx *fasthttp.RequestCtx, userSession UserSession) error {
-	store, err := p.sessionHolder.Get(ctx)
+	defer p.setCookieAttributes(ctx)
+
+	store, err := p.getSessionHolder(ctx).Get(ctx)
 
 	if err != nil {
 		return err
@@ -107,7 +423,7 @@ func (p *Provider) SaveSession(ctx *fasthttp.RequestCtx, userSession UserSession
 
 	store.Set(userSessionStorerKey, userSessionJSON)
 
-	err = p.sessionHolder.Save(ctx, store)
+	err = p.getSessionHolder(ctx).Save(ctx, store)
 
 	if err != nil {
 		return err
@@ -116,20 +432,73 @@ func (p *Provider) SaveSession(ctx *fasthttp.RequestCtx, userSession UserSession
 	return nil
 }
 
-// RegenerateSession regenerate a session ID.
+// RegenerateSession regenerate a session ID, the session keeps its entry in the index of the active sessions.
 func (p *Provider) RegenerateSession(ctx *fasthttp.RequestCtx) error {
-	err := p.sessionHolder.Regenerate(ctx)
-	return err
+	defer p.setCookieAttributes(ctx)
+
+	sessionHolder := p.getSessionHolder(ctx)
+
+	store, err := sessionHolder.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	storeKey := p.getStoreKey(ctx, store.GetSessionID())
+
+	// A session which cannot be decoded is not indexed.
+	userSession, _ := p.GetSession(ctx)
+
+	err = sessionHolder.Regenerate(ctx)
+	if err != nil {
+		return err
+	}
+
+	if userSession.Username == "" {
+		return nil
+	}
+
+	store, err = sessionHolder.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	return p.moveActiveSession(userSession.Username, storeKey, p.getStoreKey(ctx, store.GetSessionID()))
 }
 
-// DestroySession destroy a session ID and delete the cookie.
+// DestroySession destroy a session ID and delete the cookie, the session is removed from the index of the active
+// sessions.
 func (p *Provider) DestroySession(ctx *fasthttp.RequestCtx) error {
-	return p.sessionHolder.Destroy(ctx)
+	defer p.setCookieAttributes(ctx)
+
+	sessionHolder := p.getSessionHolder(ctx)
+
+	store, err := sessionHolder.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	storeKey := p.getStoreKey(ctx, store.GetSessionID())
+
+	// A session which cannot be decoded is not indexed.
+	userSession, _ := p.GetSession(ctx)
+
+	err = sessionHolder.Destroy(ctx)
+	if err != nil {
+		return err
+	}
+
+	if userSession.Username == "" {
+		return nil
+	}
+
+	return p.removeActiveSession(userSession.Username, storeKey)
 }
 
 // UpdateExpiration update the expiration of the cookie and session.
 func (p *Provider) UpdateExpiration(ctx *fasthttp.RequestCtx, expiration time.Duration) error {
-	store, err := p.sessionHolder.Get(ctx)
+	defer p.setCookieAttributes(ctx)
+
+	store, err := p.getSessionHolder(ctx).Get(ctx)
 
 	if err != nil {
 		return err
@@ -141,12 +510,12 @@ func (p *Provider) UpdateExpiration(ctx *fasthttp.RequestCtx, expiration time.Du
 		return err
 	}
 
-	return p.sessionHolder.Save(ctx, store)
+	return p.getSessionHolder(ctx).Save(ctx, store)
 }
 
 // GetExpiration get the expiration of the current session.
 func (p *Provider) GetExpiration(ctx *fasthttp.RequestCtx) (time.Duration, error) {
-	store, err := p.sessionHolder.Get(ctx)
+	store, err := p.getSessionHolder(ctx).Get(ctx)
 
 	if err != nil {
 		return time.Duration(0), err
@@ -154,3 +523,25 @@ func (p *Provider) GetExpiration(ctx *fasthttp.RequestCtx) (time.Duration, error
 
 	return store.GetExpiration(), nil
 }
+
+// getRequestHost retrieve the host the user is visiting, i.e. the host of the target URL forwarded by the proxy on
+// the verify endpoint, or the host of the request otherwise. The forwarded headers are ignored when the request does
+// not come from a trusted proxy.
+func (p *Provider) getRequestHost(ctx *fasthttp.RequestCtx) string {
+	if len(p.trustedProxies) != 0 && !utils.IsIPInNetworks(ctx.RemoteIP(), p.trustedProxies) {
+		return (&url.URL{Host: string(ctx.Host())}).Hostname()
+	}
+
+	if originalURL := ctx.Request.Header.Peek(xOriginalURLHeader); len(originalURL) != 0 {
+		if targetURL, err := url.ParseRequestURI(string(originalURL)); err == nil {
+			return targetURL.Hostname()
+		}
+	}
+
+	host := ctx.Request.Header.Peek(xForwardedHostHeader)
+	if len(host) == 0 {
+		host = ctx.Host()
+	}
+
+	return (&url.URL{Host: string(host)}).Hostname()
+}
@@ -1,7 +1,11 @@
 package session
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"io/ioutil"
+	"time"
 
 	"github.com/fasthttp/session/v2"
 	"github.com/fasthttp/session/v2/providers/redis"
@@ -35,12 +39,79 @@ func NewProviderConfig(configuration schema.SessionConfiguration) ProviderConfig
 
 	var redisConfig *redis.Config
 
+	var redisSentinelConfig *redis.FailoverConfig
+
+	var idleTimeout, dialTimeout, readTimeout, writeTimeout time.Duration
+
+	var tlsConfig *tls.Config
+
+	var gcInterval time.Duration
+
+	var serializer *EncryptingSerializer
+
+	var memorySnapshotPath string
+
 	var providerName string
 
-	// If redis configuration is provided, then use the redis provider.
 	if configuration.Redis != nil {
+		// Ignore the errors as they will be handled by validator.
+		idleTimeout, _ = utils.ParseDurationString(configuration.Redis.IdleTimeout)
+		dialTimeout, _ = utils.ParseDurationString(configuration.Redis.DialTimeout)
+		readTimeout, _ = utils.ParseDurationString(configuration.Redis.ReadTimeout)
+		writeTimeout, _ = utils.ParseDurationString(configuration.Redis.WriteTimeout)
+
+		if configuration.Redis.TLS != nil {
+			var err error
+
+			tlsConfig, err = newRedisTLSConfig(*configuration.Redis.TLS)
+			if err != nil {
+				panic(err)
+			}
+		}
+	}
+
+	// The sessions are encrypted when they are stored outside of the process.
+	if configuration.Redis != nil || configuration.SQL != nil {
+		serializer = NewEncryptingSerializer(configuration.Secret, configuration.PreviousSecrets...)
+		config.EncodeFunc = serializer.Encode
+		config.DecodeFunc = serializer.Decode
+	}
+
+	// If redis configuration is provided, then use the redis provider.
+	switch {
+	case configuration.Redis != nil && configuration.Redis.HighAvailability != nil:
+		providerName = "redis-sentinel"
+
+		// The host and port of the redis section are the first Sentinel node.
+		var addrs []string
+
+		if configuration.Redis.Host != "" {
+			addrs = append(addrs, fmt.Sprintf("%s:%d", configuration.Redis.Host, configuration.Redis.Port))
+		}
+
+		for _, node := range configuration.Redis.HighAvailability.Nodes {
+			addrs = append(addrs, fmt.Sprintf("%s:%d", node.Host, node.Port))
+		}
+
+		redisSentinelConfig = &redis.FailoverConfig{
+			MasterName:       configuration.Redis.HighAvailability.SentinelName,
+			SentinelAddrs:    addrs,
+			SentinelPassword: configuration.Redis.HighAvailability.SentinelPassword,
+			RouteByLatency:   configuration.Redis.HighAvailability.RouteByLatency,
+			RouteRandomly:    configuration.Redis.HighAvailability.RouteRandomly,
+			Username:         configuration.Redis.Username,
+			Password:         configuration.Redis.Password,
+			DB:               configuration.Redis.DatabaseIndex,
+			PoolSize:         configuration.Redis.PoolSize,
+			IdleTimeout:      idleTimeout,
+			DialTimeout:      dialTimeout,
+			ReadTimeout:      readTimeout,
+			WriteTimeout:     writeTimeout,
+			TLSConfig:        tlsConfig,
+			KeyPrefix:        redisSessionKeyPrefix,
+		}
+	case configuration.Redis != nil:
 		providerName = "redis"
-		serializer := NewEncryptingSerializer(configuration.Secret)
 		network := "tcp"
 
 		var addr string
@@ -55,22 +126,79 @@ func NewProviderConfig(configuration schema.SessionConfiguration) ProviderConfig
 		redisConfig = &redis.Config{
 			Network:  network,
 			Addr:     addr,
+			Username: configuration.Redis.Username,
 			Password: configuration.Redis.Password,
 			// DB is the fasthttp/session property for the Redis DB Index.
-			DB:          configuration.Redis.DatabaseIndex,
-			PoolSize:    8,
-			IdleTimeout: 300,
-			KeyPrefix:   "authelia-session",
+			DB:           configuration.Redis.DatabaseIndex,
+			PoolSize:     configuration.Redis.PoolSize,
+			IdleTimeout:  idleTimeout,
+			DialTimeout:  dialTimeout,
+			ReadTimeout:  readTimeout,
+			WriteTimeout: writeTimeout,
+			TLSConfig:    tlsConfig,
+			KeyPrefix:    redisSessionKeyPrefix,
 		}
-		config.EncodeFunc = serializer.Encode
-		config.DecodeFunc = serializer.Decode
-	} else { // if no option is provided, use the memory provider.
+	case configuration.SQL != nil:
+		providerName = "sql"
+
+		// Ignore the error as it will be handled by validator.
+		gcInterval, _ = utils.ParseDurationString(configuration.SQL.GCInterval)
+	default: // if no option is provided, use the memory provider.
 		providerName = "memory"
+
+		if configuration.Memory != nil {
+			memorySnapshotPath = configuration.Memory.SnapshotPath
+		}
 	}
 
 	return ProviderConfig{
-		config:       config,
-		redisConfig:  redisConfig,
-		providerName: providerName,
+		config:              config,
+		redisConfig:         redisConfig,
+		redisSentinelConfig: redisSentinelConfig,
+		sqlGCInterval:       gcInterval,
+		serializer:          serializer,
+		memorySnapshotPath:  memorySnapshotPath,
+		providerName:        providerName,
+	}
+}
+
+// newRedisTLSConfig creates the TLS configuration of the connections to redis. The certificates of the server are
+// verified against the system pool and the trusted certificate if any.
+func newRedisTLSConfig(configuration schema.RedisTLSConfiguration) (*tls.Config, error) {
+	// Ignore the error as it will be handled by validator.
+	minimumVersion, _ := utils.TLSStringToTLSConfigVersion(configuration.MinimumVersion)
+
+	certPool, err := x509.SystemCertPool()
+	if err != nil || certPool == nil {
+		certPool = x509.NewCertPool()
+	}
+
+	if configuration.TrustedCert != "" {
+		pem, err := ioutil.ReadFile(configuration.TrustedCert)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read the trusted certificate of redis: %s", err)
+		}
+
+		if ok := certPool.AppendCertsFromPEM(pem); !ok {
+			return nil, fmt.Errorf("Unable to import the trusted certificate of redis from %s", configuration.TrustedCert)
+		}
+	}
+
+	config := &tls.Config{
+		ServerName:         configuration.ServerName,
+		InsecureSkipVerify: configuration.SkipVerify, //nolint:gosec // Disabling InsecureSkipVerify is an informed choice by users.
+		MinVersion:         minimumVersion,
+		RootCAs:            certPool,
 	}
+
+	if configuration.Certificate != "" {
+		certificate, err := tls.LoadX509KeyPair(configuration.Certificate, configuration.Key)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to load the client certificate of redis: %s", err)
+		}
+
+		config.Certificates = []tls.Certificate{certificate}
+	}
+
+	return config, nil
 }
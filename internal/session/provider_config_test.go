@@ -2,6 +2,7 @@ package session
 
 import (
 	"crypto/sha256"
+	"crypto/tls"
 	"testing"
 	"time"
 
@@ -87,6 +88,102 @@ func TestShouldCreateRedisSessionProviderWithUnixSocket(t *testing.T) {
 	assert.Equal(t, 0, pConfig.DB)
 }
 
+func TestShouldCreateRedisSentinelSessionProvider(t *testing.T) {
+	configuration := schema.SessionConfiguration{}
+	configuration.Domain = testDomain
+	configuration.Name = testName
+	configuration.Expiration = testExpiration
+	configuration.Secret = "abc"
+	configuration.Redis = &schema.RedisSessionConfiguration{
+		Host:          "sentinel-node1",
+		Port:          26379,
+		Password:      "pass",
+		DatabaseIndex: 2,
+		HighAvailability: &schema.RedisHighAvailabilityConfiguration{
+			SentinelName:     "mymaster",
+			SentinelPassword: "sentinel_pass",
+			Nodes: []schema.RedisNode{
+				{Host: "sentinel-node2", Port: 26380},
+			},
+			RouteByLatency: true,
+		},
+	}
+	providerConfig := NewProviderConfig(configuration)
+
+	assert.Equal(t, "redis-sentinel", providerConfig.providerName)
+	assert.Nil(t, providerConfig.redisConfig)
+	assert.NotNil(t, providerConfig.config.EncodeFunc)
+	assert.NotNil(t, providerConfig.config.DecodeFunc)
+
+	pConfig := providerConfig.redisSentinelConfig
+	require.NotNil(t, pConfig)
+	assert.Equal(t, "mymaster", pConfig.MasterName)
+	assert.Equal(t, []string{"sentinel-node1:26379", "sentinel-node2:26380"}, pConfig.SentinelAddrs)
+	assert.Equal(t, "sentinel_pass", pConfig.SentinelPassword)
+	assert.Equal(t, "pass", pConfig.Password)
+	assert.Equal(t, 2, pConfig.DB)
+	assert.True(t, pConfig.RouteByLatency)
+	assert.False(t, pConfig.RouteRandomly)
+}
+
+func TestShouldConfigureRedisConnections(t *testing.T) {
+	configuration := schema.SessionConfiguration{}
+	configuration.Domain = testDomain
+	configuration.Name = testName
+	configuration.Expiration = testExpiration
+	configuration.Redis = &schema.RedisSessionConfiguration{
+		Host:         "redis.example.com",
+		Port:         6380,
+		Username:     "authelia",
+		Password:     "pass",
+		PoolSize:     20,
+		IdleTimeout:  "5m",
+		DialTimeout:  "10s",
+		ReadTimeout:  "3s",
+		WriteTimeout: "4s",
+		TLS: &schema.RedisTLSConfiguration{
+			ServerName:     "redis.internal",
+			MinimumVersion: "TLS1.3",
+		},
+	}
+	providerConfig := NewProviderConfig(configuration)
+
+	pConfig := providerConfig.redisConfig
+	require.NotNil(t, pConfig)
+	assert.Equal(t, "authelia", pConfig.Username)
+	assert.Equal(t, 20, pConfig.PoolSize)
+	assert.Equal(t, 5*time.Minute, pConfig.IdleTimeout)
+	assert.Equal(t, 10*time.Second, pConfig.DialTimeout)
+	assert.Equal(t, 3*time.Second, pConfig.ReadTimeout)
+	assert.Equal(t, 4*time.Second, pConfig.WriteTimeout)
+
+	require.NotNil(t, pConfig.TLSConfig)
+	assert.Equal(t, "redis.internal", pConfig.TLSConfig.ServerName)
+	assert.Equal(t, uint16(tls.VersionTLS13), pConfig.TLSConfig.MinVersion)
+	assert.False(t, pConfig.TLSConfig.InsecureSkipVerify)
+	assert.NotNil(t, pConfig.TLSConfig.RootCAs)
+	assert.Len(t, pConfig.TLSConfig.Certificates, 0)
+}
+
+func TestShouldNotConfigureTLSWhenNotEnabled(t *testing.T) {
+	configuration := schema.SessionConfiguration{}
+	configuration.Redis = &schema.RedisSessionConfiguration{
+		Host: "redis.example.com",
+		Port: 6379,
+	}
+	providerConfig := NewProviderConfig(configuration)
+
+	assert.Nil(t, providerConfig.redisConfig.TLSConfig)
+}
+
+func TestShouldFailToLoadMissingRedisTrustedCert(t *testing.T) {
+	_, err := newRedisTLSConfig(schema.RedisTLSConfiguration{TrustedCert: "/path/not/found/ca.pem"})
+	assert.EqualError(t, err, "Unable to read the trusted certificate of redis: open /path/not/found/ca.pem: no such file or directory")
+
+	_, err = newRedisTLSConfig(schema.RedisTLSConfiguration{Certificate: "/path/not/found/cert.pem", Key: "/path/not/found/key.pem"})
+	assert.EqualError(t, err, "Unable to load the client certificate of redis: open /path/not/found/cert.pem: no such file or directory")
+}
+
 func TestShouldSetDbNumber(t *testing.T) {
 	configuration := schema.SessionConfiguration{}
 	configuration.Domain = testDomain
@@ -1,7 +1,9 @@
 package session
 
 import (
+	"net"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -18,7 +20,7 @@ func TestShouldInitializerSession(t *testing.T) {
 	configuration.Name = testName
 	configuration.Expiration = testExpiration
 
-	provider := NewProvider(configuration)
+	provider := NewProvider(configuration, nil, nil, nil)
 	session, err := provider.GetSession(ctx)
 	require.NoError(t, err)
 
@@ -32,7 +34,7 @@ func TestShouldUpdateSession(t *testing.T) {
 	configuration.Name = testName
 	configuration.Expiration = testExpiration
 
-	provider := NewProvider(configuration)
+	provider := NewProvider(configuration, nil, nil, nil)
 	session, _ := provider.GetSession(ctx)
 
 	session.Username = testUsername
@@ -57,7 +59,7 @@ func TestShouldDestroySessionAndWipeSessionData(t *testing.T) {
 	configuration.Name = testName
 	configuration.Expiration = testExpiration
 
-	provider := NewProvider(configuration)
+	provider := NewProvider(configuration, nil, nil, nil)
 	session, err := provider.GetSession(ctx)
 	require.NoError(t, err)
 
@@ -80,3 +82,208 @@ func TestShouldDestroySessionAndWipeSessionData(t *testing.T) {
 	assert.Equal(t, "", newUserSession.Username)
 	assert.Equal(t, authentication.NotAuthenticated, newUserSession.AuthenticationLevel)
 }
+
+func TestShouldIssueCookieForDomainOfRequest(t *testing.T) {
+	configuration := schema.SessionConfiguration{}
+	configuration.Domain = testDomain
+	configuration.Name = testName
+	configuration.Expiration = testExpiration
+	configuration.RememberMeDuration = "1h"
+
+	provider := NewProvider(configuration, []schema.DomainConfiguration{
+		{Domain: testDomain},
+		{Domain: "example.io", Expiration: "1h", RememberMeDuration: "2h"},
+	}, nil, nil)
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetHost("login.example.io")
+
+	session, err := provider.GetSession(ctx)
+	require.NoError(t, err)
+
+	session.Username = testUsername
+	err = provider.SaveSession(ctx, session)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(ctx.Response.Header.PeekCookie(testName)), "domain=example.io")
+	assert.Equal(t, 2*time.Hour, provider.GetRememberMe(ctx))
+
+	expiration, err := provider.GetExpiration(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, expiration)
+
+	ctx = &fasthttp.RequestCtx{}
+	ctx.Request.Header.Set("X-Original-URL", "https://app.example.com/")
+
+	session, err = provider.GetSession(ctx)
+	require.NoError(t, err)
+
+	err = provider.SaveSession(ctx, session)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(ctx.Response.Header.PeekCookie(testName)), "domain=example.com")
+	assert.Equal(t, time.Hour, provider.GetRememberMe(ctx))
+}
+
+func TestShouldIssueCookieForDomainOfSessionWhenRequestIsNotProtected(t *testing.T) {
+	configuration := schema.SessionConfiguration{}
+	configuration.Domain = testDomain
+	configuration.Name = testName
+	configuration.Expiration = testExpiration
+
+	provider := NewProvider(configuration, []schema.DomainConfiguration{
+		{Domain: "example.io"},
+		{Domain: testDomain},
+	}, nil, nil)
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetHost("example.org")
+
+	session, err := provider.GetSession(ctx)
+	require.NoError(t, err)
+
+	err = provider.SaveSession(ctx, session)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(ctx.Response.Header.PeekCookie(testName)), "domain=example.com")
+}
+
+func TestShouldSetCookieAttributes(t *testing.T) {
+	configuration := schema.SessionConfiguration{}
+	configuration.Domain = testDomain
+	configuration.Name = testName
+	configuration.Expiration = testExpiration
+	configuration.CookiePath = "/auth"
+
+	provider := NewProvider(configuration, []schema.DomainConfiguration{
+		{Domain: testDomain},
+		{Domain: "admin.example.io", SameSite: schema.SessionSameSiteStrict},
+	}, nil, nil)
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetHost("login.example.com")
+
+	session, err := provider.GetSession(ctx)
+	require.NoError(t, err)
+
+	session.Username = testUsername
+	require.NoError(t, provider.SaveSession(ctx, session))
+
+	cookie := string(ctx.Response.Header.PeekCookie(testName))
+	assert.Contains(t, cookie, "path=/auth")
+	assert.Contains(t, cookie, "HttpOnly")
+	assert.Contains(t, cookie, "SameSite=Lax")
+
+	ctx = &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetHost("admin.example.io")
+
+	session, err = provider.GetSession(ctx)
+	require.NoError(t, err)
+
+	session.Username = testUsername
+	require.NoError(t, provider.SaveSession(ctx, session))
+
+	cookie = string(ctx.Response.Header.PeekCookie(testName))
+	assert.Contains(t, cookie, "path=/auth")
+	assert.Contains(t, cookie, "SameSite=Strict")
+
+	// The cookie is deleted with the same attributes.
+	require.NoError(t, provider.DestroySession(ctx))
+
+	cookie = string(ctx.Response.Header.PeekCookie(testName))
+	assert.Contains(t, cookie, "path=/auth")
+	assert.Contains(t, cookie, "SameSite=Strict")
+}
+
+func TestShouldSetSameSiteNoneCookie(t *testing.T) {
+	configuration := schema.SessionConfiguration{}
+	configuration.Domain = testDomain
+	configuration.Name = testName
+	configuration.Expiration = testExpiration
+	configuration.SameSite = schema.SessionSameSiteNone
+
+	provider := NewProvider(configuration, nil, nil, nil)
+
+	ctx := &fasthttp.RequestCtx{}
+
+	session, err := provider.GetSession(ctx)
+	require.NoError(t, err)
+
+	session.Username = testUsername
+	require.NoError(t, provider.SaveSession(ctx, session))
+
+	cookie := string(ctx.Response.Header.PeekCookie(testName))
+	assert.Contains(t, cookie, "path=/")
+	assert.Contains(t, cookie, "SameSite=None")
+	assert.Contains(t, cookie, "secure")
+}
+
+func TestShouldNotShareSessionsBetweenDomains(t *testing.T) {
+	configuration := schema.SessionConfiguration{}
+	configuration.Domain = testDomain
+	configuration.Name = testName
+	configuration.Expiration = testExpiration
+
+	provider := NewProvider(configuration, []schema.DomainConfiguration{
+		{Domain: testDomain},
+		{Domain: "example.io"},
+	}, nil, nil)
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetHost("login.example.com")
+
+	session, err := provider.GetSession(ctx)
+	require.NoError(t, err)
+
+	session.Username = testUsername
+	require.NoError(t, provider.SaveSession(ctx, session))
+
+	cookie := fasthttp.AcquireCookie()
+	defer fasthttp.ReleaseCookie(cookie)
+
+	cookie.SetKey(testName)
+	require.True(t, ctx.Response.Header.Cookie(cookie))
+
+	// The cookie of example.com replayed against example.io does not open the session.
+	ctx = &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetHost("app.example.io")
+	ctx.Request.Header.SetCookieBytesKV(cookie.Key(), cookie.Value())
+
+	session, err = provider.GetSession(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "", session.Username)
+
+	ctx = &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetHost("app.example.com")
+	ctx.Request.Header.SetCookieBytesKV(cookie.Key(), cookie.Value())
+
+	session, err = provider.GetSession(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, testUsername, session.Username)
+}
+
+func TestShouldIgnoreForwardedHostOfUntrustedClients(t *testing.T) {
+	configuration := schema.SessionConfiguration{}
+	configuration.Domain = testDomain
+	configuration.Name = testName
+	configuration.Expiration = testExpiration
+
+	provider := NewProvider(configuration, []schema.DomainConfiguration{
+		{Domain: testDomain},
+		{Domain: "example.io"},
+	}, []string{"10.0.0.0/8"}, nil)
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Init(&fasthttp.Request{}, &net.TCPAddr{IP: net.ParseIP("192.168.1.1")}, nil)
+	ctx.Request.Header.SetHost("login.example.com")
+	ctx.Request.Header.Set("X-Forwarded-Host", "login.example.io")
+
+	assert.Equal(t, "login.example.com", provider.getRequestHost(ctx))
+
+	ctx = &fasthttp.RequestCtx{}
+	ctx.Init(&fasthttp.Request{}, &net.TCPAddr{IP: net.ParseIP("10.0.0.1")}, nil)
+	ctx.Request.Header.SetHost("login.example.com")
+	ctx.Request.Header.Set("X-Forwarded-Host", "login.example.io")
+
+	assert.Equal(t, "login.example.io", provider.getRequestHost(ctx))
+}
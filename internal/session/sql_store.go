@@ -0,0 +1,117 @@
+package session
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/authelia/authelia/internal/logging"
+)
+
+// SQLStorage is the storage holding the sessions of the SQL session provider and the indexes of the active sessions of
+// the users.
+type SQLStorage interface {
+	LoadSession(id string, now time.Time) ([]byte, error)
+	SaveSession(id string, data []byte, expiresAt time.Time) error
+	RegenerateSession(id string, newID string, expiresAt time.Time) error
+	DeleteSession(id string) error
+	DeleteExpiredSessions(now time.Time) (int64, error)
+	CountSessions(now time.Time) (int, error)
+	LoadSessions(now time.Time) ([][]byte, error)
+
+	SaveActiveSession(username, id string, data []byte, expiresAt time.Time) error
+	LoadActiveSessions(username string, now time.Time) ([][]byte, error)
+	DeleteActiveSession(username, id string) error
+}
+
+// sqlStore is a session store keeping the sessions in the SQL storage, the sessions are encrypted by the serializer
+// of the session holder before reaching it.
+type sqlStore struct {
+	storage SQLStorage
+	stopGC  func()
+}
+
+// newSQLStore creates a session store keeping the sessions in the given SQL storage.
+func newSQLStore(storage SQLStorage) *sqlStore {
+	return &sqlStore{storage: storage}
+}
+
+// Get returns the data of a session, nil if it does not exist or has expired.
+func (s *sqlStore) Get(id []byte) ([]byte, error) {
+	return s.storage.LoadSession(string(id), time.Now())
+}
+
+// Save saves the data of a session, an expiration of 0 means the session never expires.
+func (s *sqlStore) Save(id, data []byte, expiration time.Duration) error {
+	return s.storage.SaveSession(string(id), data, storeExpiresAt(expiration))
+}
+
+// Regenerate changes the identifier of a session.
+func (s *sqlStore) Regenerate(id, newID []byte, expiration time.Duration) error {
+	return s.storage.RegenerateSession(string(id), string(newID), storeExpiresAt(expiration))
+}
+
+// Destroy deletes a session.
+func (s *sqlStore) Destroy(id []byte) error {
+	return s.storage.DeleteSession(string(id))
+}
+
+// Count returns the number of sessions which have not expired yet.
+func (s *sqlStore) Count() int {
+	count, err := s.storage.CountSessions(time.Now())
+	if err != nil {
+		logging.Logger().Errorf("Unable to count the sessions: %s", err)
+	}
+
+	return count
+}
+
+// Sessions returns the data of the sessions which have not expired yet.
+func (s *sqlStore) Sessions() ([][]byte, error) {
+	return s.storage.LoadSessions(time.Now())
+}
+
+// NeedGC returns false since the expired sessions are collected by the loop started with StartGC instead of one loop
+// per session holder.
+func (s *sqlStore) NeedGC() bool {
+	return false
+}
+
+// GC deletes the sessions which have expired.
+func (s *sqlStore) GC() error {
+	deleted, err := s.storage.DeleteExpiredSessions(time.Now())
+	if err != nil {
+		return fmt.Errorf("Unable to delete the expired sessions: %s", err)
+	}
+
+	if deleted > 0 {
+		logging.Logger().Debugf("%d expired session(s) have been deleted", deleted)
+	}
+
+	return nil
+}
+
+// StartGC deletes the expired sessions every interval until StopGC is called.
+func (s *sqlStore) StartGC(interval time.Duration) {
+	s.stopGC = runEvery(interval, func() {
+		if err := s.GC(); err != nil {
+			logging.Logger().Error(err)
+		}
+	})
+}
+
+// StopGC stops the deletion of the expired sessions started with StartGC.
+func (s *sqlStore) StopGC() {
+	if s.stopGC != nil {
+		s.stopGC()
+		s.stopGC = nil
+	}
+}
+
+// storeExpiresAt returns the expiry time of a session given its expiration, the zero time if it never expires.
+func storeExpiresAt(expiration time.Duration) time.Time {
+	if expiration <= 0 {
+		return time.Time{}
+	}
+
+	return time.Now().Add(expiration)
+}
@@ -0,0 +1,244 @@
+package session
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/session/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/storage"
+)
+
+func TestShouldSaveSessionInSQLStorage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storageMock := storage.NewMockProvider(ctrl)
+	store := newSQLStore(storageMock)
+
+	before := time.Now()
+
+	storageMock.EXPECT().
+		SaveSession("abc", []byte("data"), gomock.Any()).
+		DoAndReturn(func(id string, data []byte, expiresAt time.Time) error {
+			assert.False(t, expiresAt.Before(before.Add(time.Hour)))
+			assert.False(t, expiresAt.After(time.Now().Add(time.Hour)))
+
+			return nil
+		})
+
+	require.NoError(t, store.Save([]byte("abc"), []byte("data"), time.Hour))
+
+	// A session without expiration never expires.
+	storageMock.EXPECT().SaveSession("def", []byte("data"), time.Time{}).Return(nil)
+	require.NoError(t, store.Save([]byte("def"), []byte("data"), 0))
+
+	storageMock.EXPECT().LoadSession("abc", gomock.Any()).Return([]byte("data"), nil)
+
+	data, err := store.Get([]byte("abc"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data"), data)
+
+	storageMock.EXPECT().RegenerateSession("abc", "ghi", time.Time{}).Return(nil)
+	require.NoError(t, store.Regenerate([]byte("abc"), []byte("ghi"), 0))
+
+	storageMock.EXPECT().DeleteSession("ghi").Return(nil)
+	require.NoError(t, store.Destroy([]byte("ghi")))
+
+	storageMock.EXPECT().CountSessions(gomock.Any()).Return(1, nil)
+	assert.Equal(t, 1, store.Count())
+
+	assert.False(t, store.NeedGC())
+}
+
+func TestShouldDeleteExpiredSessionsFromSQLStorage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storageMock := storage.NewMockProvider(ctrl)
+	store := newSQLStore(storageMock)
+
+	storageMock.EXPECT().DeleteExpiredSessions(gomock.Any()).Return(int64(2), nil)
+	assert.NoError(t, store.GC())
+
+	// The errors are only logged by the collection loop, the next collection will delete them.
+	storageMock.EXPECT().DeleteExpiredSessions(gomock.Any()).Return(int64(0), fmt.Errorf("failed"))
+	assert.EqualError(t, store.GC(), "Unable to delete the expired sessions: failed")
+}
+
+func TestShouldStopDeletingExpiredSessionsFromSQLStorage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storageMock := storage.NewMockProvider(ctrl)
+	store := newSQLStore(storageMock)
+
+	collected := make(chan struct{}, 1)
+
+	storageMock.EXPECT().DeleteExpiredSessions(gomock.Any()).
+		DoAndReturn(func(now time.Time) (int64, error) {
+			select {
+			case collected <- struct{}{}:
+			default:
+			}
+
+			return 0, nil
+		}).MinTimes(1)
+
+	store.StartGC(time.Millisecond)
+
+	select {
+	case <-collected:
+	case <-time.After(time.Second):
+		t.Fatal("The expired sessions have not been deleted")
+	}
+
+	store.StopGC()
+
+	// No collection happens once stopped.
+	select {
+	case <-collected:
+	default:
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	select {
+	case <-collected:
+		t.Fatal("The expired sessions have been deleted after the collection was stopped")
+	default:
+	}
+}
+
+func TestShouldEncryptSessionsOfSQLProvider(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storageMock := storage.NewMockProvider(ctrl)
+
+	configuration := schema.SessionConfiguration{}
+	configuration.Domain = testDomain
+	configuration.Name = testName
+	configuration.Expiration = testExpiration
+	configuration.Secret = "abc"
+	configuration.SQL = &schema.SQLSessionConfiguration{GCInterval: "1h"}
+
+	providerConfig := NewProviderConfig(configuration)
+	assert.Equal(t, "sql", providerConfig.providerName)
+	assert.Equal(t, time.Hour, providerConfig.sqlGCInterval)
+
+	provider := NewProvider(configuration, nil, nil, storageMock)
+
+	saved := make(map[string][]byte)
+
+	storageMock.EXPECT().LoadSession(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(id string, now time.Time) ([]byte, error) {
+			return saved[id], nil
+		}).AnyTimes()
+	storageMock.EXPECT().SaveSession(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(id string, data []byte, expiresAt time.Time) error {
+			saved[id] = data
+			return nil
+		}).AnyTimes()
+
+	ctx := &fasthttp.RequestCtx{}
+
+	userSession, err := provider.GetSession(ctx)
+	require.NoError(t, err)
+
+	userSession.Username = testUsername
+	userSession.AuthenticationLevel = authentication.OneFactor
+	require.NoError(t, provider.SaveSession(ctx, userSession))
+
+	require.Len(t, saved, 1)
+
+	for _, data := range saved {
+		assert.NotContains(t, string(data), testUsername)
+	}
+
+	userSession, err = provider.GetSession(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, testUsername, userSession.Username)
+}
+
+func TestShouldPanicWhenSQLProviderHasNoStorage(t *testing.T) {
+	configuration := schema.SessionConfiguration{}
+	configuration.Domain = testDomain
+	configuration.Name = testName
+	configuration.Expiration = testExpiration
+	configuration.Secret = "abc"
+	configuration.SQL = &schema.SQLSessionConfiguration{GCInterval: "1h"}
+
+	assert.PanicsWithError(t, "The SQL session provider requires a SQL storage backend", func() {
+		NewProvider(configuration, nil, nil, nil)
+	})
+}
+
+func TestShouldEncryptSessionsOfPreviousSecretWithCurrentSecret(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storageMock := storage.NewMockProvider(ctrl)
+
+	configuration := schema.SessionConfiguration{}
+	configuration.Domain = testDomain
+	configuration.Name = testName
+	configuration.Expiration = testExpiration
+	configuration.Secret = "oldsecret"
+	configuration.SQL = &schema.SQLSessionConfiguration{GCInterval: "1h"}
+
+	saved := make(map[string][]byte)
+
+	storageMock.EXPECT().LoadSession(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(id string, now time.Time) ([]byte, error) {
+			return saved[id], nil
+		}).AnyTimes()
+	storageMock.EXPECT().SaveSession(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(id string, data []byte, expiresAt time.Time) error {
+			saved[id] = data
+			return nil
+		}).AnyTimes()
+
+	provider := NewProvider(configuration, nil, nil, storageMock)
+	ctx := &fasthttp.RequestCtx{}
+
+	userSession, err := provider.GetSession(ctx)
+	require.NoError(t, err)
+
+	userSession.Username = testUsername
+	userSession.KeepMeLoggedIn = true
+	require.NoError(t, provider.SaveSession(ctx, userSession))
+
+	cookie := fasthttp.AcquireCookie()
+	defer fasthttp.ReleaseCookie(cookie)
+
+	cookie.SetKey(testName)
+	require.True(t, ctx.Response.Header.Cookie(cookie))
+
+	// The secret is rotated and the session is only read.
+	configuration.Secret = "newsecret"
+	configuration.PreviousSecrets = []string{"oldsecret"}
+	provider = NewProvider(configuration, nil, nil, storageMock)
+
+	ctx = &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetCookieBytesKV([]byte(testName), cookie.Value())
+
+	userSession, err = provider.GetSession(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, testUsername, userSession.Username)
+
+	require.Len(t, saved, 1)
+
+	for _, data := range saved {
+		decoded := session.Dict{}
+		require.NoError(t, NewEncryptingSerializer("newsecret").Decode(&decoded, data))
+		assert.Nil(t, decoded.Get(previousSecretStorerKey))
+	}
+}
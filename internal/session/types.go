@@ -12,9 +12,13 @@ import (
 
 // ProviderConfig is the configuration used to create the session provider.
 type ProviderConfig struct {
-	config       session.Config
-	redisConfig  *redis.Config
-	providerName string
+	config              session.Config
+	redisConfig         *redis.Config
+	redisSentinelConfig *redis.FailoverConfig
+	sqlGCInterval       time.Duration
+	serializer          *EncryptingSerializer
+	memorySnapshotPath  string
+	providerName        string
 }
 
 // U2FRegistration is a serializable version of a U2F registration.
@@ -30,11 +34,33 @@ type UserSession struct {
 	// TODO(c.michaud): move groups out of the session.
 	Groups []string
 	Emails []string
+	// The extra attributes of the user, indexed by attribute name.
+	Attributes map[string][]string
 
 	KeepMeLoggedIn      bool
 	AuthenticationLevel authentication.Level
 	LastActivity        int64
 
+	// The generation of the sessions of the user at login, the session is invalidated when it becomes stale.
+	Generation int
+
+	// The fingerprint of the client recorded at login when the sessions are bound to their clients, the prefix of its
+	// IP address and the hash of its user agent.
+	BindingIP        string
+	BindingUserAgent string
+
+	// The time and the authentication level recorded in the index of the active sessions of the user when the entry of
+	// the session was last updated.
+	ActiveSessionUpdatedAt int64
+	ActiveSessionLevel     authentication.Level
+
+	// The second factor methods used by the user since the first factor was validated.
+	AuthenticationMethods []string
+
+	// The timestamps at which the user last completed the first and the second factor.
+	FirstFactorAuthnTimestamp  int64
+	SecondFactorAuthnTimestamp int64
+
 	// The challenge generated in first step of U2F registration (after identity verification) or authentication.
 	// This is used reused in the second phase to check that the challenge has been completed.
 	U2FChallenge *u2f.Challenge
@@ -49,6 +75,17 @@ type UserSession struct {
 	RefreshTTL time.Time
 }
 
+// ActiveSession is the metadata of an active session of a user. The ID is a public identifier of the session derived
+// from the session ID, which must never be disclosed since it is the value of the session cookie.
+type ActiveSession struct {
+	ID                  string               `json:"id"`
+	CreatedAt           int64                `json:"created_at"`
+	LastActivity        int64                `json:"last_activity"`
+	IP                  string               `json:"ip"`
+	UserAgent           string               `json:"user_agent"`
+	AuthenticationLevel authentication.Level `json:"authentication_level"`
+}
+
 // Identity identity of the user who is being verified.
 type Identity struct {
 	Username string
@@ -1,7 +1,10 @@
 package session
 
 import (
+	"time"
+
 	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/utils"
 )
 
 // NewDefaultUserSession create a default user session.
@@ -12,3 +15,29 @@ func NewDefaultUserSession() UserSession {
 		LastActivity:        0,
 	}
 }
+
+// AddAuthenticationMethod records a second factor method used by the user in the session.
+func (s *UserSession) AddAuthenticationMethod(method string) {
+	if !utils.IsStringInSlice(method, s.AuthenticationMethods) {
+		s.AuthenticationMethods = append(s.AuthenticationMethods, method)
+	}
+}
+
+// IsActiveSessionOutdated returns true when the entry of the session in the index of the active sessions of the user
+// must be updated: the user logged in or changed authentication level, or the entry was last updated more than one
+// interval ago.
+func (s *UserSession) IsActiveSessionOutdated(now time.Time) bool {
+	if s.Username == "" {
+		return false
+	}
+
+	return s.ActiveSessionLevel != s.AuthenticationLevel ||
+		now.Unix()-s.ActiveSessionUpdatedAt >= int64(activeSessionUpdateInterval/time.Second)
+}
+
+// MarkActiveSessionUpdated records that the entry of the session in the index of the active sessions of the user has
+// been updated at the given time.
+func (s *UserSession) MarkActiveSessionUpdated(now time.Time) {
+	s.ActiveSessionUpdatedAt = now.Unix()
+	s.ActiveSessionLevel = s.AuthenticationLevel
+}
@@ -2,9 +2,10 @@ package storage
 
 import (
 	"fmt"
+	"time"
 )
 
-const storageSchemaCurrentVersion = SchemaVersion(1)
+const storageSchemaCurrentVersion = SchemaVersion(4)
 const storageSchemaUpgradeMessage = "Storage schema upgraded to v"
 const storageSchemaUpgradeErrorText = "storage schema upgrade failed at v"
 
@@ -15,6 +16,10 @@ const totpSecretsTableName = "totp_secrets"
 const u2fDeviceHandlesTableName = "u2f_devices"
 const authenticationLogsTableName = "authentication_logs"
 const configTableName = "config"
+const accessGrantsTableName = "access_grants"
+const sessionGenerationsTableName = "session_generations"
+const sessionsTableName = "sessions"
+const activeSessionsTableName = "active_sessions"
 
 // sqlUpgradeCreateTableStatements is a map of the schema version number, plus a map of the table name and the statement used to create it.
 // The statement is fmt.Sprintf'd with the table name as the first argument.
@@ -27,6 +32,16 @@ var sqlUpgradeCreateTableStatements = map[SchemaVersion]map[string]string{
 		authenticationLogsTableName:         "CREATE TABLE %s (username VARCHAR(100), successful BOOL, time INTEGER)",
 		configTableName:                     "CREATE TABLE %s (category VARCHAR(32) NOT NULL, key_name VARCHAR(32) NOT NULL, value TEXT, PRIMARY KEY (category, key_name))",
 	},
+	SchemaVersion(2): {
+		accessGrantsTableName: "CREATE TABLE %s (id VARCHAR(32) PRIMARY KEY, subject VARCHAR(100), domain VARCHAR(255), resource VARCHAR(512), policy VARCHAR(16), expires_at BIGINT)",
+	},
+	SchemaVersion(3): {
+		sessionGenerationsTableName: "CREATE TABLE %s (username VARCHAR(100) PRIMARY KEY, generation INTEGER NOT NULL)",
+	},
+	SchemaVersion(4): {
+		sessionsTableName:       "CREATE TABLE %s (id VARCHAR(255) PRIMARY KEY, data TEXT NOT NULL, expires_at BIGINT NOT NULL)",
+		activeSessionsTableName: "CREATE TABLE %s (username VARCHAR(100) NOT NULL, id VARCHAR(64) NOT NULL, data TEXT NOT NULL, expires_at BIGINT NOT NULL, PRIMARY KEY (username, id))",
+	},
 }
 
 // sqlUpgradesCreateTableIndexesStatements is a map of t he schema version number, plus a slice of statements to create all of the indexes.
@@ -37,3 +52,7 @@ var sqlUpgradesCreateTableIndexesStatements = map[SchemaVersion][]string{
 }
 
 const unitTestUser = "john"
+
+// SessionGenerationCacheTTL is the duration the generations of the sessions are cached, the sessions invalidated by
+// another instance stay valid for this duration at most.
+const SessionGenerationCacheTTL = 10 * time.Second
@@ -39,6 +39,26 @@ func NewMySQLProvider(configuration schema.MySQLStorageConfiguration) *MySQLProv
 			sqlInsertAuthenticationLog:     fmt.Sprintf("INSERT INTO %s (username, successful, time) VALUES (?, ?, ?)", authenticationLogsTableName),
 			sqlGetLatestAuthenticationLogs: fmt.Sprintf("SELECT successful, time FROM %s WHERE time>? AND username=? ORDER BY time DESC", authenticationLogsTableName),
 
+			sqlInsertAccessGrant:     fmt.Sprintf("INSERT INTO %s (id, subject, domain, resource, policy, expires_at) VALUES (?, ?, ?, ?, ?, ?)", accessGrantsTableName),
+			sqlGetActiveAccessGrants: fmt.Sprintf("SELECT id, subject, domain, resource, policy, expires_at FROM %s WHERE expires_at>? ORDER BY expires_at", accessGrantsTableName),
+			sqlDeleteAccessGrant:     fmt.Sprintf("DELETE FROM %s WHERE id=?", accessGrantsTableName),
+
+			sqlGetSessionGenerationByUsername: fmt.Sprintf("SELECT generation FROM %s WHERE username=?", sessionGenerationsTableName),
+			sqlIncrementSessionGeneration:     fmt.Sprintf("INSERT INTO %s (username, generation) VALUES (?, 1) ON DUPLICATE KEY UPDATE generation=generation+1", sessionGenerationsTableName),
+
+			sqlGetSessionData:         fmt.Sprintf("SELECT data FROM %s WHERE id=? AND (expires_at=0 OR expires_at>?)", sessionsTableName),
+			sqlUpsertSessionData:      fmt.Sprintf("REPLACE INTO %s (id, data, expires_at) VALUES (?, ?, ?)", sessionsTableName),
+			sqlRegenerateSession:      fmt.Sprintf("UPDATE %s SET id=?, expires_at=? WHERE id=?", sessionsTableName),
+			sqlDeleteSession:          fmt.Sprintf("DELETE FROM %s WHERE id=?", sessionsTableName),
+			sqlDeleteExpiredSessions:  fmt.Sprintf("DELETE FROM %s WHERE expires_at<>0 AND expires_at<=?", sessionsTableName),
+			sqlCountActiveSessionData: fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE expires_at=0 OR expires_at>?", sessionsTableName),
+			sqlGetSessionsData:        fmt.Sprintf("SELECT data FROM %s WHERE expires_at=0 OR expires_at>?", sessionsTableName),
+
+			sqlUpsertActiveSession:         fmt.Sprintf("REPLACE INTO %s (username, id, data, expires_at) VALUES (?, ?, ?, ?)", activeSessionsTableName),
+			sqlGetActiveSessionsByUsername: fmt.Sprintf("SELECT data FROM %s WHERE username=? AND (expires_at=0 OR expires_at>?)", activeSessionsTableName),
+			sqlDeleteActiveSession:         fmt.Sprintf("DELETE FROM %s WHERE username=? AND id=?", activeSessionsTableName),
+			sqlDeleteExpiredActiveSessions: fmt.Sprintf("DELETE FROM %s WHERE expires_at<>0 AND expires_at<=?", activeSessionsTableName),
+
 			sqlGetExistingTables: "SELECT table_name FROM information_schema.tables WHERE table_type='BASE TABLE' AND table_schema=database()",
 
 			sqlConfigSetValue: fmt.Sprintf("REPLACE INTO %s (category, key_name, value) VALUES (?, ?, ?)", configTableName),
@@ -41,6 +41,26 @@ func NewPostgreSQLProvider(configuration schema.PostgreSQLStorageConfiguration)
 			sqlInsertAuthenticationLog:     fmt.Sprintf("INSERT INTO %s (username, successful, time) VALUES ($1, $2, $3)", authenticationLogsTableName),
 			sqlGetLatestAuthenticationLogs: fmt.Sprintf("SELECT successful, time FROM %s WHERE time>$1 AND username=$2 ORDER BY time DESC", authenticationLogsTableName),
 
+			sqlInsertAccessGrant:     fmt.Sprintf("INSERT INTO %s (id, subject, domain, resource, policy, expires_at) VALUES ($1, $2, $3, $4, $5, $6)", accessGrantsTableName),
+			sqlGetActiveAccessGrants: fmt.Sprintf("SELECT id, subject, domain, resource, policy, expires_at FROM %s WHERE expires_at>$1 ORDER BY expires_at", accessGrantsTableName),
+			sqlDeleteAccessGrant:     fmt.Sprintf("DELETE FROM %s WHERE id=$1", accessGrantsTableName),
+
+			sqlGetSessionGenerationByUsername: fmt.Sprintf("SELECT generation FROM %s WHERE username=$1", sessionGenerationsTableName),
+			sqlIncrementSessionGeneration:     fmt.Sprintf("INSERT INTO %s (username, generation) VALUES ($1, 1) ON CONFLICT (username) DO UPDATE SET generation=%s.generation+1", sessionGenerationsTableName, sessionGenerationsTableName),
+
+			sqlGetSessionData:         fmt.Sprintf("SELECT data FROM %s WHERE id=$1 AND (expires_at=0 OR expires_at>$2)", sessionsTableName),
+			sqlUpsertSessionData:      fmt.Sprintf("INSERT INTO %s (id, data, expires_at) VALUES ($1, $2, $3) ON CONFLICT (id) DO UPDATE SET data=$2, expires_at=$3", sessionsTableName),
+			sqlRegenerateSession:      fmt.Sprintf("UPDATE %s SET id=$1, expires_at=$2 WHERE id=$3", sessionsTableName),
+			sqlDeleteSession:          fmt.Sprintf("DELETE FROM %s WHERE id=$1", sessionsTableName),
+			sqlDeleteExpiredSessions:  fmt.Sprintf("DELETE FROM %s WHERE expires_at<>0 AND expires_at<=$1", sessionsTableName),
+			sqlCountActiveSessionData: fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE expires_at=0 OR expires_at>$1", sessionsTableName),
+			sqlGetSessionsData:        fmt.Sprintf("SELECT data FROM %s WHERE expires_at=0 OR expires_at>$1", sessionsTableName),
+
+			sqlUpsertActiveSession:         fmt.Sprintf("INSERT INTO %s (username, id, data, expires_at) VALUES ($1, $2, $3, $4) ON CONFLICT (username, id) DO UPDATE SET data=$3, expires_at=$4", activeSessionsTableName),
+			sqlGetActiveSessionsByUsername: fmt.Sprintf("SELECT data FROM %s WHERE username=$1 AND (expires_at=0 OR expires_at>$2)", activeSessionsTableName),
+			sqlDeleteActiveSession:         fmt.Sprintf("DELETE FROM %s WHERE username=$1 AND id=$2", activeSessionsTableName),
+			sqlDeleteExpiredActiveSessions: fmt.Sprintf("DELETE FROM %s WHERE expires_at<>0 AND expires_at<=$1", activeSessionsTableName),
+
 			sqlGetExistingTables: "SELECT table_name FROM information_schema.tables WHERE table_type='BASE TABLE' AND table_schema='public'",
 
 			sqlConfigSetValue: fmt.Sprintf("INSERT INTO %s (category, key_name, value) VALUES ($1, $2, $3) ON CONFLICT (category, key_name) DO UPDATE SET value=$3", configTableName),
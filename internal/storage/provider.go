@@ -3,6 +3,7 @@ package storage
 import (
 	"time"
 
+	"github.com/authelia/authelia/internal/configuration/schema"
 	"github.com/authelia/authelia/internal/models"
 )
 
@@ -25,4 +26,37 @@ type Provider interface {
 
 	AppendAuthenticationLog(attempt models.AuthenticationAttempt) error
 	LoadLatestAuthenticationLogs(username string, fromDate time.Time) ([]models.AuthenticationAttempt, error)
+
+	SaveAccessGrant(grant models.AccessGrant) error
+	LoadActiveAccessGrants(now time.Time) ([]models.AccessGrant, error)
+	DeleteAccessGrant(id string) (bool, error)
+
+	LoadSessionGeneration(username string) (int, error)
+	IncrementSessionGeneration(username string) error
+
+	LoadSession(id string, now time.Time) ([]byte, error)
+	SaveSession(id string, data []byte, expiresAt time.Time) error
+	RegenerateSession(id string, newID string, expiresAt time.Time) error
+	DeleteSession(id string) error
+	DeleteExpiredSessions(now time.Time) (int64, error)
+	CountSessions(now time.Time) (int, error)
+	LoadSessions(now time.Time) ([][]byte, error)
+
+	SaveActiveSession(username, id string, data []byte, expiresAt time.Time) error
+	LoadActiveSessions(username string, now time.Time) ([][]byte, error)
+	DeleteActiveSession(username, id string) error
+}
+
+// NewProvider instantiate the storage provider matching the configuration, nil if the configuration is not recognized.
+func NewProvider(configuration schema.StorageConfiguration) Provider {
+	switch {
+	case configuration.PostgreSQL != nil:
+		return NewPostgreSQLProvider(*configuration.PostgreSQL)
+	case configuration.MySQL != nil:
+		return NewMySQLProvider(*configuration.MySQL)
+	case configuration.Local != nil:
+		return NewSQLiteProvider(configuration.Local.Path)
+	}
+
+	return nil
 }
@@ -209,3 +209,221 @@ func (mr *MockProviderMockRecorder) LoadLatestAuthenticationLogs(username, fromD
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadLatestAuthenticationLogs", reflect.TypeOf((*MockProvider)(nil).LoadLatestAuthenticationLogs), username, fromDate)
 }
+
+// SaveAccessGrant mocks base method
+func (m *MockProvider) SaveAccessGrant(grant models.AccessGrant) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveAccessGrant", grant)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveAccessGrant indicates an expected call of SaveAccessGrant
+func (mr *MockProviderMockRecorder) SaveAccessGrant(grant interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveAccessGrant", reflect.TypeOf((*MockProvider)(nil).SaveAccessGrant), grant)
+}
+
+// LoadActiveAccessGrants mocks base method
+func (m *MockProvider) LoadActiveAccessGrants(now time.Time) ([]models.AccessGrant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadActiveAccessGrants", now)
+	ret0, _ := ret[0].([]models.AccessGrant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadActiveAccessGrants indicates an expected call of LoadActiveAccessGrants
+func (mr *MockProviderMockRecorder) LoadActiveAccessGrants(now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadActiveAccessGrants", reflect.TypeOf((*MockProvider)(nil).LoadActiveAccessGrants), now)
+}
+
+// DeleteAccessGrant mocks base method
+func (m *MockProvider) DeleteAccessGrant(id string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccessGrant", id)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteAccessGrant indicates an expected call of DeleteAccessGrant
+func (mr *MockProviderMockRecorder) DeleteAccessGrant(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccessGrant", reflect.TypeOf((*MockProvider)(nil).DeleteAccessGrant), id)
+}
+
+// LoadSessionGeneration mocks base method
+func (m *MockProvider) LoadSessionGeneration(username string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadSessionGeneration", username)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadSessionGeneration indicates an expected call of LoadSessionGeneration
+func (mr *MockProviderMockRecorder) LoadSessionGeneration(username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadSessionGeneration", reflect.TypeOf((*MockProvider)(nil).LoadSessionGeneration), username)
+}
+
+// IncrementSessionGeneration mocks base method
+func (m *MockProvider) IncrementSessionGeneration(username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrementSessionGeneration", username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// IncrementSessionGeneration indicates an expected call of IncrementSessionGeneration
+func (mr *MockProviderMockRecorder) IncrementSessionGeneration(username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementSessionGeneration", reflect.TypeOf((*MockProvider)(nil).IncrementSessionGeneration), username)
+}
+
+// LoadSession mocks base method
+func (m *MockProvider) LoadSession(id string, now time.Time) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadSession", id, now)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadSession indicates an expected call of LoadSession
+func (mr *MockProviderMockRecorder) LoadSession(id, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadSession", reflect.TypeOf((*MockProvider)(nil).LoadSession), id, now)
+}
+
+// SaveSession mocks base method
+func (m *MockProvider) SaveSession(id string, data []byte, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveSession", id, data, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveSession indicates an expected call of SaveSession
+func (mr *MockProviderMockRecorder) SaveSession(id, data, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSession", reflect.TypeOf((*MockProvider)(nil).SaveSession), id, data, expiresAt)
+}
+
+// RegenerateSession mocks base method
+func (m *MockProvider) RegenerateSession(id string, newID string, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegenerateSession", id, newID, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RegenerateSession indicates an expected call of RegenerateSession
+func (mr *MockProviderMockRecorder) RegenerateSession(id, newID, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegenerateSession", reflect.TypeOf((*MockProvider)(nil).RegenerateSession), id, newID, expiresAt)
+}
+
+// DeleteSession mocks base method
+func (m *MockProvider) DeleteSession(id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSession", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSession indicates an expected call of DeleteSession
+func (mr *MockProviderMockRecorder) DeleteSession(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSession", reflect.TypeOf((*MockProvider)(nil).DeleteSession), id)
+}
+
+// DeleteExpiredSessions mocks base method
+func (m *MockProvider) DeleteExpiredSessions(now time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredSessions", now)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredSessions indicates an expected call of DeleteExpiredSessions
+func (mr *MockProviderMockRecorder) DeleteExpiredSessions(now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredSessions", reflect.TypeOf((*MockProvider)(nil).DeleteExpiredSessions), now)
+}
+
+// CountSessions mocks base method
+func (m *MockProvider) CountSessions(now time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountSessions", now)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountSessions indicates an expected call of CountSessions
+func (mr *MockProviderMockRecorder) CountSessions(now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountSessions", reflect.TypeOf((*MockProvider)(nil).CountSessions), now)
+}
+
+// LoadSessions mocks base method
+func (m *MockProvider) LoadSessions(now time.Time) ([][]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadSessions", now)
+	ret0, _ := ret[0].([][]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadSessions indicates an expected call of LoadSessions
+func (mr *MockProviderMockRecorder) LoadSessions(now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadSessions", reflect.TypeOf((*MockProvider)(nil).LoadSessions), now)
+}
+
+// SaveActiveSession mocks base method
+func (m *MockProvider) SaveActiveSession(username, id string, data []byte, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveActiveSession", username, id, data, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveActiveSession indicates an expected call of SaveActiveSession
+func (mr *MockProviderMockRecorder) SaveActiveSession(username, id, data, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveActiveSession", reflect.TypeOf((*MockProvider)(nil).SaveActiveSession), username, id, data, expiresAt)
+}
+
+// LoadActiveSessions mocks base method
+func (m *MockProvider) LoadActiveSessions(username string, now time.Time) ([][]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadActiveSessions", username, now)
+	ret0, _ := ret[0].([][]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadActiveSessions indicates an expected call of LoadActiveSessions
+func (mr *MockProviderMockRecorder) LoadActiveSessions(username, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadActiveSessions", reflect.TypeOf((*MockProvider)(nil).LoadActiveSessions), username, now)
+}
+
+// DeleteActiveSession mocks base method
+func (m *MockProvider) DeleteActiveSession(username, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteActiveSession", username, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteActiveSession indicates an expected call of DeleteActiveSession
+func (mr *MockProviderMockRecorder) DeleteActiveSession(username, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteActiveSession", reflect.TypeOf((*MockProvider)(nil).DeleteActiveSession), username, id)
+}
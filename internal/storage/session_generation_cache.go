@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"sync"
+	"time"
+
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// SessionGenerationCache is a storage provider keeping the generations of the sessions in memory for a short time
+// since they are checked on every request of the users. The generations incremented by the other instances are then
+// seen with this delay at most. The sessions are not verified while the storage is unreachable.
+type SessionGenerationCache struct {
+	Provider
+
+	ttl   time.Duration
+	clock utils.Clock
+
+	mutex       sync.Mutex
+	generations map[string]sessionGenerationCacheEntry
+	prunedAt    time.Time
+
+	// Bumped when an increment starts and when it ends, a generation loaded while an increment was in progress may be
+	// stale and is not cached.
+	increments uint64
+}
+
+type sessionGenerationCacheEntry struct {
+	generation int
+	loadedAt   time.Time
+}
+
+// NewSessionGenerationCache creates a storage provider caching the generations of the sessions of the given provider
+// for the given duration.
+func NewSessionGenerationCache(provider Provider, ttl time.Duration, clock utils.Clock) *SessionGenerationCache {
+	return &SessionGenerationCache{
+		Provider:    provider,
+		ttl:         ttl,
+		clock:       clock,
+		generations: map[string]sessionGenerationCacheEntry{},
+		prunedAt:    clock.Now(),
+	}
+}
+
+// LoadSessionGeneration load the generation of the sessions of a user from the cache or the storage when it has not
+// been loaded recently.
+func (c *SessionGenerationCache) LoadSessionGeneration(username string) (int, error) {
+	now := c.clock.Now()
+
+	c.mutex.Lock()
+	entry, ok := c.generations[username]
+	increments := c.increments
+	c.mutex.Unlock()
+
+	if ok && now.Sub(entry.loadedAt) < c.ttl {
+		return entry.generation, nil
+	}
+
+	generation, err := c.Provider.LoadSessionGeneration(username)
+	if err != nil {
+		return 0, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.prune(now)
+
+	if c.increments == increments {
+		c.generations[username] = sessionGenerationCacheEntry{generation: generation, loadedAt: now}
+	}
+
+	return generation, nil
+}
+
+// IncrementSessionGeneration increment the generation of the sessions of a user and forget the cached one so that the
+// sessions are invalidated right away on this instance.
+func (c *SessionGenerationCache) IncrementSessionGeneration(username string) error {
+	c.mutex.Lock()
+	c.increments++
+	c.mutex.Unlock()
+
+	err := c.Provider.IncrementSessionGeneration(username)
+
+	c.mutex.Lock()
+	c.increments++
+	delete(c.generations, username)
+	c.mutex.Unlock()
+
+	return err
+}
+
+// prune removes the generations which have expired, at most once per TTL. The mutex must be held.
+func (c *SessionGenerationCache) prune(now time.Time) {
+	if now.Sub(c.prunedAt) < c.ttl {
+		return
+	}
+
+	for username, entry := range c.generations {
+		if now.Sub(entry.loadedAt) >= c.ttl {
+			delete(c.generations, username)
+		}
+	}
+
+	c.prunedAt = now
+}
@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fixedClock struct {
+	now time.Time
+}
+
+func (c *fixedClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fixedClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func TestShouldCacheSessionGenerations(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := NewMockProvider(ctrl)
+	clock := &fixedClock{now: time.Unix(1600000000, 0)}
+	cache := NewSessionGenerationCache(provider, 10*time.Second, clock)
+
+	provider.EXPECT().LoadSessionGeneration("john").Return(1, nil)
+
+	generation, err := cache.LoadSessionGeneration("john")
+	require.NoError(t, err)
+	assert.Equal(t, 1, generation)
+
+	clock.now = clock.now.Add(5 * time.Second)
+
+	generation, err = cache.LoadSessionGeneration("john")
+	require.NoError(t, err)
+	assert.Equal(t, 1, generation)
+
+	clock.now = clock.now.Add(5 * time.Second)
+
+	provider.EXPECT().LoadSessionGeneration("john").Return(2, nil)
+
+	generation, err = cache.LoadSessionGeneration("john")
+	require.NoError(t, err)
+	assert.Equal(t, 2, generation)
+}
+
+func TestShouldReloadSessionGenerationAfterIncrement(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := NewMockProvider(ctrl)
+	cache := NewSessionGenerationCache(provider, 10*time.Second, &fixedClock{now: time.Unix(1600000000, 0)})
+
+	gomock.InOrder(
+		provider.EXPECT().LoadSessionGeneration("john").Return(1, nil),
+		provider.EXPECT().IncrementSessionGeneration("john").Return(nil),
+		provider.EXPECT().LoadSessionGeneration("john").Return(2, nil),
+	)
+
+	_, err := cache.LoadSessionGeneration("john")
+	require.NoError(t, err)
+
+	require.NoError(t, cache.IncrementSessionGeneration("john"))
+
+	generation, err := cache.LoadSessionGeneration("john")
+	require.NoError(t, err)
+	assert.Equal(t, 2, generation)
+}
+
+func TestShouldReturnErrorWhenStorageIsUnreachable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := NewMockProvider(ctrl)
+	clock := &fixedClock{now: time.Unix(1600000000, 0)}
+	cache := NewSessionGenerationCache(provider, 10*time.Second, clock)
+
+	gomock.InOrder(
+		provider.EXPECT().LoadSessionGeneration("john").Return(1, nil),
+		provider.EXPECT().LoadSessionGeneration("john").Return(0, errors.New("connection refused")),
+	)
+
+	_, err := cache.LoadSessionGeneration("john")
+	require.NoError(t, err)
+
+	clock.now = clock.now.Add(time.Minute)
+
+	// The last generation loaded may have been incremented since, the session can't be verified.
+	_, err = cache.LoadSessionGeneration("john")
+	assert.EqualError(t, err, "connection refused")
+}
+
+func TestShouldNotCacheSessionGenerationLoadedDuringIncrement(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := NewMockProvider(ctrl)
+	cache := NewSessionGenerationCache(provider, 10*time.Second, &fixedClock{now: time.Unix(1600000000, 0)})
+
+	gomock.InOrder(
+		provider.EXPECT().LoadSessionGeneration("john").DoAndReturn(func(username string) (int, error) {
+			// The generation is incremented by another request once it has been read.
+			require.NoError(t, cache.IncrementSessionGeneration(username))
+			return 1, nil
+		}),
+		provider.EXPECT().IncrementSessionGeneration("john").Return(nil),
+		provider.EXPECT().LoadSessionGeneration("john").Return(2, nil),
+	)
+
+	generation, err := cache.LoadSessionGeneration("john")
+	require.NoError(t, err)
+	assert.Equal(t, 1, generation)
+
+	generation, err = cache.LoadSessionGeneration("john")
+	require.NoError(t, err)
+	assert.Equal(t, 2, generation)
+}
+
+func TestShouldPruneExpiredSessionGenerations(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := NewMockProvider(ctrl)
+	clock := &fixedClock{now: time.Unix(1600000000, 0)}
+	cache := NewSessionGenerationCache(provider, 10*time.Second, clock)
+
+	provider.EXPECT().LoadSessionGeneration("john").Return(1, nil)
+	provider.EXPECT().LoadSessionGeneration("harry").Return(1, nil)
+	provider.EXPECT().LoadSessionGeneration("bob").Return(1, nil)
+
+	_, err := cache.LoadSessionGeneration("john")
+	require.NoError(t, err)
+
+	clock.now = clock.now.Add(5 * time.Second)
+
+	_, err = cache.LoadSessionGeneration("harry")
+	require.NoError(t, err)
+	assert.Len(t, cache.generations, 2)
+
+	clock.now = clock.now.Add(5 * time.Second)
+
+	_, err = cache.LoadSessionGeneration("bob")
+	require.NoError(t, err)
+	assert.Len(t, cache.generations, 2)
+	assert.NotContains(t, cache.generations, "john")
+}
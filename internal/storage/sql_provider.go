@@ -39,6 +39,26 @@ type SQLProvider struct {
 	sqlInsertAuthenticationLog     string
 	sqlGetLatestAuthenticationLogs string
 
+	sqlInsertAccessGrant     string
+	sqlGetActiveAccessGrants string
+	sqlDeleteAccessGrant     string
+
+	sqlGetSessionGenerationByUsername string
+	sqlIncrementSessionGeneration     string
+
+	sqlGetSessionData         string
+	sqlUpsertSessionData      string
+	sqlRegenerateSession      string
+	sqlDeleteSession          string
+	sqlDeleteExpiredSessions  string
+	sqlCountActiveSessionData string
+	sqlGetSessionsData        string
+
+	sqlUpsertActiveSession         string
+	sqlGetActiveSessionsByUsername string
+	sqlDeleteActiveSession         string
+	sqlDeleteExpiredActiveSessions string
+
 	sqlGetExistingTables string
 
 	sqlConfigSetValue string
@@ -111,6 +131,27 @@ func (p *SQLProvider) upgrade() error {
 				return p.handleUpgradeFailure(tx, 1, err)
 			}
 
+			fallthrough
+		case 1:
+			err := p.upgradeSchemaToVersion002(tx, tables)
+			if err != nil {
+				return p.handleUpgradeFailure(tx, 2, err)
+			}
+
+			fallthrough
+		case 2:
+			err := p.upgradeSchemaToVersion003(tx, tables)
+			if err != nil {
+				return p.handleUpgradeFailure(tx, 3, err)
+			}
+
+			fallthrough
+		case 3:
+			err := p.upgradeSchemaToVersion004(tx, tables)
+			if err != nil {
+				return p.handleUpgradeFailure(tx, 4, err)
+			}
+
 			fallthrough
 		default:
 			err := tx.Commit()
@@ -283,3 +324,200 @@ func (p *SQLProvider) LoadLatestAuthenticationLogs(username string, fromDate tim
 
 	return attempts, nil
 }
+
+// SaveAccessGrant save an access grant in the database.
+func (p *SQLProvider) SaveAccessGrant(grant models.AccessGrant) error {
+	_, err := p.db.Exec(p.sqlInsertAccessGrant,
+		grant.ID, grant.Subject, grant.Domain, grant.Resource, grant.Policy, grant.ExpiresAt.Unix())
+
+	return err
+}
+
+// LoadActiveAccessGrants retrieve the access grants which have not expired yet at the given time.
+func (p *SQLProvider) LoadActiveAccessGrants(now time.Time) ([]models.AccessGrant, error) {
+	var t int64
+
+	rows, err := p.db.Query(p.sqlGetActiveAccessGrants, now.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	grants := make([]models.AccessGrant, 0)
+
+	for rows.Next() {
+		var grant models.AccessGrant
+
+		err = rows.Scan(&grant.ID, &grant.Subject, &grant.Domain, &grant.Resource, &grant.Policy, &t)
+		if err != nil {
+			return nil, err
+		}
+
+		grant.ExpiresAt = time.Unix(t, 0)
+		grants = append(grants, grant)
+	}
+
+	return grants, nil
+}
+
+// DeleteAccessGrant delete an access grant from the database given its identifier, false if it does not exist.
+func (p *SQLProvider) DeleteAccessGrant(id string) (bool, error) {
+	result, err := p.db.Exec(p.sqlDeleteAccessGrant, id)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// LoadSessionGeneration load the generation of the sessions of a user, 0 if the user never had them invalidated.
+func (p *SQLProvider) LoadSessionGeneration(username string) (int, error) {
+	var generation int
+	if err := p.db.QueryRow(p.sqlGetSessionGenerationByUsername, username).Scan(&generation); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+
+		return 0, err
+	}
+
+	return generation, nil
+}
+
+// IncrementSessionGeneration increment the generation of the sessions of a user, invalidating the sessions of the
+// previous generations.
+func (p *SQLProvider) IncrementSessionGeneration(username string) error {
+	_, err := p.db.Exec(p.sqlIncrementSessionGeneration, username)
+	return err
+}
+
+// LoadSession load the data of a session which has not expired yet at the given time, nil if there is none.
+func (p *SQLProvider) LoadSession(id string, now time.Time) ([]byte, error) {
+	var data string
+	if err := p.db.QueryRow(p.sqlGetSessionData, id, now.Unix()).Scan(&data); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return base64.StdEncoding.DecodeString(data)
+}
+
+// SaveSession save the data of a session in the database, a zero expiry time means the session never expires.
+func (p *SQLProvider) SaveSession(id string, data []byte, expiresAt time.Time) error {
+	_, err := p.db.Exec(p.sqlUpsertSessionData, id, base64.StdEncoding.EncodeToString(data), sessionExpiresAt(expiresAt))
+	return err
+}
+
+// RegenerateSession change the identifier and the expiry time of a session.
+func (p *SQLProvider) RegenerateSession(id string, newID string, expiresAt time.Time) error {
+	_, err := p.db.Exec(p.sqlRegenerateSession, newID, sessionExpiresAt(expiresAt), id)
+	return err
+}
+
+// DeleteSession delete a session from the database given its identifier.
+func (p *SQLProvider) DeleteSession(id string) error {
+	_, err := p.db.Exec(p.sqlDeleteSession, id)
+	return err
+}
+
+// DeleteExpiredSessions delete the sessions and the entries of the active sessions which have expired at the given
+// time and return how many sessions were deleted.
+func (p *SQLProvider) DeleteExpiredSessions(now time.Time) (int64, error) {
+	result, err := p.db.Exec(p.sqlDeleteExpiredSessions, now.Unix())
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := p.db.Exec(p.sqlDeleteExpiredActiveSessions, now.Unix()); err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// CountSessions count the sessions which have not expired yet at the given time.
+func (p *SQLProvider) CountSessions(now time.Time) (int, error) {
+	var count int
+
+	err := p.db.QueryRow(p.sqlCountActiveSessionData, now.Unix()).Scan(&count)
+
+	return count, err
+}
+
+// LoadSessions load the data of the sessions which have not expired yet at the given time.
+func (p *SQLProvider) LoadSessions(now time.Time) ([][]byte, error) {
+	rows, err := p.db.Query(p.sqlGetSessionsData, now.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions [][]byte
+
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+
+		decodedData, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, decodedData)
+	}
+
+	return sessions, rows.Err()
+}
+
+// SaveActiveSession save the entry of an active session of a user, a zero expiry time means it never expires.
+func (p *SQLProvider) SaveActiveSession(username, id string, data []byte, expiresAt time.Time) error {
+	_, err := p.db.Exec(p.sqlUpsertActiveSession, username, id, string(data), sessionExpiresAt(expiresAt))
+	return err
+}
+
+// LoadActiveSessions load the entries of the active sessions of a user which have not expired yet at the given time.
+func (p *SQLProvider) LoadActiveSessions(username string, now time.Time) ([][]byte, error) {
+	rows, err := p.db.Query(p.sqlGetActiveSessionsByUsername, username, now.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries [][]byte
+
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, []byte(data))
+	}
+
+	return entries, rows.Err()
+}
+
+// DeleteActiveSession delete the entry of an active session of a user.
+func (p *SQLProvider) DeleteActiveSession(username, id string) error {
+	_, err := p.db.Exec(p.sqlDeleteActiveSession, username, id)
+	return err
+}
+
+// sessionExpiresAt returns the expiry time of a session as stored in the database, 0 if it never expires.
+func sessionExpiresAt(expiresAt time.Time) int64 {
+	if expiresAt.IsZero() {
+		return 0
+	}
+
+	return expiresAt.Unix()
+}
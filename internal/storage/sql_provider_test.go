@@ -16,7 +16,7 @@ import (
 	"github.com/authelia/authelia/internal/models"
 )
 
-const currentSchemaMockSchemaVersion = "1"
+const currentSchemaMockSchemaVersion = "4"
 
 func TestSQLInitializeDatabase(t *testing.T) {
 	provider, mock := NewSQLMockProvider()
@@ -50,6 +50,37 @@ func TestSQLInitializeDatabase(t *testing.T) {
 		WithArgs("schema", "version", "1").
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE TABLE %s .*", accessGrantsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "2").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE TABLE %s .*", sessionGenerationsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "3").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE TABLE %s .*", activeSessionsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE TABLE %s .*", sessionsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "4").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
 	mock.ExpectCommit()
 
 	err := provider.initialize(provider.db)
@@ -83,13 +114,44 @@ func TestSQLUpgradeDatabase(t *testing.T) {
 		WithArgs("schema", "version", "1").
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE TABLE %s .*", accessGrantsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "2").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE TABLE %s .*", sessionGenerationsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "3").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE TABLE %s .*", activeSessionsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE TABLE %s .*", sessionsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "4").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
 	mock.ExpectCommit()
 
 	err := provider.initialize(provider.db)
 	assert.NoError(t, err)
 }
 
-func TestSQLProviderMethodsAuthenticationLogs(t *testing.T) {
+func TestSQLUpgradeDatabaseFromVersion1(t *testing.T) {
 	provider, mock := NewSQLMockProvider()
 
 	mock.ExpectQuery(
@@ -102,12 +164,169 @@ func TestSQLProviderMethodsAuthenticationLogs(t *testing.T) {
 			AddRow(authenticationLogsTableName).
 			AddRow(configTableName))
 
+	mock.ExpectQuery(
+		fmt.Sprintf("SELECT value FROM %s WHERE category=\\? AND key_name=\\?", configTableName)).
+		WithArgs("schema", "version").
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).
+			AddRow("1"))
+
+	mock.ExpectBegin()
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE TABLE %s .*", accessGrantsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "2").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE TABLE %s .*", sessionGenerationsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "3").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE TABLE %s .*", activeSessionsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE TABLE %s .*", sessionsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "4").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectCommit()
+
+	err := provider.initialize(provider.db)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSQLUpgradeDatabaseFromVersion2(t *testing.T) {
+	provider, mock := NewSQLMockProvider()
+
+	mock.ExpectQuery(
+		"SELECT name FROM sqlite_master WHERE type='table'").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).
+			AddRow(userPreferencesTableName).
+			AddRow(identityVerificationTokensTableName).
+			AddRow(totpSecretsTableName).
+			AddRow(u2fDeviceHandlesTableName).
+			AddRow(authenticationLogsTableName).
+			AddRow(configTableName).
+			AddRow(accessGrantsTableName))
+
+	mock.ExpectQuery(
+		fmt.Sprintf("SELECT value FROM %s WHERE category=\\? AND key_name=\\?", configTableName)).
+		WithArgs("schema", "version").
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).
+			AddRow("2"))
+
+	mock.ExpectBegin()
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE TABLE %s .*", sessionGenerationsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "3").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE TABLE %s .*", activeSessionsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE TABLE %s .*", sessionsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "4").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectCommit()
+
+	err := provider.initialize(provider.db)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSQLUpgradeDatabaseFromVersion3(t *testing.T) {
+	provider, mock := NewSQLMockProvider()
+
+	mock.ExpectQuery(
+		"SELECT name FROM sqlite_master WHERE type='table'").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).
+			AddRow(userPreferencesTableName).
+			AddRow(identityVerificationTokensTableName).
+			AddRow(totpSecretsTableName).
+			AddRow(u2fDeviceHandlesTableName).
+			AddRow(authenticationLogsTableName).
+			AddRow(configTableName).
+			AddRow(accessGrantsTableName).
+			AddRow(sessionGenerationsTableName))
+
+	mock.ExpectQuery(
+		fmt.Sprintf("SELECT value FROM %s WHERE category=\\? AND key_name=\\?", configTableName)).
+		WithArgs("schema", "version").
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).
+			AddRow("3"))
+
+	mock.ExpectBegin()
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE TABLE %s .*", activeSessionsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE TABLE %s .*", sessionsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "4").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectCommit()
+
+	err := provider.initialize(provider.db)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSQLProviderMethodsAuthenticationLogs(t *testing.T) {
+	provider, mock := NewSQLMockProvider()
+
+	mock.ExpectQuery(
+		"SELECT name FROM sqlite_master WHERE type='table'").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).
+			AddRow(userPreferencesTableName).
+			AddRow(identityVerificationTokensTableName).
+			AddRow(totpSecretsTableName).
+			AddRow(u2fDeviceHandlesTableName).
+			AddRow(authenticationLogsTableName).
+			AddRow(configTableName).
+			AddRow(accessGrantsTableName).
+			AddRow(sessionGenerationsTableName).
+			AddRow(sessionsTableName).
+			AddRow(activeSessionsTableName))
+
 	args := []driver.Value{"schema", "version"}
 	mock.ExpectQuery(
 		fmt.Sprintf("SELECT value FROM %s WHERE category=\\? AND key_name=\\?", configTableName)).
 		WithArgs(args...).
 		WillReturnRows(sqlmock.NewRows([]string{"value"}).
-			AddRow("1"))
+			AddRow(currentSchemaMockSchemaVersion))
 
 	err := provider.initialize(provider.db)
 	assert.NoError(t, err)
@@ -174,7 +393,11 @@ func TestSQLProviderMethodsPreferred(t *testing.T) {
 			AddRow(totpSecretsTableName).
 			AddRow(u2fDeviceHandlesTableName).
 			AddRow(authenticationLogsTableName).
-			AddRow(configTableName))
+			AddRow(configTableName).
+			AddRow(accessGrantsTableName).
+			AddRow(sessionGenerationsTableName).
+			AddRow(sessionsTableName).
+			AddRow(activeSessionsTableName))
 
 	args := []driver.Value{"schema", "version"}
 	mock.ExpectQuery(
@@ -225,7 +448,11 @@ func TestSQLProviderMethodsTOTP(t *testing.T) {
 			AddRow(totpSecretsTableName).
 			AddRow(u2fDeviceHandlesTableName).
 			AddRow(authenticationLogsTableName).
-			AddRow(configTableName))
+			AddRow(configTableName).
+			AddRow(accessGrantsTableName).
+			AddRow(sessionGenerationsTableName).
+			AddRow(sessionsTableName).
+			AddRow(activeSessionsTableName))
 
 	args := []driver.Value{"schema", "version"}
 	mock.ExpectQuery(
@@ -287,7 +514,11 @@ func TestSQLProviderMethodsU2F(t *testing.T) {
 			AddRow(totpSecretsTableName).
 			AddRow(u2fDeviceHandlesTableName).
 			AddRow(authenticationLogsTableName).
-			AddRow(configTableName))
+			AddRow(configTableName).
+			AddRow(accessGrantsTableName).
+			AddRow(sessionGenerationsTableName).
+			AddRow(sessionsTableName).
+			AddRow(activeSessionsTableName))
 
 	args := []driver.Value{"schema", "version"}
 	mock.ExpectQuery(
@@ -348,7 +579,11 @@ func TestSQLProviderMethodsIdentityVerificationTokens(t *testing.T) {
 			AddRow(totpSecretsTableName).
 			AddRow(u2fDeviceHandlesTableName).
 			AddRow(authenticationLogsTableName).
-			AddRow(configTableName))
+			AddRow(configTableName).
+			AddRow(accessGrantsTableName).
+			AddRow(sessionGenerationsTableName).
+			AddRow(sessionsTableName).
+			AddRow(activeSessionsTableName))
 
 	args := []driver.Value{"schema", "version"}
 	mock.ExpectQuery(
@@ -398,3 +633,308 @@ func TestSQLProviderMethodsIdentityVerificationTokens(t *testing.T) {
 	assert.NoError(t, err)
 	assert.False(t, valid)
 }
+
+func TestSQLProviderMethodsAccessGrants(t *testing.T) {
+	provider, mock := NewSQLMockProvider()
+
+	mock.ExpectQuery(
+		"SELECT name FROM sqlite_master WHERE type='table'").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).
+			AddRow(userPreferencesTableName).
+			AddRow(identityVerificationTokensTableName).
+			AddRow(totpSecretsTableName).
+			AddRow(u2fDeviceHandlesTableName).
+			AddRow(authenticationLogsTableName).
+			AddRow(configTableName).
+			AddRow(accessGrantsTableName).
+			AddRow(sessionGenerationsTableName).
+			AddRow(sessionsTableName).
+			AddRow(activeSessionsTableName))
+
+	mock.ExpectQuery(
+		fmt.Sprintf("SELECT value FROM %s WHERE category=\\? AND key_name=\\?", configTableName)).
+		WithArgs("schema", "version").
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).
+			AddRow(currentSchemaMockSchemaVersion))
+
+	err := provider.initialize(provider.db)
+	assert.NoError(t, err)
+
+	grant := models.AccessGrant{
+		ID:        "abc123",
+		Subject:   "user:bob",
+		Domain:    "app.example.com",
+		Resource:  "^/api/",
+		Policy:    "one_factor",
+		ExpiresAt: time.Unix(1577880000, 0),
+	}
+
+	mock.ExpectExec(
+		fmt.Sprintf("INSERT INTO %s \\(id, subject, domain, resource, policy, expires_at\\) VALUES \\(\\?, \\?, \\?, \\?, \\?, \\?\\)", accessGrantsTableName)).
+		WithArgs(grant.ID, grant.Subject, grant.Domain, grant.Resource, grant.Policy, grant.ExpiresAt.Unix()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = provider.SaveAccessGrant(grant)
+	assert.NoError(t, err)
+
+	mock.ExpectQuery(
+		fmt.Sprintf("SELECT id, subject, domain, resource, policy, expires_at FROM %s WHERE expires_at>\\? ORDER BY expires_at", accessGrantsTableName)).
+		WithArgs(1577870000).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "subject", "domain", "resource", "policy", "expires_at"}).
+			AddRow(grant.ID, grant.Subject, grant.Domain, grant.Resource, grant.Policy, grant.ExpiresAt.Unix()))
+
+	grants, err := provider.LoadActiveAccessGrants(time.Unix(1577870000, 0))
+	assert.NoError(t, err)
+	require.Len(t, grants, 1)
+	assert.Equal(t, grant, grants[0])
+
+	mock.ExpectExec(
+		fmt.Sprintf("DELETE FROM %s WHERE id=\\?", accessGrantsTableName)).
+		WithArgs(grant.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	deleted, err := provider.DeleteAccessGrant(grant.ID)
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+
+	mock.ExpectExec(
+		fmt.Sprintf("DELETE FROM %s WHERE id=\\?", accessGrantsTableName)).
+		WithArgs("unknown").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	deleted, err = provider.DeleteAccessGrant("unknown")
+	assert.NoError(t, err)
+	assert.False(t, deleted)
+}
+
+func TestSQLProviderMethodsSessionGenerations(t *testing.T) {
+	provider, mock := NewSQLMockProvider()
+
+	mock.ExpectQuery(
+		"SELECT name FROM sqlite_master WHERE type='table'").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).
+			AddRow(userPreferencesTableName).
+			AddRow(identityVerificationTokensTableName).
+			AddRow(totpSecretsTableName).
+			AddRow(u2fDeviceHandlesTableName).
+			AddRow(authenticationLogsTableName).
+			AddRow(configTableName).
+			AddRow(accessGrantsTableName).
+			AddRow(sessionGenerationsTableName).
+			AddRow(sessionsTableName).
+			AddRow(activeSessionsTableName))
+
+	mock.ExpectQuery(
+		fmt.Sprintf("SELECT value FROM %s WHERE category=\\? AND key_name=\\?", configTableName)).
+		WithArgs("schema", "version").
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).
+			AddRow(currentSchemaMockSchemaVersion))
+
+	err := provider.initialize(provider.db)
+	assert.NoError(t, err)
+
+	mock.ExpectQuery(
+		fmt.Sprintf("SELECT generation FROM %s WHERE username=\\?", sessionGenerationsTableName)).
+		WithArgs(unitTestUser).
+		WillReturnRows(sqlmock.NewRows([]string{"generation"}))
+
+	generation, err := provider.LoadSessionGeneration(unitTestUser)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, generation)
+
+	mock.ExpectExec(
+		fmt.Sprintf("INSERT INTO %s \\(username, generation\\) VALUES \\(\\?, 1\\) ON CONFLICT \\(username\\) DO UPDATE SET generation=generation\\+1", sessionGenerationsTableName)).
+		WithArgs(unitTestUser).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = provider.IncrementSessionGeneration(unitTestUser)
+	assert.NoError(t, err)
+
+	mock.ExpectQuery(
+		fmt.Sprintf("SELECT generation FROM %s WHERE username=\\?", sessionGenerationsTableName)).
+		WithArgs(unitTestUser).
+		WillReturnRows(sqlmock.NewRows([]string{"generation"}).
+			AddRow(1))
+
+	generation, err = provider.LoadSessionGeneration(unitTestUser)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, generation)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSQLProviderMethodsSessions(t *testing.T) {
+	provider, mock := NewSQLMockProvider()
+
+	mock.ExpectQuery(
+		"SELECT name FROM sqlite_master WHERE type='table'").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).
+			AddRow(userPreferencesTableName).
+			AddRow(identityVerificationTokensTableName).
+			AddRow(totpSecretsTableName).
+			AddRow(u2fDeviceHandlesTableName).
+			AddRow(authenticationLogsTableName).
+			AddRow(configTableName).
+			AddRow(accessGrantsTableName).
+			AddRow(sessionGenerationsTableName).
+			AddRow(sessionsTableName).
+			AddRow(activeSessionsTableName))
+
+	mock.ExpectQuery(
+		fmt.Sprintf("SELECT value FROM %s WHERE category=\\? AND key_name=\\?", configTableName)).
+		WithArgs("schema", "version").
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).
+			AddRow(currentSchemaMockSchemaVersion))
+
+	err := provider.initialize(provider.db)
+	assert.NoError(t, err)
+
+	now := time.Unix(1577880000, 0)
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(id, data, expires_at\\) VALUES \\(\\?, \\?, \\?\\)", sessionsTableName)).
+		WithArgs("abc", "ZGF0YQ==", int64(1577883600)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = provider.SaveSession("abc", []byte("data"), now.Add(time.Hour))
+	assert.NoError(t, err)
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(id, data, expires_at\\) VALUES \\(\\?, \\?, \\?\\)", sessionsTableName)).
+		WithArgs("def", "ZGF0YQ==", int64(0)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// A zero expiry time means the session never expires.
+	err = provider.SaveSession("def", []byte("data"), time.Time{})
+	assert.NoError(t, err)
+
+	mock.ExpectQuery(
+		fmt.Sprintf("SELECT data FROM %s WHERE id=\\? AND \\(expires_at=0 OR expires_at>\\?\\)", sessionsTableName)).
+		WithArgs("abc", now.Unix()).
+		WillReturnRows(sqlmock.NewRows([]string{"data"}).
+			AddRow("ZGF0YQ=="))
+
+	data, err := provider.LoadSession("abc", now)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("data"), data)
+
+	mock.ExpectExec(
+		fmt.Sprintf("UPDATE %s SET id=\\?, expires_at=\\? WHERE id=\\?", sessionsTableName)).
+		WithArgs("ghi", int64(1577887200), "abc").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = provider.RegenerateSession("abc", "ghi", now.Add(2*time.Hour))
+	assert.NoError(t, err)
+
+	mock.ExpectQuery(
+		fmt.Sprintf("SELECT data FROM %s WHERE id=\\? AND \\(expires_at=0 OR expires_at>\\?\\)", sessionsTableName)).
+		WithArgs("abc", now.Unix()).
+		WillReturnRows(sqlmock.NewRows([]string{"data"}))
+
+	data, err = provider.LoadSession("abc", now)
+	assert.NoError(t, err)
+	assert.Nil(t, data)
+
+	mock.ExpectQuery(
+		fmt.Sprintf("SELECT COUNT\\(\\*\\) FROM %s WHERE expires_at=0 OR expires_at>\\?", sessionsTableName)).
+		WithArgs(now.Unix()).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).
+			AddRow(2))
+
+	count, err := provider.CountSessions(now)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	mock.ExpectQuery(
+		fmt.Sprintf("SELECT data FROM %s WHERE expires_at=0 OR expires_at>\\?", sessionsTableName)).
+		WithArgs(now.Unix()).
+		WillReturnRows(sqlmock.NewRows([]string{"data"}).
+			AddRow("ZGF0YQ==").
+			AddRow("b3RoZXI="))
+
+	sessions, err := provider.LoadSessions(now)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("data"), []byte("other")}, sessions)
+
+	mock.ExpectExec(
+		fmt.Sprintf("DELETE FROM %s WHERE id=\\?", sessionsTableName)).
+		WithArgs("ghi").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = provider.DeleteSession("ghi")
+	assert.NoError(t, err)
+
+	mock.ExpectExec(
+		fmt.Sprintf("DELETE FROM %s WHERE expires_at<>0 AND expires_at<=\\?", sessionsTableName)).
+		WithArgs(now.Unix()).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	mock.ExpectExec(
+		fmt.Sprintf("DELETE FROM %s WHERE expires_at<>0 AND expires_at<=\\?", activeSessionsTableName)).
+		WithArgs(now.Unix()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	deleted, err := provider.DeleteExpiredSessions(now)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), deleted)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSQLProviderMethodsActiveSessions(t *testing.T) {
+	provider, mock := NewSQLMockProvider()
+
+	mock.ExpectQuery(
+		"SELECT name FROM sqlite_master WHERE type='table'").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).
+			AddRow(userPreferencesTableName).
+			AddRow(identityVerificationTokensTableName).
+			AddRow(totpSecretsTableName).
+			AddRow(u2fDeviceHandlesTableName).
+			AddRow(authenticationLogsTableName).
+			AddRow(configTableName).
+			AddRow(accessGrantsTableName).
+			AddRow(sessionGenerationsTableName).
+			AddRow(sessionsTableName).
+			AddRow(activeSessionsTableName))
+
+	mock.ExpectQuery(
+		fmt.Sprintf("SELECT value FROM %s WHERE category=\\? AND key_name=\\?", configTableName)).
+		WithArgs("schema", "version").
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).
+			AddRow(currentSchemaMockSchemaVersion))
+
+	err := provider.initialize(provider.db)
+	assert.NoError(t, err)
+
+	now := time.Unix(1577880000, 0)
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(username, id, data, expires_at\\) VALUES \\(\\?, \\?, \\?, \\?\\)", activeSessionsTableName)).
+		WithArgs(unitTestUser, "abc", "{}", int64(1577883600)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = provider.SaveActiveSession(unitTestUser, "abc", []byte("{}"), now.Add(time.Hour))
+	assert.NoError(t, err)
+
+	mock.ExpectQuery(
+		fmt.Sprintf("SELECT data FROM %s WHERE username=\\? AND \\(expires_at=0 OR expires_at>\\?\\)", activeSessionsTableName)).
+		WithArgs(unitTestUser, now.Unix()).
+		WillReturnRows(sqlmock.NewRows([]string{"data"}).
+			AddRow("{}").
+			AddRow("[]"))
+
+	entries, err := provider.LoadActiveSessions(unitTestUser, now)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("{}"), []byte("[]")}, entries)
+
+	mock.ExpectExec(
+		fmt.Sprintf("DELETE FROM %s WHERE username=\\? AND id=\\?", activeSessionsTableName)).
+		WithArgs(unitTestUser, "abc").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = provider.DeleteActiveSession(unitTestUser, "abc")
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -74,3 +74,39 @@ func (p *SQLProvider) upgradeSchemaToVersion001(tx transaction, tables []string)
 
 	return nil
 }
+
+// upgradeSchemaToVersion002 upgrades the schema to version 2.
+func (p *SQLProvider) upgradeSchemaToVersion002(tx transaction, tables []string) error {
+	version := SchemaVersion(2)
+
+	err := p.upgradeCreateTableStatements(tx, p.sqlUpgradesCreateTableStatements[version], tables)
+	if err != nil {
+		return err
+	}
+
+	return p.upgradeFinalize(tx, version)
+}
+
+// upgradeSchemaToVersion003 upgrades the schema to version 3.
+func (p *SQLProvider) upgradeSchemaToVersion003(tx transaction, tables []string) error {
+	version := SchemaVersion(3)
+
+	err := p.upgradeCreateTableStatements(tx, p.sqlUpgradesCreateTableStatements[version], tables)
+	if err != nil {
+		return err
+	}
+
+	return p.upgradeFinalize(tx, version)
+}
+
+// upgradeSchemaToVersion004 upgrades the schema to version 4.
+func (p *SQLProvider) upgradeSchemaToVersion004(tx transaction, tables []string) error {
+	version := SchemaVersion(4)
+
+	err := p.upgradeCreateTableStatements(tx, p.sqlUpgradesCreateTableStatements[version], tables)
+	if err != nil {
+		return err
+	}
+
+	return p.upgradeFinalize(tx, version)
+}
@@ -0,0 +1,29 @@
+package templates
+
+import (
+	"text/template"
+)
+
+// PlainTextSessionEvictedEmailTemplate the template of email that the user will receive when one of their sessions is
+// closed because they reached the maximum number of concurrent sessions.
+var PlainTextSessionEvictedEmailTemplate *template.Template
+
+func init() {
+	t, err := template.New("text_session_evicted_email_template").Parse(emailSessionEvictedPlainTextContent)
+	if err != nil {
+		panic(err)
+	}
+
+	PlainTextSessionEvictedEmailTemplate = t
+}
+
+const emailSessionEvictedPlainTextContent = `
+This email has been sent to you because you logged in while having reached the maximum number of active sessions.
+The following session has been closed to make room for the new one:
+
+Opened: {{.created}}
+IP address: {{.ip}}
+Browser: {{.userAgent}}
+
+If you did not log in recently your credentials might have been compromised. You should reset your password and contact an administrator.
+`
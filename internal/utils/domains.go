@@ -0,0 +1,17 @@
+package utils
+
+import "strings"
+
+// GetMostSpecificDomain returns the index of the longest of the domains the host is under, -1 if it is under none of
+// them.
+func GetMostSpecificDomain(host string, domains []string) int {
+	index := -1
+
+	for i, domain := range domains {
+		if strings.HasSuffix(host, domain) && (index == -1 || len(domain) > len(domains[index])) {
+			index = i
+		}
+	}
+
+	return index
+}
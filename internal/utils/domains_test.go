@@ -0,0 +1,18 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShouldGetMostSpecificDomain(t *testing.T) {
+	domains := []string{"example.com", "internal.example.com", "example.org"}
+
+	assert.Equal(t, 0, GetMostSpecificDomain("app.example.com", domains))
+	assert.Equal(t, 1, GetMostSpecificDomain("app.internal.example.com", domains))
+	assert.Equal(t, 1, GetMostSpecificDomain("internal.example.com", domains))
+	assert.Equal(t, 2, GetMostSpecificDomain("example.org", domains))
+	assert.Equal(t, -1, GetMostSpecificDomain("example.net", domains))
+	assert.Equal(t, -1, GetMostSpecificDomain("example.com", nil))
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"net"
+	"strings"
+)
+
+// ParseNetwork parses a network in CIDR notation or a single IP address into a network.
+func ParseNetwork(network string) (*net.IPNet, error) {
+	if !strings.Contains(network, "/") {
+		ip := net.ParseIP(network)
+		if ip == nil {
+			return nil, &net.ParseError{Type: "IP address", Text: network}
+		}
+
+		if ip.To4() != nil {
+			return &net.IPNet{IP: ip.To4(), Mask: net.CIDRMask(32, 32)}, nil
+		}
+
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+	}
+
+	_, ipNet, err := net.ParseCIDR(network)
+
+	return ipNet, err
+}
+
+// ParseNetworks parses a list of networks given as IPs or CIDRs, the invalid ones are skipped since the networks of
+// the configuration are checked by the validator.
+func ParseNetworks(networks []string) []*net.IPNet {
+	ipNets := make([]*net.IPNet, 0, len(networks))
+
+	for _, network := range networks {
+		ipNet, err := ParseNetwork(network)
+		if err != nil {
+			continue
+		}
+
+		ipNets = append(ipNets, ipNet)
+	}
+
+	return ipNets
+}
+
+// IsIPInNetworks check whether the IP belongs to one of the networks.
+func IsIPInNetworks(ip net.IP, networks []*net.IPNet) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
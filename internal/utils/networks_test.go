@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShouldParseNetworks(t *testing.T) {
+	networks := ParseNetworks([]string{"10.0.0.0/8", "192.168.1.1", "fec0::1", "invalid", "172.16.0.0/33"})
+	require.Len(t, networks, 3)
+
+	assert.Equal(t, "10.0.0.0/8", networks[0].String())
+	assert.Equal(t, "192.168.1.1/32", networks[1].String())
+	assert.Equal(t, "fec0::1/128", networks[2].String())
+
+	assert.True(t, IsIPInNetworks(net.ParseIP("10.1.2.3"), networks))
+	assert.True(t, IsIPInNetworks(net.ParseIP("192.168.1.1"), networks))
+	assert.False(t, IsIPInNetworks(net.ParseIP("192.168.1.2"), networks))
+	assert.False(t, IsIPInNetworks(net.ParseIP("10.0.0.1"), nil))
+}
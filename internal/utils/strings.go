@@ -76,6 +76,23 @@ func IsStringSlicesDifferent(a, b []string) (different bool) {
 	return false
 }
 
+// IsStringSliceMapsDifferent checks two maps of slices of strings and returns true if they do not have the same keys
+// or if the slices of a key are different, otherwise returns false.
+func IsStringSliceMapsDifferent(a, b map[string][]string) (different bool) {
+	if len(a) != len(b) {
+		return true
+	}
+
+	for key, values := range a {
+		other, ok := b[key]
+		if !ok || IsStringSlicesDifferent(values, other) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // StringSlicesDelta takes a before and after []string and compares them returning a added and removed []string.
 func StringSlicesDelta(before, after []string) (added, removed []string) {
 	for _, s := range before {
@@ -70,6 +70,23 @@ func TestShouldNotFindSliceDifferences(t *testing.T) {
 	assert.False(t, diff)
 }
 
+func TestShouldFindSliceMapsDifferences(t *testing.T) {
+	a := map[string][]string{"department": {"finance"}, "title": {"manager"}}
+
+	assert.True(t, IsStringSliceMapsDifferent(a, map[string][]string{"department": {"finance"}}))
+	assert.True(t, IsStringSliceMapsDifferent(a, map[string][]string{"department": {"finance"}, "title": {"engineer"}}))
+	assert.True(t, IsStringSliceMapsDifferent(a, map[string][]string{"department": {"finance"}, "location": {"manager"}}))
+	assert.True(t, IsStringSliceMapsDifferent(nil, a))
+}
+
+func TestShouldNotFindSliceMapsDifferences(t *testing.T) {
+	a := map[string][]string{"department": {"finance"}, "title": {"manager"}}
+	b := map[string][]string{"title": {"manager"}, "department": {"finance"}}
+
+	assert.False(t, IsStringSliceMapsDifferent(a, b))
+	assert.False(t, IsStringSliceMapsDifferent(nil, map[string][]string{}))
+}
+
 func TestShouldFindStringInSliceContains(t *testing.T) {
 	a := "abc"
 	b := []string{"abc", "onetwothree"}
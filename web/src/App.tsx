@@ -11,12 +11,14 @@ import {
     ResetPasswordStep1Route, RegisterSecurityKeyRoute,
     RegisterOneTimePasswordRoute,
     LogoutRoute,
+    AccessDeniedRoute,
 } from "./Routes";
 import LoginPortal from './views/LoginPortal/LoginPortal';
 import NotificationsContext from './hooks/NotificationsContext';
 import { Notification } from './models/Notifications';
 import NotificationBar from './components/NotificationBar';
 import SignOut from './views/LoginPortal/SignOut/SignOut';
+import AccessDenied from './views/AccessDenied/AccessDenied';
 import { getRememberMe, getResetPassword } from './utils/Configuration';
 import '@fortawesome/fontawesome-svg-core/styles.css'
 import { config as faConfig } from '@fortawesome/fontawesome-svg-core';
@@ -47,6 +49,9 @@ const App: React.FC = () => {
                     <Route path={LogoutRoute} exact>
                         <SignOut />
                     </Route>
+                    <Route path={AccessDeniedRoute} exact>
+                        <AccessDenied />
+                    </Route>
                     <Route path={FirstFactorRoute}>
                         <LoginPortal
                             rememberMe={getRememberMe()}
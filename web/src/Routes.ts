@@ -11,4 +11,5 @@ export const ResetPasswordStep1Route = "/reset-password/step1";
 export const ResetPasswordStep2Route = "/reset-password/step2";
 export const RegisterSecurityKeyRoute = "/security-key/register";
 export const RegisterOneTimePasswordRoute = "/one-time-password/register";
-export const LogoutRoute = "/logout";
\ No newline at end of file
+export const LogoutRoute = "/logout";
+export const AccessDeniedRoute = "/denied";
\ No newline at end of file
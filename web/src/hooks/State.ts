@@ -1,6 +1,11 @@
+import { useCallback } from "react";
+
 import { getState } from "../services/State";
 import { useRemoteCall } from "./RemoteCall";
+import { useRedirectionURL } from "./RedirectionURL";
 
 export function useAutheliaState() {
-    return useRemoteCall(getState, []);
+    const redirectionURL = useRedirectionURL();
+    const fetchState = useCallback(() => getState(redirectionURL), [redirectionURL]);
+    return useRemoteCall(fetchState, [redirectionURL]);
 }
\ No newline at end of file
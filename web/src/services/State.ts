@@ -1,5 +1,6 @@
 import { Get } from "./Client";
 import { StatePath } from "./Api";
+import { Method2FA } from "./UserPreferences";
 
 export enum AuthenticationLevel {
     Unauthenticated = 0,
@@ -10,8 +11,14 @@ export enum AuthenticationLevel {
 export interface AutheliaState {
     username: string;
     authentication_level: AuthenticationLevel
+    required_methods?: Method2FA[];
 }
 
-export async function getState(): Promise<AutheliaState> {
+// The authentication level is the one of the session for the target URL when it is provided, it is lower when the
+// user must step up to access it, and the required methods are the second factor methods allowed to access it.
+export async function getState(targetURL?: string): Promise<AutheliaState> {
+    if (targetURL) {
+        return Get<AutheliaState>(`${StatePath}?rd=${encodeURIComponent(targetURL)}`);
+    }
     return Get<AutheliaState>(StatePath);
 }
\ No newline at end of file
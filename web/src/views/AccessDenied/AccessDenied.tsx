@@ -0,0 +1,79 @@
+import React, { useEffect } from "react";
+import { Grid, Typography, Button, makeStyles } from "@material-ui/core";
+import { useHistory } from "react-router";
+import LoginLayout from "../../layouts/LoginLayout";
+import LoadingPage from "../LoadingPage/LoadingPage";
+import { useAutheliaState } from "../../hooks/State";
+import { useRedirectionURL } from "../../hooks/RedirectionURL";
+import { useNotifications } from "../../hooks/NotificationsContext";
+import { AuthenticationLevel } from "../../services/State";
+import { LogoutRoute, FirstFactorRoute } from "../../Routes";
+import { getBasePath } from "../../utils/BasePath";
+
+export interface Props { }
+
+const AccessDenied = function (props: Props) {
+    const style = useStyles();
+    const history = useHistory();
+    const redirectionURL = useRedirectionURL();
+    const { createErrorNotification } = useNotifications();
+    const [state, fetchState, , fetchStateError] = useAutheliaState();
+
+    useEffect(() => { fetchState() }, [fetchState]);
+
+    useEffect(() => {
+        if (fetchStateError) {
+            createErrorNotification("There was an issue fetching the current user state");
+        }
+    }, [fetchStateError, createErrorNotification]);
+
+    // Sign out and come back to the login portal which redirects to the denied resource once signed in.
+    const handleSwitchUserClick = () => {
+        const redirectionSuffix = redirectionURL
+            ? `?rd=${encodeURIComponent(redirectionURL)}`
+            : "";
+        const loginURL = `${window.location.origin}${getBasePath()}${FirstFactorRoute}${redirectionSuffix}`;
+        history.push(`${LogoutRoute}?rd=${encodeURIComponent(loginURL)}`);
+    }
+
+    if (!state) {
+        return <LoadingPage />
+    }
+
+    const signedIn = state.authentication_level > AuthenticationLevel.Unauthenticated;
+
+    return (
+        <LoginLayout id="access-denied-stage" title="Access denied" showBrand>
+            <Grid container>
+                <Grid item xs={12}>
+                    <Typography className={style.typo}>
+                        {signedIn
+                            ? <span>You are signed in as <b id="access-denied-username">{state.username}</b>, which is not allowed to access</span>
+                            : <span>You are not allowed to access</span>}
+                    </Typography>
+                    {redirectionURL
+                        ? <Typography className={style.typo} id="access-denied-url">{redirectionURL}</Typography>
+                        : <Typography className={style.typo}>the requested resource.</Typography>}
+                </Grid>
+                {signedIn ? <Grid item xs={12}>
+                    <Button
+                        variant="contained"
+                        color="primary"
+                        onClick={handleSwitchUserClick}
+                        id="switch-user-button">
+                        Switch user
+                    </Button>
+                </Grid> : null}
+            </Grid>
+        </LoginLayout>
+    )
+}
+
+export default AccessDenied
+
+const useStyles = makeStyles(theme => ({
+    typo: {
+        padding: theme.spacing(),
+        wordBreak: "break-all",
+    }
+}))
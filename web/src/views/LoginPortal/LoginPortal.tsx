@@ -9,6 +9,7 @@ import {
 import { useAutheliaState } from "../../hooks/State";
 import LoadingPage from "../LoadingPage/LoadingPage";
 import { AuthenticationLevel } from "../../services/State";
+import { Method2FA, toEnum } from "../../services/UserPreferences";
 import { useNotifications } from "../../hooks/NotificationsContext";
 import { useRedirectionURL } from "../../hooks/RedirectionURL";
 import { useUserPreferences as userUserInfo } from "../../hooks/UserInfo";
@@ -87,9 +88,15 @@ const LoginPortal = function (props: Props) {
                 if (!configuration.second_factor_enabled) {
                     redirect(AuthenticatedRoute);
                 } else {
-                    if (userInfo.method === SecondFactorMethod.U2F) {
+                    // The preferred method is replaced by the first allowed one when the target URL requires another.
+                    const allowedMethods = getAllowedMethods(configuration.available_methods, state.required_methods);
+                    const method = allowedMethods.size === 0 || allowedMethods.has(userInfo.method)
+                        ? userInfo.method
+                        : allowedMethods.values().next().value;
+
+                    if (method === SecondFactorMethod.U2F) {
                         redirect(`${SecondFactorU2FRoute}${redirectionSuffix}`);
-                    } else if (userInfo.method === SecondFactorMethod.MobilePush) {
+                    } else if (method === SecondFactorMethod.MobilePush) {
                         redirect(`${SecondFactorPushRoute}${redirectionSuffix}`);
                     } else {
                         redirect(`${SecondFactorTOTPRoute}${redirectionSuffix}`);
@@ -130,7 +137,10 @@ const LoginPortal = function (props: Props) {
                 {state && userInfo && configuration ? <SecondFactorForm
                     authenticationLevel={state.authentication_level}
                     userInfo={userInfo}
-                    configuration={configuration}
+                    configuration={{
+                        ...configuration,
+                        available_methods: getAllowedMethods(configuration.available_methods, state.required_methods),
+                    }}
                     onMethodChanged={() => fetchUserInfo()}
                     onAuthenticationSuccess={handleAuthSuccess} /> : null}
             </Route>
@@ -146,6 +156,19 @@ const LoginPortal = function (props: Props) {
 
 export default LoginPortal
 
+// The available methods restricted to the ones allowed to access the target URL, all of them when any is allowed.
+function getAllowedMethods(availableMethods: Set<SecondFactorMethod>, requiredMethods?: Method2FA[]) {
+    if (!requiredMethods || requiredMethods.length === 0) {
+        return availableMethods;
+    }
+
+    const allowedMethods = new Set<SecondFactorMethod>();
+    requiredMethods.map(toEnum)
+        .filter(method => availableMethods.has(method))
+        .forEach(method => allowedMethods.add(method));
+    return allowedMethods;
+}
+
 interface ComponentOrLoadingProps {
     ready: boolean;
 